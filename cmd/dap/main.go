@@ -0,0 +1,59 @@
+// Command dap is a Debug Adapter Protocol server for 6502 programs. Editors
+// such as VS Code start it and talk to it over stdin and stdout, or connect
+// to it over TCP with -listen.
+//
+// Usage:
+//
+//	dap [-listen host:port] [-v]
+//
+// Clients launch a program with arguments such as
+//
+//	{
+//	    "program": "build/main.bin",
+//	    "loadAddress": "$8000",
+//	    "cpu": "65c02",
+//	    "debugInfo": "build/main.dbg",
+//	    "stopOnEntry": true
+//	}
+//
+// where debugInfo is the output of ld65 --dbgfile, used to map breakpoints
+// and stack frames to source lines. Without start, execution begins at the
+// reset vector.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/dap"
+)
+
+func main() {
+	listen := flag.String("listen", "", "serve clients on this TCP address, e.g. localhost:4711, instead of stdio")
+	verbose := flag.Bool("v", false, "log every message to stderr")
+	flag.Parse()
+
+	s := &dap.Server{}
+	if *verbose {
+		logger := log.New(os.Stderr, "", log.Ltime|log.Lmicroseconds)
+		s.Logf = logger.Printf
+	}
+
+	var err error
+	if *listen != "" {
+		fmt.Fprintf(os.Stderr, "DAP server listening on %s\n", *listen)
+		err = s.ListenAndServe(*listen)
+	} else {
+		err = s.ServeConn(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout})
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dap:", err)
+		os.Exit(1)
+	}
+}
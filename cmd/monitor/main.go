@@ -0,0 +1,120 @@
+// Command monitor is an interactive machine-language monitor in the style of
+// Wozmon and the VICE monitor. It runs a CPU of either variant against 64K of
+// RAM and lets you load binaries, examine and deposit memory, disassemble,
+// assemble, single-step, set breakpoints and run until a break.
+//
+// Usage:
+//
+//	monitor [-cpu nmos|65c02] [-load file] [-addr hex] [-reset] [-gdb host:port]
+//
+// Type ? at the prompt for the list of commands. Addresses and values are
+// hex, with or without a leading $.
+//
+// With -gdb the monitor serves the loaded program to GDB remote protocol
+// clients on the given TCP address instead of reading commands.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/debugger"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/gdbstub"
+)
+
+func main() {
+	cpuName := flag.String("cpu", "nmos", "CPU variant: nmos or 65c02")
+	loadFile := flag.String("load", "", "binary file to load at startup")
+	loadAddr := flag.String("addr", "0200", "address to load the file at")
+	reset := flag.Bool("reset", false, "reset through the vector at $FFFC after loading")
+	gdbAddr := flag.String("gdb", "", "serve GDB remote protocol clients on this address, e.g. localhost:1234")
+	flag.Parse()
+
+	variant, err := parseVariant(*cpuName)
+	if err != nil {
+		fatal(err)
+	}
+	m, err := newMonitor(variant, os.Stdout)
+	if err != nil {
+		fatal(err)
+	}
+
+	if *loadFile != "" {
+		if err := m.exec("l " + *loadFile + " " + *loadAddr); err != nil {
+			fatal(err)
+		}
+		if !*reset {
+			m.exec("r pc=" + *loadAddr)
+		}
+	}
+	if *reset {
+		m.exec("reset")
+	}
+
+	if *gdbAddr != "" {
+		if err := serveGDB(m, *gdbAddr); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	// Ctrl-C stops a running "g" instead of exiting
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		for range interrupts {
+			m.interrupted.Store(true)
+		}
+	}()
+
+	fmt.Printf("%v monitor, ? for help\n", variant)
+	in := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Printf("(%04X) ", m.cpu.Registers().PC)
+		if !in.Scan() {
+			fmt.Println()
+			return
+		}
+		if err := m.exec(in.Text()); err != nil {
+			if errors.Is(err, errQuit) {
+				return
+			}
+			fmt.Println("error:", err)
+		}
+	}
+}
+
+// serveGDB hands the monitor's memory and registers to a GDB stub.
+func serveGDB(m *monitor, addr string) error {
+	d, err := debugger.New(m.cpu.GetVariant(), m.ram)
+	if err != nil {
+		return err
+	}
+	if err := d.CPU.Restore(m.cpu.Snapshot()); err != nil {
+		return err
+	}
+
+	fmt.Printf("%v GDB stub listening on %s\n", m.cpu.GetVariant(), addr)
+	return gdbstub.NewServer(d).ListenAndServe(addr)
+}
+
+func parseVariant(name string) (core.Variant, error) {
+	switch strings.ToLower(name) {
+	case "nmos", "6502":
+		return core.VariantNMOS, nil
+	case "65c02", "wdc", "cmos":
+		return core.VariantWDC65C02, nil
+	}
+	return 0, fmt.Errorf("unknown CPU %q (want nmos or 65c02)", name)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "monitor:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/asm"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/disasm"
+)
+
+// RAM is 64K of flat memory.
+type RAM struct {
+	memory [0x10000]byte
+}
+
+func (r *RAM) Read(addr uint16) byte {
+	return r.memory[addr]
+}
+
+func (r *RAM) Write(addr uint16, data byte) {
+	r.memory[addr] = data
+}
+
+// runSlice is how many cycles "g" runs between checks for an interrupt.
+const runSlice = 100000
+
+// monitor holds the state of a session. Addresses and values are typed in
+// hex, with or without a leading $.
+type monitor struct {
+	cpu    core.CPU
+	ram    *RAM
+	dis    *disasm.Disassembler
+	out    io.Writer
+	breaks map[uint16]bool
+
+	// interrupted is set from outside to stop a running "g"
+	interrupted atomic.Bool
+
+	// Where "m" and "d" continue when given no address
+	nextMem, nextDis uint16
+}
+
+func newMonitor(variant core.Variant, out io.Writer) (*monitor, error) {
+	ram := &RAM{}
+	cpu, err := core.NewCPU(variant, ram)
+	if err != nil {
+		return nil, err
+	}
+	dis, err := disasm.New(ram, variant)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &monitor{cpu: cpu, ram: ram, dis: dis, out: out, breaks: make(map[uint16]bool)}
+	cpu.SetBreakFunc(func(pc uint16) bool { return m.breaks[pc] })
+	return m, nil
+}
+
+type command struct {
+	usage string
+	help  string
+	run   func(m *monitor, args []string) error
+}
+
+var commands map[string]*command
+
+func init() {
+	commands = map[string]*command{
+		"r":     {"r [reg=val ...]", "show or set registers (A X Y SP PC P)", (*monitor).registers},
+		"m":     {"m [start [end]]", "dump memory", (*monitor).memory},
+		">":     {"> addr byte ...", "deposit bytes", (*monitor).deposit},
+		"d":     {"d [addr [count]]", "disassemble", (*monitor).disassemble},
+		"a":     {"a addr instruction", "assemble one instruction", (*monitor).assemble},
+		"z":     {"z [count]", "step instructions", (*monitor).step},
+		"g":     {"g [addr]", "run until a breakpoint or error (Ctrl-C stops)", (*monitor).goCmd},
+		"b":     {"b [addr]", "set a breakpoint, or list them", (*monitor).breakpoint},
+		"bd":    {"bd addr|*", "delete a breakpoint, or all of them", (*monitor).deleteBreakpoint},
+		"l":     {"l file addr", "load a binary file", (*monitor).load},
+		"reset": {"reset", "reset the CPU through the reset vector", (*monitor).reset},
+		"irq":   {"irq", "trigger an IRQ", func(m *monitor, _ []string) error { m.cpu.TriggerIRQ(); return nil }},
+		"nmi":   {"nmi", "trigger an NMI", func(m *monitor, _ []string) error { m.cpu.TriggerNMI(); return nil }},
+		"?":     {"?", "show this help", (*monitor).help},
+	}
+	commands["help"] = commands["?"]
+	commands["s"] = commands["z"]
+}
+
+// errQuit is returned by exec for the quit command.
+var errQuit = errors.New("quit")
+
+// exec runs one command line.
+func (m *monitor) exec(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	// ">addr" without a space is common in Wozmon-style input
+	name, args := strings.ToLower(fields[0]), fields[1:]
+	if len(name) > 1 && name[0] == '>' {
+		name, args = ">", append([]string{name[1:]}, args...)
+	}
+
+	switch name {
+	case "x", "q", "quit", "exit":
+		return errQuit
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q (? for help)", fields[0])
+	}
+	return cmd.run(m, args)
+}
+
+func (m *monitor) help(args []string) error {
+	names := make([]string, 0, len(commands))
+	for name, cmd := range commands {
+		if strings.HasPrefix(cmd.usage, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(m.out, "  %-20s %s\n", commands[name].usage, commands[name].help)
+	}
+	fmt.Fprintf(m.out, "  %-20s %s\n", "x", "exit")
+	return nil
+}
+
+// parseNum parses a hex number with an optional $ prefix.
+func parseNum(s string, max uint64) (uint64, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "$"), 16, 32)
+	if err != nil || v > max {
+		return 0, fmt.Errorf("bad value %q", s)
+	}
+	return v, nil
+}
+
+func parseAddr(s string) (uint16, error) {
+	v, err := parseNum(s, 0xFFFF)
+	return uint16(v), err
+}
+
+// flagString shows the status register as NV-BDIZC, upper case when set.
+func flagString(p byte) string {
+	const names = "nv-bdizc"
+	b := []byte(names)
+	for i := range b {
+		if p&(0x80>>i) != 0 && b[i] != '-' {
+			b[i] -= 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+func (m *monitor) printRegisters() {
+	r := m.cpu.Registers()
+	fmt.Fprintf(m.out, "PC=%04X A=%02X X=%02X Y=%02X SP=%02X P=%02X %s cycles=%d\n",
+		r.PC, r.A, r.X, r.Y, r.SP, r.Status, flagString(r.Status), m.cpu.Snapshot().TotalCycles)
+}
+
+func (m *monitor) registers(args []string) error {
+	r := m.cpu.Registers()
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("expected reg=value, got %q", arg)
+		}
+		if strings.EqualFold(name, "PC") {
+			pc, err := parseAddr(value)
+			if err != nil {
+				return err
+			}
+			r.PC = pc
+			continue
+		}
+
+		v, err := parseNum(value, 0xFF)
+		if err != nil {
+			return err
+		}
+		switch strings.ToUpper(name) {
+		case "A":
+			r.A = byte(v)
+		case "X":
+			r.X = byte(v)
+		case "Y":
+			r.Y = byte(v)
+		case "SP", "S":
+			r.SP = byte(v)
+		case "P":
+			r.Status = byte(v) | core.FlagUnused
+		default:
+			return fmt.Errorf("unknown register %q", name)
+		}
+	}
+	m.cpu.SetRegisters(r)
+	m.printRegisters()
+	return nil
+}
+
+func (m *monitor) memory(args []string) error {
+	start, end := m.nextMem, uint32(m.nextMem)+0x7F
+	if len(args) > 0 {
+		v, err := parseAddr(args[0])
+		if err != nil {
+			return err
+		}
+		start, end = v, uint32(v)+0x7F
+	}
+	if len(args) > 1 {
+		v, err := parseAddr(args[1])
+		if err != nil {
+			return err
+		}
+		end = uint32(v)
+	}
+	end = min(end, 0xFFFF)
+
+	for addr := uint32(start); addr <= end; addr += 16 {
+		var hex, text strings.Builder
+		for n := uint32(0); n < 16 && addr+n <= end; n++ {
+			b := m.ram.Read(uint16(addr + n))
+			fmt.Fprintf(&hex, "%02X ", b)
+			if b >= 0x20 && b < 0x7F {
+				text.WriteByte(b)
+			} else {
+				text.WriteByte('.')
+			}
+		}
+		fmt.Fprintf(m.out, "%04X  %-48s %s\n", addr, hex.String(), text.String())
+	}
+	m.nextMem = uint16(end + 1)
+	return nil
+}
+
+func (m *monitor) deposit(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: > addr byte ...")
+	}
+	addr, err := parseAddr(args[0])
+	if err != nil {
+		return err
+	}
+	for _, arg := range args[1:] {
+		v, err := parseNum(arg, 0xFF)
+		if err != nil {
+			return err
+		}
+		m.ram.Write(addr, byte(v))
+		addr++
+	}
+	return nil
+}
+
+func (m *monitor) disassemble(args []string) error {
+	addr, count := m.nextDis, 16
+	if len(args) > 0 {
+		v, err := parseAddr(args[0])
+		if err != nil {
+			return err
+		}
+		addr = v
+	}
+	if len(args) > 1 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 1 {
+			return fmt.Errorf("bad count %q", args[1])
+		}
+		count = v
+	}
+
+	for _, ins := range m.dis.DecodeRange(addr, count) {
+		m.printInstruction(ins)
+		addr = ins.Addr + uint16(ins.Len())
+	}
+	m.nextDis = addr
+	return nil
+}
+
+func (m *monitor) printInstruction(ins disasm.Instruction) {
+	marker := " "
+	if m.breaks[ins.Addr] {
+		marker = "*"
+	}
+	fmt.Fprintf(m.out, "%s%s\n", marker, ins.Format())
+}
+
+// assemble assembles one instruction at addr, for example "a 0200 lda #$01".
+func (m *monitor) assemble(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: a addr instruction")
+	}
+	addr, err := parseAddr(args[0])
+	if err != nil {
+		return err
+	}
+
+	src := fmt.Sprintf(".org $%04X\n%s", addr, strings.Join(args[1:], " "))
+	prog, err := asm.Assemble(m.cpu.GetVariant(), src)
+	if err != nil {
+		var asmErr *asm.Error
+		if errors.As(err, &asmErr) {
+			return errors.New(asmErr.Msg)
+		}
+		return err
+	}
+	prog.Load(m.ram)
+	m.printInstruction(m.dis.Decode(addr))
+	return nil
+}
+
+func (m *monitor) step(args []string) error {
+	count := 1
+	if len(args) > 0 {
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v < 1 {
+			return fmt.Errorf("bad count %q", args[0])
+		}
+		count = v
+	}
+
+	for n := 0; n < count; {
+		result, err := m.cpu.StepInstruction()
+		if errors.Is(err, core.ErrBreakpoint) {
+			continue // Stepping again executes the instruction
+		}
+		if err != nil {
+			return err
+		}
+		if result.Event != core.EventInstruction {
+			fmt.Fprintf(m.out, "%v\n", result.Event)
+		}
+		n++
+	}
+	m.printNext()
+	return nil
+}
+
+// printNext shows the next instruction and the registers.
+func (m *monitor) printNext() {
+	pc := m.cpu.Registers().PC
+	m.printInstruction(m.dis.Decode(pc))
+	m.printRegisters()
+	m.nextDis = pc
+}
+
+func (m *monitor) goCmd(args []string) error {
+	if len(args) > 0 {
+		addr, err := parseAddr(args[0])
+		if err != nil {
+			return err
+		}
+		r := m.cpu.Registers()
+		r.PC = addr
+		m.cpu.SetRegisters(r)
+	}
+
+	// Step off a breakpoint at the current PC before running
+	if m.breaks[m.cpu.Registers().PC] {
+		if _, err := m.cpu.StepInstruction(); err != nil && !errors.Is(err, core.ErrBreakpoint) {
+			return err
+		}
+	}
+
+	m.interrupted.Store(false)
+	var err error
+	for err == nil && !m.interrupted.Load() {
+		_, err = m.cpu.RunCycles(runSlice)
+	}
+
+	var stepErr *core.StepError
+	switch {
+	case err == nil:
+		fmt.Fprintln(m.out, "interrupted")
+	case errors.As(err, &stepErr) && stepErr.Err == core.ErrBreakpoint:
+		fmt.Fprintf(m.out, "break at $%04X\n", stepErr.PC)
+	default:
+		fmt.Fprintln(m.out, err)
+	}
+	m.printNext()
+	return nil
+}
+
+func (m *monitor) breakpoint(args []string) error {
+	if len(args) == 0 {
+		addrs := make([]int, 0, len(m.breaks))
+		for addr := range m.breaks {
+			addrs = append(addrs, int(addr))
+		}
+		sort.Ints(addrs)
+		for _, addr := range addrs {
+			fmt.Fprintf(m.out, "$%04X\n", addr)
+		}
+		return nil
+	}
+
+	for _, arg := range args {
+		addr, err := parseAddr(arg)
+		if err != nil {
+			return err
+		}
+		m.breaks[addr] = true
+	}
+	return nil
+}
+
+func (m *monitor) deleteBreakpoint(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: bd addr|*")
+	}
+	if args[0] == "*" {
+		clear(m.breaks)
+		return nil
+	}
+	addr, err := parseAddr(args[0])
+	if err != nil {
+		return err
+	}
+	if !m.breaks[addr] {
+		return fmt.Errorf("no breakpoint at $%04X", addr)
+	}
+	delete(m.breaks, addr)
+	return nil
+}
+
+func (m *monitor) load(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: l file addr")
+	}
+	addr, err := parseAddr(args[1])
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	return m.loadBytes(data, addr)
+}
+
+func (m *monitor) loadBytes(data []byte, addr uint16) error {
+	if int(addr)+len(data) > 0x10000 {
+		return fmt.Errorf("%d bytes at $%04X do not fit below $10000", len(data), addr)
+	}
+	copy(m.ram.memory[addr:], data)
+	fmt.Fprintf(m.out, "loaded %d bytes at $%04X\n", len(data), addr)
+	return nil
+}
+
+func (m *monitor) reset(args []string) error {
+	m.cpu.Reset()
+	m.printNext()
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// run executes commands and returns the output of the last one.
+func run(t *testing.T, m *monitor, lines ...string) string {
+	t.Helper()
+	buf := m.out.(*bytes.Buffer)
+	for _, line := range lines {
+		buf.Reset()
+		if err := m.exec(line); err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+	}
+	return buf.String()
+}
+
+func newTestMonitor(t *testing.T, variant core.Variant) *monitor {
+	t.Helper()
+	m, err := newMonitor(variant, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestDepositAndExamine(t *testing.T) {
+	m := newTestMonitor(t, core.VariantNMOS)
+
+	out := run(t, m, "> 0200 48 69 $21", "m 0200 0202")
+	if want := "0200  48 69 21"; !strings.HasPrefix(out, want) || !strings.HasSuffix(out, "Hi!\n") {
+		t.Errorf("got %q, want %q... Hi!", out, want)
+	}
+
+	// Wozmon-style deposit without a space, then "m" continues
+	out = run(t, m, ">0203 FF", "m")
+	if !strings.HasPrefix(out, "0203  FF ") {
+		t.Errorf("expected dump to continue at $0203, got %q", out)
+	}
+}
+
+func TestStepAndRegisters(t *testing.T) {
+	m := newTestMonitor(t, core.VariantNMOS)
+
+	run(t, m, "a 0200 ldx #$03", "a 0202 dex", "a 0203 bne $0202", "r pc=0200 a=ff")
+	out := run(t, m, "z")
+	if !strings.Contains(out, "0202  CA        DEX") || !strings.Contains(out, "X=03") {
+		t.Errorf("unexpected step output:\n%s", out)
+	}
+	if !strings.Contains(out, "A=FF") || !strings.Contains(out, "nv-BdIzc") {
+		t.Errorf("unexpected registers:\n%s", out)
+	}
+
+	out = run(t, m, "z 2")
+	if !strings.Contains(out, "PC=0202") || !strings.Contains(out, "X=02") {
+		t.Errorf("unexpected output after z 2:\n%s", out)
+	}
+}
+
+func TestBreakpoints(t *testing.T) {
+	m := newTestMonitor(t, core.VariantWDC65C02)
+
+	run(t, m, "a 0200 ldx #$03", "a 0202 dex", "a 0203 bne $0202", "a 0205 stp", "b 0203")
+	out := run(t, m, "g 0200")
+	if !strings.Contains(out, "break at $0203") || !strings.Contains(out, "X=02") {
+		t.Errorf("expected break at $0203 with X=02:\n%s", out)
+	}
+
+	// Continuing runs the instruction at the breakpoint and stops there again
+	out = run(t, m, "g")
+	if !strings.Contains(out, "break at $0203") || !strings.Contains(out, "X=01") {
+		t.Errorf("expected second break with X=01:\n%s", out)
+	}
+
+	if out := run(t, m, "b"); out != "$0203\n" {
+		t.Errorf("unexpected breakpoint list %q", out)
+	}
+	out = run(t, m, "bd *", "g")
+	if !strings.Contains(out, "CPU stopped at $0205") {
+		t.Errorf("expected STP to stop the run:\n%s", out)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	m := newTestMonitor(t, core.VariantNMOS)
+
+	path := filepath.Join(t.TempDir(), "prog.bin")
+	if err := os.WriteFile(path, []byte{0xA9, 0x42, 0x02}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run(t, m, "l "+path+" C000", "> FFFC 00 C0", "reset")
+	out := run(t, m, "d")
+	if !strings.HasPrefix(out, " C000  A9 42     LDA #$42\n C002  02        JAM\n") {
+		t.Errorf("unexpected disassembly:\n%s", out)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	m := newTestMonitor(t, core.VariantNMOS)
+
+	for _, line := range []string{"foo", "m xyz", "> 0200 100", "r q=1", "bd 1234", "a 0200 bra *", "l"} {
+		if err := m.exec(line); err == nil {
+			t.Errorf("%q: expected an error", line)
+		}
+	}
+	if err := m.exec("x"); !errors.Is(err, errQuit) {
+		t.Errorf("expected errQuit, got %v", err)
+	}
+}
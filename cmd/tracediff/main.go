@@ -0,0 +1,210 @@
+// Command tracediff compares execution traces in nestest.log format and
+// reports the first line where they diverge, with the lines before it.
+//
+// Usage:
+//
+//	tracediff [flags] got.log want.log
+//	tracediff [flags] -run program want.log
+//
+// With -run it loads and traces a program itself for as many instructions
+// as the reference log has lines. Raw binaries are loaded at -addr; iNES
+// files (such as nestest.nes) have their PRG ROM mapped at $8000, mirrored
+// at $C000 if it is 16K. To check against nestest.log:
+//
+//	tracediff -run nestest.nes -pc C000 nestest.log
+//
+// The exit status is 1 if the traces diverge.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/trace"
+)
+
+// RAM is 64K of memory.
+type RAM struct {
+	memory [0x10000]byte
+}
+
+func (r *RAM) Read(addr uint16) byte {
+	return r.memory[addr]
+}
+
+func (r *RAM) Write(addr uint16, data byte) {
+	r.memory[addr] = data
+}
+
+func main() {
+	cpuName := flag.String("cpu", "nmos", "CPU variant: nmos or 65c02")
+	program := flag.String("run", "", "trace this program instead of reading got.log")
+	loadAddr := flag.String("addr", "0200", "address to load a raw binary at")
+	startPC := flag.String("pc", "", "address to start at (default the reset vector)")
+	cycles := flag.Uint64("cycles", 7, "cycle count at the first instruction")
+	output := flag.String("o", "", "also write the trace to this file")
+	context := flag.Int("context", 5, "matching lines to show before the divergence")
+	ignoreDisasm := flag.Bool("ignore-disasm", false, "compare only PC, bytes, registers and cycles")
+	ignoreCycles := flag.Bool("ignore-cycles", false, "do not compare cycle counts")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: tracediff [flags] got.log want.log")
+		fmt.Fprintln(os.Stderr, "       tracediff [flags] -run program want.log")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var got io.Reader
+	var wantFile string
+	switch {
+	case *program != "" && flag.NArg() == 1:
+		wantFile = flag.Arg(0)
+		want, err := os.ReadFile(wantFile)
+		if err != nil {
+			fatal(err)
+		}
+		lines := 0
+		for _, line := range strings.Split(string(want), "\n") {
+			if strings.TrimSpace(line) != "" {
+				lines++
+			}
+		}
+		got, err = run(*cpuName, *program, *loadAddr, *startPC, *cycles, *output, lines)
+		if err != nil {
+			fatal(err)
+		}
+	case *program == "" && flag.NArg() == 2:
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		got, wantFile = f, flag.Arg(1)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	want, err := os.Open(wantFile)
+	if err != nil {
+		fatal(err)
+	}
+	defer want.Close()
+
+	d, err := trace.Diff(got, want, trace.DiffOptions{
+		Context:      *context,
+		IgnoreDisasm: *ignoreDisasm,
+		IgnoreCycles: *ignoreCycles,
+	})
+	if err != nil {
+		fatal(err)
+	}
+	if d != nil {
+		fmt.Print(d)
+		os.Exit(1)
+	}
+	fmt.Println("traces match")
+}
+
+// run traces n instructions of a program and returns the trace.
+func run(cpuName, program, loadAddr, startPC string, cycles uint64, output string, n int) (io.Reader, error) {
+	variant, err := parseVariant(cpuName)
+	if err != nil {
+		return nil, err
+	}
+	ram := &RAM{}
+	if err := load(ram, program, loadAddr); err != nil {
+		return nil, err
+	}
+	cpu, err := core.NewCPU(variant, ram)
+	if err != nil {
+		return nil, err
+	}
+	cpu.Reset()
+	if startPC != "" {
+		pc, err := strconv.ParseUint(strings.TrimPrefix(startPC, "$"), 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("bad start address %q", startPC)
+		}
+		r := cpu.Registers()
+		r.PC = uint16(pc)
+		cpu.SetRegisters(r)
+	}
+
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		w = io.MultiWriter(&buf, f)
+	}
+
+	tr, err := trace.New(cpu, ram, w)
+	if err != nil {
+		return nil, err
+	}
+	tr.Cycles = cycles
+	if err := tr.Run(n); err != nil {
+		// The trace up to the error is still compared
+		fmt.Fprintln(os.Stderr, "tracediff: run stopped:", err)
+	}
+	return &buf, nil
+}
+
+// load reads an iNES file or a raw binary into ram.
+func load(ram *RAM, path, loadAddr string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if bytes.HasPrefix(data, []byte("NES\x1a")) && len(data) >= 16 {
+		prg := int(data[4]) * 0x4000
+		offset := 16
+		if data[6]&0x04 != 0 {
+			offset += 512 // Trainer
+		}
+		if prg == 0 || prg > 0x8000 || len(data) < offset+prg {
+			return errors.New("unsupported iNES PRG ROM size")
+		}
+		copy(ram.memory[0x8000:], data[offset:offset+prg])
+		if prg == 0x4000 {
+			copy(ram.memory[0xC000:], data[offset:offset+prg])
+		}
+		return nil
+	}
+
+	addr, err := strconv.ParseUint(strings.TrimPrefix(loadAddr, "$"), 16, 16)
+	if err != nil {
+		return fmt.Errorf("bad load address %q", loadAddr)
+	}
+	if int(addr)+len(data) > 0x10000 {
+		return fmt.Errorf("%s does not fit at $%04X", path, addr)
+	}
+	copy(ram.memory[addr:], data)
+	return nil
+}
+
+func parseVariant(name string) (core.Variant, error) {
+	switch strings.ToLower(name) {
+	case "nmos", "6502":
+		return core.VariantNMOS, nil
+	case "65c02", "wdc", "cmos":
+		return core.VariantWDC65C02, nil
+	}
+	return 0, fmt.Errorf("unknown CPU %q (want nmos or 65c02)", name)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "tracediff:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,662 @@
+// Package asm is a two-pass assembler for NMOS 6502 and WDC 65C02 code.
+//
+// The source syntax follows ca65, so tests and tools can embed readable
+// assembly instead of hand-assembled byte slices:
+//
+//	        .org $0200
+//	count = 10
+//	start:  ldx #count
+//	@loop:  lda table-1,x   ; @loop is local to start
+//	        sta $0400,x
+//	        dex
+//	        bne @loop
+//	:       jmp :-          ; unnamed label
+//	table:  .byte 1, 2, "abc"
+//	        .word start, >table
+//	        .res 16, $FF
+//
+// Supported features:
+//   - Labels ("name:"), @local labels scoped to the previous label and
+//     unnamed labels (":", referenced as :+ :++ :- :--)
+//   - Constants ("name = expr") and expressions with ca65 operators
+//   - Directives .org, .byte, .word (.addr), .res and .asciiz
+//   - Macros defined with .macro name [params] ... .endmacro
+//   - a: and z: prefixes to force absolute or zero page addressing
+//
+// Instructions are encoded from the same opcode tables the CPUs execute, so
+// the NMOS undocumented opcodes (LAX, DCP, ...) and the 65C02 additions
+// (BRA, STZ, BBR0 zp,label, ...) are available for their variant. Operands
+// that refer to symbols defined later are assumed to be absolute.
+package asm
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/mos6502"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/wdc65c02"
+)
+
+// Error is an assembly error at a source line. Errors in macro expansions
+// report the line of the invocation.
+type Error struct {
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Chunk is a run of bytes assembled at consecutive addresses.
+type Chunk struct {
+	Addr uint16
+	Data []byte
+}
+
+// Program is the output of the assembler.
+type Program struct {
+	Chunks  []Chunk
+	Symbols map[string]uint16 // Labels and constants, with @locals as "scope@name"
+
+	labels map[string]bool
+}
+
+// Load writes every chunk to bus.
+func (p *Program) Load(bus core.Bus) {
+	for _, c := range p.Chunks {
+		for n, b := range c.Data {
+			bus.Write(c.Addr+uint16(n), b)
+		}
+	}
+}
+
+// Image returns the program as one contiguous block starting at the lowest
+// assembled address. Gaps between chunks are filled with zero.
+func (p *Program) Image() (origin uint16, data []byte) {
+	if len(p.Chunks) == 0 {
+		return 0, nil
+	}
+
+	lo, hi := 0x10000, 0
+	for _, c := range p.Chunks {
+		lo = min(lo, int(c.Addr))
+		hi = max(hi, int(c.Addr)+len(c.Data))
+	}
+	data = make([]byte, hi-lo)
+	for _, c := range p.Chunks {
+		copy(data[int(c.Addr)-lo:], c.Data)
+	}
+	return uint16(lo), data
+}
+
+// Labels maps addresses to label names, for use as a disassembler symbol
+// table. Constants, @local and unnamed labels are left out; when several
+// labels share an address the alphabetically first is used.
+func (p *Program) Labels() map[uint16]string {
+	names := make([]string, 0, len(p.labels))
+	for name := range p.labels {
+		if !strings.Contains(name, "@") {
+			names = append(names, name)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	out := make(map[uint16]string, len(names))
+	for _, name := range names {
+		out[p.Symbols[name]] = name
+	}
+	return out
+}
+
+// Assemble assembles src for variant.
+func Assemble(variant core.Variant, src string) (*Program, error) {
+	ops, ok := opcodeMaps[variant]
+	if !ok {
+		return nil, fmt.Errorf("asm: unsupported variant %v", variant)
+	}
+
+	stmts, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &assembler{
+		ops:     ops,
+		symbols: make(map[string]int),
+		labels:  make(map[string]bool),
+	}
+	for a.pass = 1; a.pass <= 2; a.pass++ {
+		a.addr = 0
+		a.chunks = nil
+		for _, s := range stmts {
+			a.cur = s
+			if err := a.statement(s); err != nil {
+				return nil, &Error{Line: s.line, Msg: err.Error()}
+			}
+		}
+	}
+
+	prog := &Program{Symbols: make(map[string]uint16, len(a.symbols)), labels: a.labels}
+	for name, v := range a.symbols {
+		prog.Symbols[name] = uint16(v)
+	}
+	for _, c := range a.chunks {
+		if len(c.Data) > 0 {
+			prog.Chunks = append(prog.Chunks, c)
+		}
+	}
+	return prog, nil
+}
+
+// MustAssemble is like Assemble but panics on error. It is intended for
+// tests and for programs built into the binary.
+func MustAssemble(variant core.Variant, src string) *Program {
+	prog, err := Assemble(variant, src)
+	if err != nil {
+		panic("asm: " + err.Error())
+	}
+	return prog
+}
+
+// opcodeMaps maps mnemonic and addressing mode to an opcode for each
+// variant. Documented opcodes win over undocumented duplicates.
+var opcodeMaps = map[core.Variant]map[string]map[core.AddressingMode]byte{}
+
+func init() {
+	nmos := mos6502.Opcodes()
+	wdc := wdc65c02.Opcodes()
+	opcodeMaps[core.VariantNMOS] = buildOpcodeMap(&nmos)
+	opcodeMaps[core.VariantWDC65C02] = buildOpcodeMap(&wdc)
+}
+
+func buildOpcodeMap(table *[256]core.Opcode) map[string]map[core.AddressingMode]byte {
+	out := make(map[string]map[core.AddressingMode]byte)
+	for _, legalPass := range []bool{true, false} {
+		for _, op := range table {
+			if op.Handler == nil || op.Illegal == legalPass {
+				continue
+			}
+			modes := out[op.Mnemonic]
+			if modes == nil {
+				modes = make(map[core.AddressingMode]byte)
+				out[op.Mnemonic] = modes
+			}
+			if _, dup := modes[op.Mode]; !dup {
+				modes[op.Mode] = op.Code
+			}
+		}
+	}
+	return out
+}
+
+type assembler struct {
+	ops          map[string]map[core.AddressingMode]byte
+	symbols      map[string]int
+	labels       map[string]bool
+	unnamedAddrs []int // Addresses of unnamed labels, in source order
+
+	pass   int
+	addr   int // Current assembly address
+	cur    *stmt
+	chunks []Chunk
+}
+
+// symbol implements resolver.
+func (a *assembler) symbol(name string) (int, bool, error) {
+	if name[0] == '@' {
+		name = a.cur.scope + name
+	}
+	if v, ok := a.symbols[name]; ok {
+		return v, true, nil
+	}
+	if a.pass == 1 {
+		return 0, false, nil
+	}
+	return 0, false, fmt.Errorf("undefined symbol %s", name)
+}
+
+// unnamed implements resolver. :- counts back from the labels defined at or
+// before the current statement, :+ forward from the ones after it.
+func (a *assembler) unnamed(ref string) (int, bool, error) {
+	count := len(ref) - 1
+	index := a.cur.unnamedBefore + a.cur.unnamed - count
+	if ref[1] == '+' {
+		index = a.cur.unnamedBefore + a.cur.unnamed + count - 1
+	}
+
+	if index >= 0 && index < len(a.unnamedAddrs) {
+		return a.unnamedAddrs[index], true, nil
+	}
+	if a.pass == 1 && index >= 0 {
+		return 0, false, nil
+	}
+	return 0, false, fmt.Errorf("no unnamed label for %s", ref)
+}
+
+// pc implements resolver.
+func (a *assembler) pc() int {
+	return a.addr
+}
+
+// eval evaluates an expression that must be known by the current pass.
+// In pass 1 unknown values are allowed and come back as 0.
+func (a *assembler) eval(expr string) (int, bool, error) {
+	v, known, err := eval(expr, a)
+	if err != nil {
+		return 0, false, err
+	}
+	if !known && a.pass == 2 {
+		return 0, false, fmt.Errorf("value of %s is not known", expr)
+	}
+	return v, known, nil
+}
+
+// define sets a symbol, rejecting duplicates in pass 1 and catching labels
+// that moved between passes.
+func (a *assembler) define(name string, v int, label bool) error {
+	old, exists := a.symbols[name]
+	if a.pass == 1 {
+		if exists || a.labels[name] {
+			return fmt.Errorf("%s already defined", name)
+		}
+		if label {
+			a.labels[name] = true
+		}
+	} else if exists && label && old != v {
+		return fmt.Errorf("label %s moved from $%04X to $%04X between passes", name, old, v)
+	}
+	a.symbols[name] = v
+	return nil
+}
+
+func (a *assembler) statement(s *stmt) error {
+	for _, label := range s.labels {
+		if err := a.define(label, a.addr, true); err != nil {
+			return err
+		}
+	}
+	if a.pass == 1 {
+		for range s.unnamed {
+			a.unnamedAddrs = append(a.unnamedAddrs, a.addr)
+		}
+	}
+
+	switch s.kind {
+	case stmtAssign:
+		v, known, err := a.eval(s.operand)
+		if err != nil {
+			return err
+		}
+		if a.pass == 1 {
+			if a.labels[s.name] {
+				return fmt.Errorf("%s already defined", s.name)
+			}
+			if !known {
+				return nil // Defined in pass 2 once its operands are
+			}
+		}
+		if _, exists := a.symbols[s.name]; exists && a.pass == 1 {
+			return fmt.Errorf("%s already defined", s.name)
+		}
+		a.symbols[s.name] = v
+		return nil
+
+	case stmtDirective:
+		return a.directive(s)
+
+	case stmtInstruction:
+		if a.pass == 1 {
+			if err := a.encode(s); err != nil {
+				return err
+			}
+		}
+		return a.instruction(s)
+	}
+	return nil
+}
+
+// emit appends bytes at the current address.
+func (a *assembler) emit(data ...byte) error {
+	if a.addr+len(data) > 0x10000 {
+		return fmt.Errorf("code runs past $FFFF")
+	}
+	if a.pass == 2 {
+		n := len(a.chunks) - 1
+		if n < 0 || int(a.chunks[n].Addr)+len(a.chunks[n].Data) != a.addr {
+			a.chunks = append(a.chunks, Chunk{Addr: uint16(a.addr)})
+			n++
+		}
+		a.chunks[n].Data = append(a.chunks[n].Data, data...)
+	}
+	a.addr += len(data)
+	return nil
+}
+
+func (a *assembler) directive(s *stmt) error {
+	var args []string
+	if s.operand != "" {
+		args = splitOperands(s.operand)
+	}
+
+	switch s.name {
+	case ".org":
+		if len(args) != 1 {
+			return fmt.Errorf(".org takes one address")
+		}
+		v, known, err := eval(args[0], a)
+		if err != nil {
+			return err
+		}
+		if !known {
+			return fmt.Errorf(".org address must be defined before use")
+		}
+		if v < 0 || v > 0xFFFF {
+			return fmt.Errorf(".org address $%X out of range", v)
+		}
+		a.addr = v
+		return nil
+
+	case ".byte", ".asciiz":
+		if len(args) == 0 && s.name == ".byte" {
+			return fmt.Errorf("%s needs at least one value", s.name)
+		}
+		for _, arg := range args {
+			if len(arg) >= 2 && arg[0] == '"' && arg[len(arg)-1] == '"' {
+				if err := a.emit([]byte(arg[1 : len(arg)-1])...); err != nil {
+					return err
+				}
+				continue
+			}
+			v, _, err := a.eval(arg)
+			if err != nil {
+				return err
+			}
+			if a.pass == 2 && (v < -128 || v > 0xFF) {
+				return fmt.Errorf("byte value %d out of range", v)
+			}
+			if err := a.emit(byte(v)); err != nil {
+				return err
+			}
+		}
+		if s.name == ".asciiz" {
+			return a.emit(0)
+		}
+		return nil
+
+	case ".word", ".addr":
+		if len(args) == 0 {
+			return fmt.Errorf("%s needs at least one value", s.name)
+		}
+		for _, arg := range args {
+			v, _, err := a.eval(arg)
+			if err != nil {
+				return err
+			}
+			if a.pass == 2 && (v < -0x8000 || v > 0xFFFF) {
+				return fmt.Errorf("word value %d out of range", v)
+			}
+			if err := a.emit(byte(v), byte(v>>8)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case ".res":
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf(".res takes a count and an optional fill value")
+		}
+		count, known, err := eval(args[0], a)
+		if err != nil {
+			return err
+		}
+		if !known || count < 0 {
+			return fmt.Errorf(".res count must be a defined, non-negative value")
+		}
+		fill := 0
+		if len(args) == 2 {
+			if fill, _, err = a.eval(args[1]); err != nil {
+				return err
+			}
+		}
+		return a.emit(bytes.Repeat([]byte{byte(fill)}, count)...)
+	}
+	return fmt.Errorf("unknown directive %s", s.name)
+}
+
+// encode chooses the addressing mode and opcode of an instruction from its
+// operand syntax. It runs in pass 1; zero page is only chosen for operands
+// whose value is already known to fit.
+func (a *assembler) encode(s *stmt) error {
+	modes, ok := a.ops[s.name]
+	if !ok {
+		return fmt.Errorf("unknown instruction %s", s.name)
+	}
+	has := func(m core.AddressingMode) bool {
+		_, ok := modes[m]
+		return ok
+	}
+
+	operand := s.operand
+	var mode core.AddressingMode
+	var exprs []string
+
+	switch {
+	case has(core.ZeroPageRelative):
+		args := splitOperands(operand)
+		if len(args) != 2 {
+			return fmt.Errorf("%s takes a zero page address and a branch target", s.name)
+		}
+		mode, exprs = core.ZeroPageRelative, args
+
+	case operand == "":
+		mode = core.Implied
+		if !has(mode) {
+			mode = core.Accumulator
+		}
+
+	case strings.EqualFold(operand, "A"):
+		mode = core.Accumulator
+
+	case operand[0] == '#':
+		mode, exprs = core.Immediate, []string{operand[1:]}
+
+	case has(core.Relative):
+		mode, exprs = core.Relative, []string{operand}
+
+	default:
+		var err error
+		if mode, exprs, err = a.memoryOperand(operand, has); err != nil {
+			return err
+		}
+	}
+
+	code, ok := modes[mode]
+	if !ok {
+		return fmt.Errorf("%s does not support %s addressing", s.name, modeNames[mode])
+	}
+	s.opcode, s.mode, s.exprs = code, mode, exprs
+	s.size = int(mode.Length())
+	return nil
+}
+
+// memoryOperand handles the indexed and indirect operand forms.
+func (a *assembler) memoryOperand(operand string, has func(core.AddressingMode) bool) (core.AddressingMode, []string, error) {
+	if inner, ok := parenthesized(operand); ok {
+		args := splitOperands(inner)
+		switch {
+		case len(args) == 2 && strings.EqualFold(args[1], "X"):
+			if has(core.IndirectX) {
+				return core.IndirectX, args[:1], nil
+			}
+			return core.AbsoluteXIndirect, args[:1], nil
+		case len(args) == 1:
+			if has(core.Indirect) {
+				return core.Indirect, args, nil
+			}
+			return core.ZeroPageIndirect, args, nil
+		}
+	}
+
+	args := splitOperands(operand)
+	if len(args) == 2 && strings.EqualFold(args[1], "Y") {
+		if inner, ok := parenthesized(args[0]); ok {
+			return core.IndirectY, []string{inner}, nil
+		}
+	}
+
+	zp, abs := core.ZeroPage, core.Absolute
+	switch {
+	case len(args) == 1:
+	case len(args) == 2 && strings.EqualFold(args[1], "X"):
+		zp, abs = core.ZeroPageX, core.AbsoluteX
+	case len(args) == 2 && strings.EqualFold(args[1], "Y"):
+		zp, abs = core.ZeroPageY, core.AbsoluteY
+	default:
+		return 0, nil, fmt.Errorf("bad operand %q", operand)
+	}
+
+	expr := args[0]
+	forceAbs, forceZP := false, false
+	if len(expr) > 2 && expr[1] == ':' {
+		switch expr[0] {
+		case 'a', 'A':
+			forceAbs, expr = true, expr[2:]
+		case 'z', 'Z':
+			forceZP, expr = true, expr[2:]
+		}
+	}
+
+	v, known, err := eval(expr, a)
+	if err != nil {
+		return 0, nil, err
+	}
+	switch {
+	case forceZP:
+		return zp, []string{expr}, nil
+	case forceAbs:
+		return abs, []string{expr}, nil
+	case known && v >= 0 && v <= 0xFF && has(zp), !has(abs):
+		return zp, []string{expr}, nil
+	}
+	return abs, []string{expr}, nil
+}
+
+// parenthesized reports whether s is wholly enclosed in one pair of
+// parentheses and returns what is inside.
+func parenthesized(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return "", false
+	}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i != len(s)-1 {
+				return "", false
+			}
+		}
+	}
+	return strings.TrimSpace(s[1 : len(s)-1]), true
+}
+
+// instruction emits an instruction encoded by encode.
+func (a *assembler) instruction(s *stmt) error {
+	start := a.addr
+	out := []byte{s.opcode}
+
+	switch s.mode {
+	case core.Implied, core.Accumulator:
+
+	case core.Relative:
+		offset, err := a.branchOffset(s.exprs[0], start+2)
+		if err != nil {
+			return err
+		}
+		out = append(out, offset)
+
+	case core.ZeroPageRelative:
+		zp, err := a.operand(s.exprs[0], 0, 0xFF)
+		if err != nil {
+			return err
+		}
+		offset, err := a.branchOffset(s.exprs[1], start+3)
+		if err != nil {
+			return err
+		}
+		out = append(out, byte(zp), offset)
+
+	case core.Immediate:
+		v, err := a.operand(s.exprs[0], -128, 0xFF)
+		if err != nil {
+			return err
+		}
+		out = append(out, byte(v))
+
+	default:
+		if s.size == 2 {
+			v, err := a.operand(s.exprs[0], 0, 0xFF)
+			if err != nil {
+				return err
+			}
+			out = append(out, byte(v))
+		} else {
+			v, err := a.operand(s.exprs[0], 0, 0xFFFF)
+			if err != nil {
+				return err
+			}
+			out = append(out, byte(v), byte(v>>8))
+		}
+	}
+	return a.emit(out...)
+}
+
+// operand evaluates an operand and checks its range in pass 2.
+func (a *assembler) operand(expr string, lo, hi int) (int, error) {
+	v, _, err := a.eval(expr)
+	if err != nil {
+		return 0, err
+	}
+	if a.pass == 2 && (v < lo || v > hi) {
+		return 0, fmt.Errorf("operand $%X out of range", v)
+	}
+	return v, nil
+}
+
+// branchOffset returns the relative offset from next to the target.
+func (a *assembler) branchOffset(expr string, next int) (byte, error) {
+	target, _, err := a.eval(expr)
+	if err != nil {
+		return 0, err
+	}
+	offset := target - next
+	if a.pass == 2 && (offset < -128 || offset > 127) {
+		return 0, fmt.Errorf("branch target $%04X out of range (%d bytes)", target, offset)
+	}
+	return byte(offset), nil
+}
+
+var modeNames = map[core.AddressingMode]string{
+	core.Implied:           "implied",
+	core.Immediate:         "immediate",
+	core.ZeroPage:          "zero page",
+	core.ZeroPageX:         "zero page,X",
+	core.ZeroPageY:         "zero page,Y",
+	core.Absolute:          "absolute",
+	core.AbsoluteX:         "absolute,X",
+	core.AbsoluteY:         "absolute,Y",
+	core.Indirect:          "indirect",
+	core.IndirectX:         "(indirect,X)",
+	core.IndirectY:         "(indirect),Y",
+	core.Relative:          "relative",
+	core.Accumulator:       "accumulator",
+	core.ZeroPageIndirect:  "(zero page)",
+	core.AbsoluteXIndirect: "(absolute,X)",
+	core.ZeroPageRelative:  "zero page,relative",
+}
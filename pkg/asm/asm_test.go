@@ -0,0 +1,365 @@
+package asm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/disasm"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/mos6502"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/wdc65c02"
+)
+
+// SimpleRAM is a simple RAM implementation for testing
+type SimpleRAM struct {
+	memory [0x10000]byte
+}
+
+func (r *SimpleRAM) Read(addr uint16) byte {
+	return r.memory[addr]
+}
+
+func (r *SimpleRAM) Write(addr uint16, data byte) {
+	r.memory[addr] = data
+}
+
+// assemble assembles src and returns the image, which must start at $0200.
+func assemble(t *testing.T, variant core.Variant, src string) []byte {
+	t.Helper()
+	prog, err := Assemble(variant, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin, data := prog.Image()
+	if origin != 0x0200 {
+		t.Fatalf("expected origin $0200, got $%04X", origin)
+	}
+	return data
+}
+
+func TestAddressingModes(t *testing.T) {
+	tests := []struct {
+		variant core.Variant
+		src     string
+		want    []byte
+	}{
+		{core.VariantNMOS, "nop", []byte{0xEA}},
+		{core.VariantNMOS, "asl", []byte{0x0A}},
+		{core.VariantNMOS, "asl a", []byte{0x0A}},
+		{core.VariantNMOS, "lda #$42", []byte{0xA9, 0x42}},
+		{core.VariantNMOS, "lda #-1", []byte{0xA9, 0xFF}},
+		{core.VariantNMOS, "lda $10", []byte{0xA5, 0x10}},
+		{core.VariantNMOS, "lda $10,x", []byte{0xB5, 0x10}},
+		{core.VariantNMOS, "ldx $10,Y", []byte{0xB6, 0x10}},
+		{core.VariantNMOS, "lda $1234", []byte{0xAD, 0x34, 0x12}},
+		{core.VariantNMOS, "lda $1234,X", []byte{0xBD, 0x34, 0x12}},
+		{core.VariantNMOS, "lda $10,y", []byte{0xB9, 0x10, 0x00}}, // No LDA zp,Y
+		{core.VariantNMOS, "lda a:$10", []byte{0xAD, 0x10, 0x00}},
+		{core.VariantNMOS, "jmp ($10FF)", []byte{0x6C, 0xFF, 0x10}},
+		{core.VariantNMOS, "lda ($20,x)", []byte{0xA1, 0x20}},
+		{core.VariantNMOS, "lda ($20),y", []byte{0xB1, 0x20}},
+		{core.VariantNMOS, "lda ($10+$10)*2", []byte{0xA5, 0x40}},
+		{core.VariantNMOS, "bne *", []byte{0xD0, 0xFE}},
+		{core.VariantNMOS, "bpl *+$12", []byte{0x10, 0x10}},
+		{core.VariantNMOS, "lax $10", []byte{0xA7, 0x10}},
+		{core.VariantNMOS, "sbc #1", []byte{0xE9, 0x01}}, // Not the undocumented $EB
+		{core.VariantWDC65C02, "lda ($20)", []byte{0xB2, 0x20}},
+		{core.VariantWDC65C02, "jmp ($3000,x)", []byte{0x7C, 0x00, 0x30}},
+		{core.VariantWDC65C02, "bbr0 $20,*+8", []byte{0x0F, 0x20, 0x05}},
+		{core.VariantWDC65C02, "bbs7 $20,*", []byte{0xFF, 0x20, 0xFD}},
+		{core.VariantWDC65C02, "smb0 $20", []byte{0x87, 0x20}},
+		{core.VariantWDC65C02, "stz $1234,x", []byte{0x9E, 0x34, 0x12}},
+		{core.VariantWDC65C02, "bra *", []byte{0x80, 0xFE}},
+		{core.VariantWDC65C02, "inc", []byte{0x1A}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got := assemble(t, tt.variant, ".org $0200\n "+tt.src)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("got % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDisassemblyRoundTrip assembles the disassembly of every opcode and
+// checks the same bytes come back. Undocumented duplicates of other opcodes
+// assemble to the documented form and are skipped.
+func TestDisassemblyRoundTrip(t *testing.T) {
+	tables := map[core.Variant][256]core.Opcode{
+		core.VariantNMOS:     mos6502.Opcodes(),
+		core.VariantWDC65C02: wdc65c02.Opcodes(),
+	}
+
+	for variant, table := range tables {
+		ram := &SimpleRAM{}
+		d, err := disasm.New(ram, variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, op := range table {
+			if op.Handler == nil {
+				continue
+			}
+			copy(ram.memory[0x0200:], []byte{op.Code, 0x34, 0x12})
+			ins := d.Decode(0x0200)
+
+			prog, err := Assemble(variant, ".org $0200\n"+ins.String())
+			if err != nil {
+				t.Errorf("%v $%02X %q: %v", variant, op.Code, ins.String(), err)
+				continue
+			}
+			_, got := prog.Image()
+			if got[0] != op.Code && op.Illegal {
+				continue
+			}
+			if !bytes.Equal(got, ins.Bytes) {
+				t.Errorf("%v %q: got % X, want % X", variant, ins.String(), got, ins.Bytes)
+			}
+		}
+	}
+}
+
+func TestExpressions(t *testing.T) {
+	tests := []struct {
+		expr string
+		want byte
+	}{
+		{"$1F", 0x1F},
+		{"%1010", 0x0A},
+		{"200", 200},
+		{"'A'", 0x41},
+		{"1+2*3", 7},
+		{"(1+2)*3", 9},
+		{"10-2-3", 5},
+		{"<$1234", 0x34},
+		{">$1234", 0x12},
+		{"$F0|$0F", 0xFF},
+		{"$F0&$3C", 0x30},
+		{"$FF^$0F", 0xF0},
+		{"1<<4", 0x10},
+		{"$80>>7", 0x01},
+		{"~0 & $FF", 0xFF},
+		{"-1 & $FF", 0xFF},
+		{"value/2", 0x21},
+		{"* & $FF", 0x00},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got := assemble(t, core.VariantNMOS, "value = $42\n.org $0200\n.byte "+tt.expr)
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("got % X, want %02X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabels(t *testing.T) {
+	src := `
+        .org $0200
+start:  ldx #3
+@loop:  dex
+        bne @loop
+        jmp next
+next:   ldy #0
+@loop:  iny             ; A second @loop, local to next
+        bne @loop
+:       beq :+
+        bne :-
+:       jmp :--
+`
+	got := assemble(t, core.VariantNMOS, src)
+	want := []byte{
+		0xA2, 0x03, // $0200 LDX #3
+		0xCA,       // $0202 DEX
+		0xD0, 0xFD, // $0203 BNE $0202
+		0x4C, 0x08, 0x02, // $0205 JMP $0208
+		0xA0, 0x00, // $0208 LDY #0
+		0xC8,       // $020A INY
+		0xD0, 0xFD, // $020B BNE $020A
+		0xF0, 0x02, // $020D BEQ $0211
+		0xD0, 0xFC, // $020F BNE $020D
+		0x4C, 0x0D, 0x02, // $0211 JMP $020D
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got  % X\nwant % X", got, want)
+	}
+
+	prog := MustAssemble(core.VariantNMOS, src)
+	if prog.Symbols["start@loop"] != 0x0202 || prog.Symbols["next@loop"] != 0x020A {
+		t.Errorf("unexpected local labels: %v", prog.Symbols)
+	}
+	labels := prog.Labels()
+	if len(labels) != 2 || labels[0x0200] != "start" || labels[0x0208] != "next" {
+		t.Errorf("unexpected Labels(): %v", labels)
+	}
+}
+
+func TestForwardReferences(t *testing.T) {
+	src := `
+        .org $0200
+        lda data        ; Not known in pass 1, so absolute
+        lda zp          ; Known, so zero page
+        lda #<data
+        lda #>data
+zp = $10
+        .org $0300
+data:   .byte 0
+`
+	prog := MustAssemble(core.VariantNMOS, src)
+	if len(prog.Chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(prog.Chunks))
+	}
+	want := []byte{0xAD, 0x00, 0x03, 0xAD, 0x10, 0x00, 0xA9, 0x00, 0xA9, 0x03}
+	if !bytes.Equal(prog.Chunks[0].Data, want) {
+		t.Errorf("got % X, want % X", prog.Chunks[0].Data, want)
+	}
+
+	origin, image := prog.Image()
+	if origin != 0x0200 || len(image) != 0x101 {
+		t.Errorf("expected image $0200+$101, got $%04X+$%X", origin, len(image))
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	got := assemble(t, core.VariantNMOS, `
+        .org $0200
+        .byte 1, "hi", 'x'
+        .word $1234, end
+        .addr $5678
+        .res 3
+        .res 2, $EA
+        .asciiz "ok"
+end:
+`)
+	want := []byte{0x01, 'h', 'i', 'x', 0x34, 0x12, 0x12, 0x02, 0x78, 0x56, 0, 0, 0, 0xEA, 0xEA, 'o', 'k', 0}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got  % X\nwant % X", got, want)
+	}
+}
+
+func TestMacros(t *testing.T) {
+	got := assemble(t, core.VariantNMOS, `
+.macro  ldxy value
+        ldx #<value
+        ldy #>value
+.endmacro
+
+.macro  clear addr
+        lda #0
+        sta addr
+.endmacro
+
+        .org $0200
+start:  ldxy $1234
+        clear $10
+        clear $2000
+`)
+	want := []byte{0xA2, 0x34, 0xA0, 0x12, 0xA9, 0x00, 0x85, 0x10, 0xA9, 0x00, 0x8D, 0x00, 0x20}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got  % X\nwant % X", got, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		line int
+		msg  string
+	}{
+		{"  lda", 1, "does not support"},
+		{"  foo #1", 1, "unknown instruction"},
+		{"\n  lda undefined", 2, "undefined symbol"},
+		{"  .org $0200\n  bne far\n  .res 200\nfar:", 2, "out of range"},
+		{"x: nop\nx: nop", 2, "already defined"},
+		{"  lda #$100", 1, "out of range"},
+		{"  bra *", 1, "unknown instruction"}, // 65C02 only
+		{"  .bogus", 1, "unknown directive"},
+		{".macro m\n  nop", 1, "no .endmacro"},
+		{"@x: nop", 1, "no enclosing label"},
+		{"  jmp :-", 1, "no unnamed label"},
+		{"  lda #(1", 1, "missing )"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := Assemble(core.VariantNMOS, tt.src)
+			var asmErr *Error
+			if !errors.As(err, &asmErr) {
+				t.Fatalf("expected *Error, got %v", err)
+			}
+			if asmErr.Line != tt.line || !strings.Contains(asmErr.Msg, tt.msg) {
+				t.Errorf("got %q, want line %d containing %q", err, tt.line, tt.msg)
+			}
+		})
+	}
+}
+
+// TestRunAssembled assembles a routine and runs it on both CPUs.
+func TestRunAssembled(t *testing.T) {
+	src := `
+result = $10
+
+        .org $0200
+        ldx #10         ; Sum 10+9+...+1
+        lda #0
+        clc
+:       stx result
+        adc result
+        dex
+        bne :-
+        sta result
+        jam
+`
+	prog := MustAssemble(core.VariantNMOS, src)
+	ram := &SimpleRAM{}
+	prog.Load(ram)
+	cpu := mos6502.NewCPU(ram)
+	cpu.PC = 0x0200
+
+	var stepErr *core.StepError
+	if err := cpu.Run(); !errors.As(err, &stepErr) || !errors.Is(err, core.ErrJammed) {
+		t.Fatalf("expected jam, got %v", err)
+	}
+	if got := ram.memory[0x10]; got != 55 {
+		t.Errorf("expected 55, got %d", got)
+	}
+
+	// The same source less the JAM runs on the 65C02
+	prog = MustAssemble(core.VariantWDC65C02, strings.Replace(src, "jam", "stp", 1))
+	ram = &SimpleRAM{}
+	prog.Load(ram)
+	wdc := wdc65c02.NewCPU(ram)
+	wdc.PC = 0x0200
+	if err := wdc.Run(); !errors.Is(err, core.ErrStopped) {
+		t.Fatalf("expected stop, got %v", err)
+	}
+	if got := ram.memory[0x10]; got != 55 {
+		t.Errorf("expected 55, got %d", got)
+	}
+}
+
+func ExampleAssemble() {
+	prog, err := Assemble(core.VariantNMOS, `
+        .org $C000
+reset:  ldx #$FF
+        txs
+        jmp reset
+`)
+	if err != nil {
+		panic(err)
+	}
+	for _, c := range prog.Chunks {
+		fmt.Printf("$%04X: % X\n", c.Addr, c.Data)
+	}
+	fmt.Printf("reset = $%04X\n", prog.Symbols["reset"])
+	// Output:
+	// $C000: A2 FF 9A 4C 00 C0
+	// reset = $C000
+}
@@ -0,0 +1,323 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expressions follow ca65 precedence, from highest to lowest:
+//
+//	unary    -  +  ~  <  >        (< and > select the low and high byte)
+//	product  *  /  &  ^  <<  >>
+//	sum      +  -  |
+//
+// Operands are decimal, $hex, %binary and 'c' character literals, symbols,
+// @local labels, the unnamed label references :+ :++ :- :-- and * for the
+// current address.
+
+type tokenKind int
+
+const (
+	tokNumber tokenKind = iota
+	tokIdent
+	tokUnnamed // :+, :-, :++ ...
+	tokOp
+	tokEOF
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	val  int
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '@' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// tokenize splits an expression into tokens.
+func tokenize(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+
+		case c == '$':
+			j := i + 1
+			for j < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[j]) >= 0 {
+				j++
+			}
+			v, err := strconv.ParseUint(s[i+1:j], 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("bad hex number %q", s[i:j])
+			}
+			toks = append(toks, token{kind: tokNumber, text: s[i:j], val: int(v)})
+			i = j
+
+		case c == '%' && i+1 < len(s) && (s[i+1] == '0' || s[i+1] == '1'):
+			j := i + 1
+			for j < len(s) && (s[j] == '0' || s[j] == '1') {
+				j++
+			}
+			v, _ := strconv.ParseUint(s[i+1:j], 2, 32)
+			toks = append(toks, token{kind: tokNumber, text: s[i:j], val: int(v)})
+			i = j
+
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			v, err := strconv.ParseUint(s[i:j], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("bad number %q", s[i:j])
+			}
+			toks = append(toks, token{kind: tokNumber, text: s[i:j], val: int(v)})
+			i = j
+
+		case c == '\'':
+			if i+2 >= len(s) || s[i+2] != '\'' {
+				return nil, fmt.Errorf("bad character literal")
+			}
+			toks = append(toks, token{kind: tokNumber, text: s[i : i+3], val: int(s[i+1])})
+			i += 3
+
+		case c == ':':
+			j := i + 1
+			for j < len(s) && s[j] == s[i+1] && (s[j] == '+' || s[j] == '-') {
+				j++
+			}
+			if j == i+1 {
+				return nil, fmt.Errorf("expected :+ or :-")
+			}
+			toks = append(toks, token{kind: tokUnnamed, text: s[i:j]})
+			i = j
+
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(s) && isIdentChar(s[j]) {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: s[i:j]})
+			i = j
+
+		case c == '<' && i+1 < len(s) && s[i+1] == '<', c == '>' && i+1 < len(s) && s[i+1] == '>':
+			toks = append(toks, token{kind: tokOp, text: s[i : i+2]})
+			i += 2
+
+		case strings.IndexByte("+-*/&|^~<>()", c) >= 0:
+			toks = append(toks, token{kind: tokOp, text: s[i : i+1]})
+			i++
+
+		default:
+			return nil, fmt.Errorf("unexpected %q in expression", c)
+		}
+	}
+	return append(toks, token{kind: tokEOF}), nil
+}
+
+// resolver looks up the value of a symbol or unnamed label reference. It
+// returns false if the value is not known yet.
+type resolver interface {
+	symbol(name string) (int, bool, error)
+	unnamed(ref string) (int, bool, error)
+	pc() int
+}
+
+type exprParser struct {
+	toks  []token
+	pos   int
+	r     resolver
+	known bool
+}
+
+// eval evaluates an expression. known is false if it refers to a symbol
+// that has not been defined yet.
+func eval(s string, r resolver) (val int, known bool, err error) {
+	toks, err := tokenize(s)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(toks) == 1 {
+		return 0, false, fmt.Errorf("missing expression")
+	}
+
+	p := &exprParser{toks: toks, r: r, known: true}
+	val, err = p.sum()
+	if err != nil {
+		return 0, false, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return 0, false, fmt.Errorf("unexpected %q in expression", t.text)
+	}
+	return val, p.known, nil
+}
+
+func (p *exprParser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) isOp(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) sum() (int, error) {
+	v, err := p.product()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op, ok := p.isOp("+", "-", "|")
+		if !ok {
+			return v, nil
+		}
+		p.next()
+		rhs, err := p.product()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "+":
+			v += rhs
+		case "-":
+			v -= rhs
+		case "|":
+			v |= rhs
+		}
+	}
+}
+
+func (p *exprParser) product() (int, error) {
+	v, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op, ok := p.isOp("*", "/", "&", "^", "<<", ">>")
+		if !ok {
+			return v, nil
+		}
+		p.next()
+		rhs, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "*":
+			v *= rhs
+		case "/":
+			if rhs == 0 {
+				if !p.known {
+					v = 0
+					continue
+				}
+				return 0, fmt.Errorf("division by zero")
+			}
+			v /= rhs
+		case "&":
+			v &= rhs
+		case "^":
+			v ^= rhs
+		case "<<":
+			v <<= uint(rhs)
+		case ">>":
+			v >>= uint(rhs)
+		}
+	}
+}
+
+func (p *exprParser) unary() (int, error) {
+	op, ok := p.isOp("-", "+", "~", "<", ">")
+	if !ok {
+		return p.primary()
+	}
+	p.next()
+	v, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	switch op {
+	case "-":
+		return -v, nil
+	case "~":
+		return ^v, nil
+	case "<":
+		return v & 0xFF, nil
+	case ">":
+		return (v >> 8) & 0xFF, nil
+	}
+	return v, nil
+}
+
+func (p *exprParser) primary() (int, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return t.val, nil
+
+	case tokIdent:
+		v, ok, err := p.r.symbol(t.text)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			p.known = false
+		}
+		return v, nil
+
+	case tokUnnamed:
+		v, ok, err := p.r.unnamed(t.text)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			p.known = false
+		}
+		return v, nil
+
+	case tokOp:
+		switch t.text {
+		case "*":
+			return p.r.pc(), nil
+		case "(":
+			v, err := p.sum()
+			if err != nil {
+				return 0, err
+			}
+			if _, ok := p.isOp(")"); !ok {
+				return 0, fmt.Errorf("missing )")
+			}
+			p.next()
+			return v, nil
+		}
+	}
+
+	if t.kind == tokEOF {
+		return 0, fmt.Errorf("unexpected end of expression")
+	}
+	return 0, fmt.Errorf("unexpected %q in expression", t.text)
+}
@@ -0,0 +1,331 @@
+package asm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// stmtKind classifies a source statement.
+type stmtKind int
+
+const (
+	stmtEmpty stmtKind = iota // labels only
+	stmtInstruction
+	stmtDirective
+	stmtAssign
+)
+
+// stmt is one source statement after macro expansion.
+type stmt struct {
+	line    int
+	labels  []string // Qualified label names defined at this statement
+	unnamed int      // Unnamed labels defined here (":")
+	kind    stmtKind
+	name    string // Mnemonic (upper case), directive (lower case) or assigned symbol
+	operand string
+	scope   string // Enclosing non-local label, for @local references
+
+	unnamedBefore int // Unnamed labels defined before this statement's own
+
+	// Decided in pass 1 and reused in pass 2 so both passes agree on size
+	size   int
+	opcode byte
+	mode   core.AddressingMode
+	exprs  []string
+}
+
+// macro is a .macro definition.
+type macro struct {
+	params []string
+	body   []sourceLine
+}
+
+type sourceLine struct {
+	line int
+	text string
+}
+
+// maxMacroDepth bounds macro expansion to catch runaway recursion.
+const maxMacroDepth = 16
+
+type parser struct {
+	stmts  []*stmt
+	macros map[string]*macro
+	scope  string
+	count  int // Unnamed labels seen so far
+}
+
+// parse splits source into statements, expanding macros.
+func parse(src string) ([]*stmt, error) {
+	p := &parser{macros: make(map[string]*macro)}
+
+	var lines []sourceLine
+	for n, text := range strings.Split(src, "\n") {
+		lines = append(lines, sourceLine{line: n + 1, text: text})
+	}
+	if err := p.lines(lines, 0); err != nil {
+		return nil, err
+	}
+	return p.stmts, nil
+}
+
+func (p *parser) lines(lines []sourceLine, depth int) error {
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		text := strings.TrimSpace(stripComment(l.text))
+
+		// Collect a macro definition up to its .endmacro
+		if fields := strings.Fields(text); len(fields) > 0 && strings.EqualFold(fields[0], ".macro") {
+			if len(fields) < 2 {
+				return &Error{Line: l.line, Msg: ".macro needs a name"}
+			}
+			name := fields[1]
+			m := &macro{}
+			rest := strings.TrimSpace(strings.TrimSpace(text[len(fields[0]):])[len(name):])
+			if rest != "" {
+				m.params = splitOperands(rest)
+			}
+
+			closed := false
+			for i++; i < len(lines); i++ {
+				body := strings.TrimSpace(stripComment(lines[i].text))
+				if f := strings.Fields(body); len(f) > 0 && (strings.EqualFold(f[0], ".endmacro") || strings.EqualFold(f[0], ".endmac")) {
+					closed = true
+					break
+				}
+				m.body = append(m.body, lines[i])
+			}
+			if !closed {
+				return &Error{Line: l.line, Msg: fmt.Sprintf("macro %s has no .endmacro", name)}
+			}
+			if _, dup := p.macros[name]; dup {
+				return &Error{Line: l.line, Msg: fmt.Sprintf("macro %s already defined", name)}
+			}
+			p.macros[name] = m
+			continue
+		}
+
+		if err := p.statement(l.line, text, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statement parses one line with the comment removed.
+func (p *parser) statement(line int, text string, depth int) error {
+	s := &stmt{line: line}
+
+	// Labels: "name:", "@local:" and unnamed ":"
+	for {
+		if strings.HasPrefix(text, ":") && (len(text) == 1 || text[1] == ' ' || text[1] == '\t') {
+			s.unnamed++
+			text = strings.TrimSpace(text[1:])
+			continue
+		}
+		n := 0
+		for n < len(text) && isIdentChar(text[n]) {
+			n++
+		}
+		if n == 0 || n >= len(text) || text[n] != ':' || !isIdentStart(text[0]) {
+			break
+		}
+		label := text[:n]
+		if label[0] == '@' {
+			if p.scope == "" {
+				return &Error{Line: line, Msg: fmt.Sprintf("local label %s has no enclosing label", label)}
+			}
+			label = p.scope + label
+		} else {
+			p.scope = label
+		}
+		s.labels = append(s.labels, label)
+		text = strings.TrimSpace(text[n+1:])
+	}
+
+	s.scope = p.scope
+	s.unnamedBefore = p.count
+	p.count += s.unnamed
+
+	if text == "" {
+		p.emit(s)
+		return nil
+	}
+
+	// "name = expr" defines a constant
+	if eq := strings.IndexByte(text, '='); eq > 0 {
+		name := strings.TrimSpace(text[:eq])
+		if isIdent(name) {
+			s.kind = stmtAssign
+			s.name = name
+			s.operand = strings.TrimSpace(text[eq+1:])
+			p.emit(s)
+			return nil
+		}
+	}
+
+	word, operand := text, ""
+	if n := strings.IndexAny(text, " \t"); n >= 0 {
+		word, operand = text[:n], strings.TrimSpace(text[n+1:])
+	}
+
+	if strings.HasPrefix(word, ".") {
+		s.kind = stmtDirective
+		s.name = strings.ToLower(word)
+		s.operand = operand
+		p.emit(s)
+		return nil
+	}
+
+	if m, ok := p.macros[word]; ok {
+		if depth >= maxMacroDepth {
+			return &Error{Line: line, Msg: fmt.Sprintf("macro %s nested too deeply", word)}
+		}
+		// Labels on the invocation line label the expansion
+		if len(s.labels) > 0 || s.unnamed > 0 {
+			p.emit(s)
+		}
+		return p.expand(line, word, m, operand, depth)
+	}
+
+	s.kind = stmtInstruction
+	s.name = strings.ToUpper(word)
+	s.operand = operand
+	p.emit(s)
+	return nil
+}
+
+func (p *parser) emit(s *stmt) {
+	p.stmts = append(p.stmts, s)
+}
+
+// expand substitutes arguments into a macro body and parses the result.
+// Statements from the expansion report the line of the invocation.
+func (p *parser) expand(line int, name string, m *macro, operand string, depth int) error {
+	var args []string
+	if operand != "" {
+		args = splitOperands(operand)
+	}
+	if len(args) > len(m.params) {
+		return &Error{Line: line, Msg: fmt.Sprintf("macro %s takes %d arguments, got %d", name, len(m.params), len(args))}
+	}
+
+	values := make(map[string]string, len(m.params))
+	for n, param := range m.params {
+		if n < len(args) {
+			values[param] = strings.TrimSpace(args[n])
+		} else {
+			values[param] = ""
+		}
+	}
+
+	body := make([]sourceLine, len(m.body))
+	for n, l := range m.body {
+		body[n] = sourceLine{line: line, text: substitute(stripComment(l.text), values)}
+	}
+	return p.lines(body, depth+1)
+}
+
+// substitute replaces whole identifiers found in values.
+func substitute(text string, values map[string]string) string {
+	if len(values) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		c := text[i]
+		if c == '"' || c == '\'' {
+			j := i + 1
+			for j < len(text) && text[j] != c {
+				j++
+			}
+			if j < len(text) {
+				j++
+			}
+			b.WriteString(text[i:j])
+			i = j
+			continue
+		}
+		if isIdentStart(c) {
+			j := i + 1
+			for j < len(text) && isIdentChar(text[j]) {
+				j++
+			}
+			if v, ok := values[text[i:j]]; ok {
+				b.WriteString(v)
+			} else {
+				b.WriteString(text[i:j])
+			}
+			i = j
+			continue
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String()
+}
+
+// stripComment removes a ';' comment, ignoring semicolons inside quotes.
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"':
+			quote = c
+		case c == '\'' && i+2 < len(text) && text[i+2] == '\'':
+			i += 2 // Character literal
+		case c == ';':
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// splitOperands splits a comma separated list, ignoring commas inside
+// parentheses and quotes.
+func splitOperands(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"':
+			quote = c
+		case c == '\'' && i+2 < len(s) && s[i+2] == '\'':
+			i += 2
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			out = append(out, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(out, strings.TrimSpace(s[start:]))
+}
+
+func isIdent(s string) bool {
+	if s == "" || !isIdentStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isIdentChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
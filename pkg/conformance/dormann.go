@@ -0,0 +1,205 @@
+// Package conformance runs published CPU test suites against the emulated
+// CPUs: Klaus Dormann's functional tests, which run whole programs, and
+// SingleStepTests, which check single instructions bus cycle by bus cycle.
+//
+// Klaus Dormann's test programs (https://github.com/Klaus2m5/6502_65C02_functional_tests)
+// are assembled binaries that exercise every documented instruction and
+// signal the outcome by looping forever: a jump or branch to itself. A loop
+// at the success address means every test passed; a loop anywhere else is
+// the trap of a failing test, whose number the program keeps in memory.
+package conformance
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+
+	// Register the CPU variants with core.NewCPU
+	_ "github.com/andrewthecodertx/go-6502-emulator/pkg/mos6502"
+	_ "github.com/andrewthecodertx/go-6502-emulator/pkg/wdc65c02"
+)
+
+// DormannTest describes a build of one of Klaus Dormann's test programs.
+// The addresses depend on the options it was assembled with; the values in
+// FunctionalTest, DecimalTest and ExtendedOpcodesTest are for the default
+// options.
+type DormannTest struct {
+	Name string
+	File string // File name of the binary
+
+	Load  uint16 // Address the binary is loaded at
+	Start uint16 // Address execution starts at
+
+	// Success is the address of the loop reached when every test passes.
+	// If it is zero, the test passes when the byte at ErrorAddr is zero.
+	Success uint16
+
+	// TestCase, if non-zero, is the address of the number of the test
+	// being run.
+	TestCase uint16
+
+	// ErrorAddr, if non-zero, is the address of a byte that is non-zero
+	// when a test has failed.
+	ErrorAddr uint16
+
+	// StopOnSTP ends the run before any STP ($DB) opcode, whatever the
+	// variant, for programs that stop the 65C02 to signal the end. The
+	// NMOS 6502 would otherwise run $DB as DCP and carry on.
+	StopOnSTP bool
+
+	// MaxCycles ends a run that has not finished. Zero means 500 million.
+	MaxCycles uint64
+}
+
+// FunctionalTest is 6502_functional_test, which tests every documented NMOS
+// 6502 instruction and passes on both variants.
+var FunctionalTest = DormannTest{
+	Name:     "6502_functional_test",
+	File:     "6502_functional_test.bin",
+	Load:     0x0000,
+	Start:    0x0400,
+	Success:  0x3469,
+	TestCase: 0x0200,
+}
+
+// DecimalTest is 6502_decimal_test, which checks ADC and SBC in decimal mode
+// for every pair of operands. It ends with STP and leaves its result in the
+// ERROR variable.
+var DecimalTest = DormannTest{
+	Name:      "6502_decimal_test",
+	File:      "6502_decimal_test.bin",
+	Load:      0x0200,
+	Start:     0x0200,
+	ErrorAddr: 0x000B,
+	StopOnSTP: true,
+}
+
+// ExtendedOpcodesTest is 65C02_extended_opcodes_test, which tests the
+// instructions and addressing modes the 65C02 adds, including the Rockwell
+// and WDC bit instructions.
+var ExtendedOpcodesTest = DormannTest{
+	Name:     "65C02_extended_opcodes_test",
+	File:     "65C02_extended_opcodes_test.bin",
+	Load:     0x0000,
+	Start:    0x0400,
+	Success:  0x24F1,
+	TestCase: 0x0200,
+}
+
+// DormannResult describes how a run of a DormannTest ended.
+type DormannResult struct {
+	Passed bool
+
+	// Reason says why a run failed: the trap it looped in, the CPU error
+	// that stopped it or the cycle limit.
+	Reason string
+
+	PC        uint16 // Address execution stopped at
+	TestCase  int    // Number of the last test started, or -1 if not recorded
+	Registers core.Registers
+	Cycles    uint64
+}
+
+func (r DormannResult) String() string {
+	if r.Passed {
+		return fmt.Sprintf("passed after %d cycles", r.Cycles)
+	}
+	s := r.Reason
+	if r.TestCase >= 0 {
+		s += fmt.Sprintf(" in test $%02X", r.TestCase)
+	}
+	return s + fmt.Sprintf(" after %d cycles (PC:%04X A:%02X X:%02X Y:%02X P:%02X SP:%02X)",
+		r.Cycles, r.PC, r.Registers.A, r.Registers.X, r.Registers.Y,
+		r.Registers.Status, r.Registers.SP)
+}
+
+// dormannRAM is the flat 64KB memory the test programs run in.
+type dormannRAM struct {
+	memory [0x10000]byte
+}
+
+func (r *dormannRAM) Read(addr uint16) byte {
+	return r.memory[addr]
+}
+
+func (r *dormannRAM) Write(addr uint16, data byte) {
+	r.memory[addr] = data
+}
+
+// Run loads image, the contents of t.File, and runs it on a new CPU of
+// variant until it loops, stops or reaches the cycle limit. It returns an
+// error only if the test cannot be started.
+func (t DormannTest) Run(variant core.Variant, image []byte) (DormannResult, error) {
+	if int(t.Load)+len(image) > 0x10000 {
+		return DormannResult{}, fmt.Errorf("conformance: %s is %d bytes, too large to load at $%04X",
+			t.File, len(image), t.Load)
+	}
+
+	ram := &dormannRAM{}
+	copy(ram.memory[t.Load:], image)
+	cpu, err := core.NewCPU(variant, ram)
+	if err != nil {
+		return DormannResult{}, err
+	}
+	// Start at cycle zero, counting from the end of the reset sequence
+	cpu.Reset()
+	state := cpu.Snapshot()
+	state.PC = t.Start
+	state.TotalCycles = 0
+	if err := cpu.Restore(state); err != nil {
+		return DormannResult{}, err
+	}
+
+	if t.StopOnSTP {
+		cpu.SetBreakFunc(func(pc uint16) bool { return ram.memory[pc] == 0xDB })
+	}
+	limit := t.MaxCycles
+	if limit == 0 {
+		limit = 500_000_000
+	}
+
+	var cycles uint64
+	var reason string
+	for {
+		step, err := cpu.StepInstruction()
+		cycles += uint64(step.Cycles)
+		if errors.Is(err, core.ErrBreakpoint) {
+			break
+		}
+		if err != nil {
+			reason = err.Error()
+			break
+		}
+		if step.Event == core.EventInstruction && cpu.Registers().PC == step.PC {
+			break
+		}
+		if cycles >= limit {
+			reason = "did not finish"
+			break
+		}
+	}
+
+	regs := cpu.Registers()
+	result := DormannResult{
+		PC:        regs.PC,
+		Registers: regs,
+		Cycles:    cycles,
+		TestCase:  -1,
+	}
+	if t.TestCase != 0 {
+		result.TestCase = int(ram.memory[t.TestCase])
+	}
+
+	switch {
+	case reason != "":
+		result.Reason = reason
+	case t.Success != 0 && regs.PC != t.Success:
+		result.Reason = fmt.Sprintf("trapped at $%04X", regs.PC)
+	case t.ErrorAddr != 0 && ram.memory[t.ErrorAddr] != 0:
+		result.Reason = fmt.Sprintf("error flag at $%04X is $%02X", t.ErrorAddr, ram.memory[t.ErrorAddr])
+	default:
+		result.Passed = true
+	}
+	return result, nil
+}
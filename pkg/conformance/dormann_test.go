@@ -0,0 +1,152 @@
+package conformance
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/asm"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// TestDormann runs the test binaries in testdata on each variant that
+// supports them. A missing binary fails the test; see testdata/README.md.
+func TestDormann(t *testing.T) {
+	suites := []struct {
+		test     DormannTest
+		variants []core.Variant
+	}{
+		{FunctionalTest, []core.Variant{core.VariantNMOS, core.VariantWDC65C02}},
+		{DecimalTest, []core.Variant{core.VariantNMOS, core.VariantWDC65C02}},
+		{ExtendedOpcodesTest, []core.Variant{core.VariantWDC65C02}},
+	}
+
+	for _, suite := range suites {
+		for _, variant := range suite.variants {
+			t.Run(suite.test.Name+"/"+variant.String(), func(t *testing.T) {
+				image, err := os.ReadFile(filepath.Join("testdata", suite.test.File))
+				if errors.Is(err, fs.ErrNotExist) {
+					t.Fatalf("testdata/%s not found; see testdata/README.md", suite.test.File)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if testing.Short() {
+					t.Skip("skipping in short mode")
+				}
+
+				result, err := suite.test.Run(variant, image)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !result.Passed {
+					t.Fatal(result)
+				}
+			})
+		}
+	}
+}
+
+// TestDormannOutcome checks how runs end, using small programs that signal
+// their result the way the real tests do.
+func TestDormannOutcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant core.Variant
+		test    DormannTest
+		src     string
+		want    string // Result.String() prefix, or "passed"
+	}{
+		{
+			name: "Loop at the success address",
+			test: DormannTest{Start: 0x0400, Success: 0x0500, TestCase: 0x0200},
+			src: `
+                .org $0400
+                lda #$01
+                sta $0200
+                jmp $0500
+                .org $0500
+                jmp $0500`,
+			want: "passed",
+		},
+		{
+			name: "Branch trap",
+			test: DormannTest{Start: 0x0400, Success: 0x0500, TestCase: 0x0200},
+			src: `
+                .org $0400
+                lda #$2A
+                sta $0200
+                ldx #$01
+                bne *
+                .org $0500
+                jmp $0500`,
+			want: "trapped at $0407 in test $2A after 11 cycles (PC:0407 A:2A X:01 Y:00 P:34 SP:FD)",
+		},
+		{
+			name: "STP with error flag clear",
+			test: DormannTest{Start: 0x0200, ErrorAddr: 0x000B, StopOnSTP: true},
+			src: `
+                .org $0200
+                lda #$00
+                sta $0B
+                .byte $DB`,
+			want: "passed",
+		},
+		{
+			name: "STP with error flag set",
+			test: DormannTest{Start: 0x0200, ErrorAddr: 0x000B, StopOnSTP: true},
+			src: `
+                .org $0200
+                lda #$01
+                sta $0B
+                .byte $DB`,
+			want: "error flag at $000B is $01 after 5 cycles (PC:0204",
+		},
+		{
+			name:    "STP on the 65C02",
+			variant: core.VariantWDC65C02,
+			test:    DormannTest{Start: 0x0200, ErrorAddr: 0x000B, StopOnSTP: true},
+			src: `
+                .org $0200
+                .byte $DB`,
+			want: "passed",
+		},
+		{
+			name: "JAM",
+			test: DormannTest{Start: 0x0400, Success: 0x0500, TestCase: 0x0200},
+			src: `
+                .org $0400
+                .byte $02`,
+			want: "CPU jammed at $0400",
+		},
+		{
+			name: "Cycle limit",
+			test: DormannTest{Start: 0x0400, Success: 0x0500, MaxCycles: 100},
+			src: `
+                .org $0400
+        loop:   nop
+                jmp loop`,
+			want: "did not finish after 100 cycles",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origin, image := asm.MustAssemble(tt.variant, tt.src).Image()
+			tt.test.Load = origin
+			result, err := tt.test.Run(tt.variant, image)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.String(); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if result.Passed != (tt.want == "passed") {
+				t.Errorf("Passed = %v for %q", result.Passed, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// SingleStepTests (https://github.com/SingleStepTests/65x02) has a JSON file
+// per opcode, each an array of cases that run one instruction from a random
+// state:
+//
+//	{
+//	  "name": "a9 3c 12",
+//	  "initial": {"pc": 512, "s": 253, "a": 0, "x": 0, "y": 0, "p": 36,
+//	              "ram": [[512, 169], [513, 60]]},
+//	  "final":   {"pc": 514, "s": 253, "a": 60, "x": 0, "y": 0, "p": 36,
+//	              "ram": [[512, 169], [513, 60]]},
+//	  "cycles":  [[512, 169, "read"], [513, 60, "read"]]
+//	}
+//
+// The cycles list every bus access the instruction makes, dummy accesses
+// included, so a case checks timing and bus order as well as the result.
+
+// SingleStepCase is one test case.
+type SingleStepCase struct {
+	Name    string            `json:"name"`
+	Initial SingleStepState   `json:"initial"`
+	Final   SingleStepState   `json:"final"`
+	Cycles  []SingleStepCycle `json:"cycles"`
+}
+
+// SingleStepState is the CPU registers and the contents of every address a
+// case uses.
+type SingleStepState struct {
+	PC  uint16      `json:"pc"`
+	S   byte        `json:"s"`
+	A   byte        `json:"a"`
+	X   byte        `json:"x"`
+	Y   byte        `json:"y"`
+	P   byte        `json:"p"`
+	RAM [][2]uint16 `json:"ram"` // Address and value pairs
+}
+
+// SingleStepCycle is one bus access, written in JSON as
+// [address, value, "read" or "write"].
+type SingleStepCycle struct {
+	Addr  uint16
+	Data  byte
+	Write bool
+}
+
+func (c *SingleStepCycle) UnmarshalJSON(b []byte) error {
+	var fields [3]any
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	addr, ok1 := fields[0].(float64)
+	data, ok2 := fields[1].(float64)
+	rw, ok3 := fields[2].(string)
+	if !ok1 || !ok2 || !ok3 || (rw != "read" && rw != "write") {
+		return fmt.Errorf("conformance: bad bus cycle %s", b)
+	}
+	c.Addr, c.Data, c.Write = uint16(addr), byte(data), rw == "write"
+	return nil
+}
+
+func (c SingleStepCycle) String() string {
+	return fmt.Sprintf("%s %04X %02X", rw(c.Write), c.Addr, c.Data)
+}
+
+func rw(write bool) string {
+	if write {
+		return "W"
+	}
+	return "R"
+}
+
+// ReadSingleStep decodes a file of SingleStepTests cases.
+func ReadSingleStep(r io.Reader) ([]SingleStepCase, error) {
+	var cases []SingleStepCase
+	if err := json.NewDecoder(r).Decode(&cases); err != nil {
+		return nil, fmt.Errorf("conformance: %w", err)
+	}
+	return cases, nil
+}
+
+// recordingBus is sparse memory that records every access made to it.
+// Addresses a case does not set read as zero.
+type recordingBus struct {
+	memory map[uint16]byte
+	cycles []SingleStepCycle
+}
+
+func (b *recordingBus) Read(addr uint16) byte {
+	data := b.memory[addr]
+	b.cycles = append(b.cycles, SingleStepCycle{Addr: addr, Data: data})
+	return data
+}
+
+func (b *recordingBus) Write(addr uint16, data byte) {
+	b.memory[addr] = data
+	b.cycles = append(b.cycles, SingleStepCycle{Addr: addr, Data: data, Write: true})
+}
+
+// SingleStepRunner runs cases on a CPU of one variant.
+type SingleStepRunner struct {
+	cpu core.CPU
+	bus *recordingBus
+}
+
+// NewSingleStepRunner returns a runner with a CPU of variant.
+func NewSingleStepRunner(variant core.Variant) (*SingleStepRunner, error) {
+	bus := &recordingBus{memory: make(map[uint16]byte)}
+	cpu, err := core.NewCPU(variant, bus)
+	if err != nil {
+		return nil, err
+	}
+	return &SingleStepRunner{cpu: cpu, bus: bus}, nil
+}
+
+// Run executes one instruction from tc.Initial and returns how the result
+// differs from tc.Final and tc.Cycles, one line per register, address or
+// cycle. It returns nil if the case passes.
+func (r *SingleStepRunner) Run(tc SingleStepCase) []string {
+	clear(r.bus.memory)
+	for _, m := range tc.Initial.RAM {
+		r.bus.memory[m[0]] = byte(m[1])
+	}
+	r.bus.cycles = r.bus.cycles[:0]
+
+	in := tc.Initial
+	if err := r.cpu.Restore(core.Snapshot{
+		Registers: core.Registers{PC: in.PC, SP: in.S, A: in.A, X: in.X, Y: in.Y, Status: in.P},
+		Variant:   r.cpu.GetVariant(),
+		Unstable:  r.cpu.Snapshot().Unstable,
+	}); err != nil {
+		return []string{err.Error()}
+	}
+
+	var diffs []string
+	if _, err := r.cpu.StepInstruction(); err != nil {
+		diffs = append(diffs, err.Error())
+	}
+
+	got := r.cpu.Registers()
+	want := tc.Final
+	for _, reg := range []struct {
+		name      string
+		got, want uint16
+	}{
+		{"PC", got.PC, want.PC},
+		{"S", uint16(got.SP), uint16(want.S)},
+		{"A", uint16(got.A), uint16(want.A)},
+		{"X", uint16(got.X), uint16(want.X)},
+		{"Y", uint16(got.Y), uint16(want.Y)},
+		{"P", uint16(got.Status), uint16(want.P)},
+	} {
+		if reg.got != reg.want {
+			diffs = append(diffs, fmt.Sprintf("%s: got $%02X, want $%02X", reg.name, reg.got, reg.want))
+		}
+	}
+
+	for _, m := range want.RAM {
+		if data := r.bus.memory[m[0]]; data != byte(m[1]) {
+			diffs = append(diffs, fmt.Sprintf("RAM $%04X: got $%02X, want $%02X", m[0], data, m[1]))
+		}
+	}
+
+	cycles := r.bus.cycles
+	for i := 0; i < max(len(cycles), len(tc.Cycles)); i++ {
+		switch {
+		case i >= len(cycles):
+			diffs = append(diffs, fmt.Sprintf("cycle %d: missing, want %v", i+1, tc.Cycles[i]))
+		case i >= len(tc.Cycles):
+			diffs = append(diffs, fmt.Sprintf("cycle %d: got %v, want none", i+1, cycles[i]))
+		case cycles[i] != tc.Cycles[i]:
+			diffs = append(diffs, fmt.Sprintf("cycle %d: got %v, want %v", i+1, cycles[i], tc.Cycles[i]))
+		}
+	}
+	return diffs
+}
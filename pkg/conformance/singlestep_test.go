@@ -0,0 +1,153 @@
+package conformance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// adcSBC are the opcodes of ADC and SBC in every NMOS addressing mode, whose
+// cases run in decimal mode whenever the random P has D set.
+var adcSBC = []string{
+	"61", "65", "69", "6d", "71", "75", "79", "7d",
+	"e1", "e5", "e9", "ed", "f1", "f5", "f9", "fd",
+}
+
+// TestSingleStep runs the SingleStepTests files in testdata/singlestep. The
+// files for the unstable NMOS opcodes and for ADC and SBC must be present;
+// see testdata/README.md for where to get them.
+func TestSingleStep(t *testing.T) {
+	for _, suite := range []struct {
+		dir      string
+		variant  core.Variant
+		required []string
+	}{
+		// ANE, LXA, SHA, SHX, SHY and TAS
+		{"6502", core.VariantNMOS, append([]string{"8b", "ab", "93", "9f", "9e", "9c", "9b"}, adcSBC...)},
+		// The 65C02 adds (zp) modes of ADC and SBC
+		{"wdc65c02", core.VariantWDC65C02, append([]string{"72", "f2"}, adcSBC...)},
+	} {
+		t.Run(suite.dir, func(t *testing.T) {
+			dir := filepath.Join("testdata", "singlestep", suite.dir)
+			for _, op := range suite.required {
+				if _, err := os.Stat(filepath.Join(dir, op+".json")); err != nil {
+					t.Errorf("%s/%s.json not found; see testdata/README.md", dir, op)
+				}
+			}
+			files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+			if len(files) == 0 {
+				t.Fatalf("no tests in %s", dir)
+			}
+			if testing.Short() {
+				t.Skip("skipping in short mode")
+			}
+
+			runner, err := NewSingleStepRunner(suite.variant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, file := range files {
+				f, err := os.Open(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cases, err := ReadSingleStep(f)
+				f.Close()
+				if err != nil {
+					t.Fatalf("%s: %v", file, err)
+				}
+
+				// Report the first failing case of each opcode
+				for _, tc := range cases {
+					if diffs := runner.Run(tc); diffs != nil {
+						t.Errorf("%s: case %q:\n\t%s", filepath.Base(file), tc.Name, strings.Join(diffs, "\n\t"))
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSingleStepRunner(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant core.Variant
+		json    string
+		want    []string
+	}{
+		{
+			name: "LDA immediate",
+			json: `[{"name": "a9 3c 12",
+                "initial": {"pc": 512, "s": 253, "a": 0, "x": 0, "y": 0, "p": 38,
+                            "ram": [[512, 169], [513, 60], [514, 18]]},
+                "final":   {"pc": 514, "s": 253, "a": 60, "x": 0, "y": 0, "p": 36,
+                            "ram": [[512, 169], [513, 60], [514, 18]]},
+                "cycles":  [[512, 169, "read"], [513, 60, "read"]]}]`,
+		},
+		{
+			name: "NMOS INC writes twice",
+			json: `[{"name": "ee 10 20",
+                "initial": {"pc": 512, "s": 253, "a": 0, "x": 0, "y": 0, "p": 36,
+                            "ram": [[512, 238], [513, 16], [514, 32], [8208, 5]]},
+                "final":   {"pc": 515, "s": 253, "a": 0, "x": 0, "y": 0, "p": 36,
+                            "ram": [[8208, 6]]},
+                "cycles":  [[512, 238, "read"], [513, 16, "read"], [514, 32, "read"],
+                            [8208, 5, "read"], [8208, 5, "write"], [8208, 6, "write"]]}]`,
+		},
+		{
+			name:    "65C02 INC reads twice",
+			variant: core.VariantWDC65C02,
+			json: `[{"name": "ee 10 20",
+                "initial": {"pc": 512, "s": 253, "a": 0, "x": 0, "y": 0, "p": 36,
+                            "ram": [[512, 238], [513, 16], [514, 32], [8208, 5]]},
+                "final":   {"pc": 515, "s": 253, "a": 0, "x": 0, "y": 0, "p": 36,
+                            "ram": [[8208, 6]]},
+                "cycles":  [[512, 238, "read"], [513, 16, "read"], [514, 32, "read"],
+                            [8208, 5, "read"], [8208, 5, "read"], [8208, 6, "write"]]}]`,
+		},
+		{
+			name: "Mismatches",
+			json: `[{"name": "ee 10 20",
+                "initial": {"pc": 512, "s": 253, "a": 0, "x": 0, "y": 0, "p": 36,
+                            "ram": [[512, 238], [513, 16], [514, 32], [8208, 5]]},
+                "final":   {"pc": 515, "s": 253, "a": 1, "x": 0, "y": 0, "p": 36,
+                            "ram": [[8208, 7]]},
+                "cycles":  [[512, 238, "read"], [513, 16, "read"], [514, 32, "read"],
+                            [8208, 5, "read"], [8208, 5, "read"]]}]`,
+			want: []string{
+				"A: got $00, want $01",
+				"RAM $2010: got $06, want $07",
+				"cycle 5: got W 2010 05, want R 2010 05",
+				"cycle 6: got W 2010 06, want none",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cases, err := ReadSingleStep(strings.NewReader(tt.json))
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner, err := NewSingleStepRunner(tt.variant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := runner.Run(cases[0])
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got\n\t%s\nwant\n\t%s", strings.Join(got, "\n\t"), strings.Join(tt.want, "\n\t"))
+			}
+		})
+	}
+}
+
+func TestReadSingleStepBadCycle(t *testing.T) {
+	_, err := ReadSingleStep(strings.NewReader(`[{"cycles": [[512, 169, "fetch"]]}]`))
+	if err == nil {
+		t.Fatal("expected an error for an unknown access type")
+	}
+}
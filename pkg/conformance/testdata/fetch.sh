@@ -0,0 +1,20 @@
+#!/bin/sh
+# fetch.sh downloads the test data described in README.md into this
+# directory. 6502_decimal_test.a65 still has to be assembled with as65.
+set -eu
+cd "$(dirname "$0")"
+
+dormann=https://raw.githubusercontent.com/Klaus2m5/6502_65C02_functional_tests/master
+curl -fLO $dormann/bin_files/6502_functional_test.bin
+curl -fLO $dormann/bin_files/65C02_extended_opcodes_test.bin
+curl -fLO $dormann/6502_decimal_test.a65
+
+singlestep=https://raw.githubusercontent.com/SingleStepTests/65x02/main
+adcsbc="61 65 69 6d 71 75 79 7d e1 e5 e9 ed f1 f5 f9 fd"
+mkdir -p singlestep/6502 singlestep/wdc65c02
+for op in 8b ab 93 9f 9e 9c 9b $adcsbc; do
+	curl -fLo singlestep/6502/$op.json $singlestep/6502/v1/$op.json
+done
+for op in 72 f2 $adcsbc; do
+	curl -fLo singlestep/wdc65c02/$op.json $singlestep/wdc65c02/v1/$op.json
+done
@@ -8,6 +8,9 @@ package core
 //
 // Page crossing occurs when an indexed address moves to a different page
 // (different high byte). Many instructions take an extra cycle when this happens.
+//
+// The CPU itself resolves operands a cycle at a time, with the steps in
+// modeSteps; these functions make the same accesses all at once.
 
 // AddrImmediate handles immediate addressing mode (#$nn).
 // The operand is the byte immediately following the opcode.
@@ -22,7 +25,7 @@ func (c *BaseCPU) AddrImmediate() (uint16, bool) {
 // Addresses the first 256 bytes of memory (0x0000-0x00FF).
 // Uses single-byte address, faster than absolute addressing.
 func (c *BaseCPU) AddrZeroPage() (uint16, bool) {
-	addr := uint16(c.Bus.Read(c.PC))
+	addr := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
 	return addr, false
 }
@@ -30,25 +33,27 @@ func (c *BaseCPU) AddrZeroPage() (uint16, bool) {
 // AddrZeroPageX handles zero page indexed with X addressing mode ($nn,X).
 // Adds X register to zero page address with wraparound within zero page.
 func (c *BaseCPU) AddrZeroPageX() (uint16, bool) {
-	addr := uint16(c.Bus.Read(c.PC) + c.X)
+	base := c.ReadAs(AccessOperand, c.PC)
 	c.PC++
-	return addr & 0x00FF, false // Wrap to stay in zero page
+	c.IndexCycle(uint16(base))
+	return uint16(base + c.X), false // Wrap to stay in zero page
 }
 
 // AddrZeroPageY handles zero page indexed with Y addressing mode ($nn,Y).
 // Adds Y register to zero page address with wraparound within zero page.
 func (c *BaseCPU) AddrZeroPageY() (uint16, bool) {
-	addr := uint16(c.Bus.Read(c.PC) + c.Y)
+	base := c.ReadAs(AccessOperand, c.PC)
 	c.PC++
-	return addr & 0x00FF, false // Wrap to stay in zero page
+	c.IndexCycle(uint16(base))
+	return uint16(base + c.Y), false // Wrap to stay in zero page
 }
 
 // AddrAbsolute handles absolute addressing mode ($nnnn).
 // Uses a full 16-bit address specified by two bytes (low, high).
 func (c *BaseCPU) AddrAbsolute() (uint16, bool) {
-	low := uint16(c.Bus.Read(c.PC))
+	low := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
-	high := uint16(c.Bus.Read(c.PC))
+	high := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
 	return (high << 8) | low, false
 }
@@ -57,9 +62,9 @@ func (c *BaseCPU) AddrAbsolute() (uint16, bool) {
 // Adds X register to the 16-bit address.
 // Returns true if page boundary was crossed (high byte changed).
 func (c *BaseCPU) AddrAbsoluteX() (uint16, bool) {
-	low := uint16(c.Bus.Read(c.PC))
+	low := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
-	high := uint16(c.Bus.Read(c.PC))
+	high := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
 	addr := ((high << 8) | low) + uint16(c.X)
 	return addr, (addr & 0xFF00) != (high << 8)
@@ -69,9 +74,9 @@ func (c *BaseCPU) AddrAbsoluteX() (uint16, bool) {
 // Adds Y register to the 16-bit address.
 // Returns true if page boundary was crossed (high byte changed).
 func (c *BaseCPU) AddrAbsoluteY() (uint16, bool) {
-	low := uint16(c.Bus.Read(c.PC))
+	low := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
-	high := uint16(c.Bus.Read(c.PC))
+	high := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
 	addr := ((high << 8) | low) + uint16(c.Y)
 	return addr, (addr & 0xFF00) != (high << 8)
@@ -81,20 +86,24 @@ func (c *BaseCPU) AddrAbsoluteY() (uint16, bool) {
 // NMOS6502: Has page boundary bug where JMP ($10FF) reads high byte from $1000
 // WDC65C02: Bug is fixed
 func (c *BaseCPU) AddrIndirect() (uint16, bool) {
-	low := uint16(c.Bus.Read(c.PC))
+	low := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
-	high := uint16(c.Bus.Read(c.PC))
+	high := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
 	ptr := (high << 8) | low
 
 	// Check if this variant has the JMP indirect bug
-	if c.Variant.HasJMPIndirectBug() && low == 0x00FF {
-		// NMOS 6502: Read high byte from start of page (bug)
-		return (uint16(c.Bus.Read(ptr&0xFF00)) << 8) | uint16(c.Bus.Read(ptr)), false
+	if c.Variant.HasJMPIndirectBug() {
+		// NMOS 6502: The high byte does not carry into the page, so
+		// JMP ($10FF) reads its high byte from $1000 (bug)
+		target := uint16(c.ReadAs(AccessPointer, ptr))
+		return uint16(c.ReadAs(AccessPointer, ptr&0xFF00|(ptr+1)&0x00FF))<<8 | target, false
 	}
 
-	// Normal behavior (or WDC65C02 fixed behavior)
-	return (uint16(c.Bus.Read(ptr+1)) << 8) | uint16(c.Bus.Read(ptr)), false
+	// WDC65C02: Fixed, at the cost of a cycle re-reading the operand
+	c.DummyRead(c.PC - 1)
+	target := uint16(c.ReadAs(AccessPointer, ptr))
+	return uint16(c.ReadAs(AccessPointer, ptr+1))<<8 | target, false
 }
 
 // AddrIndirectX handles indexed indirect addressing mode (($nn,X)).
@@ -102,10 +111,12 @@ func (c *BaseCPU) AddrIndirect() (uint16, bool) {
 // Used pattern: LDA ($40,X) where X=0x05 reads address from $0045-$0046.
 // Wraps within zero page.
 func (c *BaseCPU) AddrIndirectX() (uint16, bool) {
-	zeroPageAddr := uint16(c.Bus.Read(c.PC) + c.X)
+	base := c.ReadAs(AccessOperand, c.PC)
 	c.PC++
-	low := uint16(c.Bus.Read(zeroPageAddr & 0x00FF))
-	high := uint16(c.Bus.Read((zeroPageAddr + 1) & 0x00FF))
+	c.IndexCycle(uint16(base))
+	zeroPageAddr := uint16(base + c.X)
+	low := uint16(c.ReadAs(AccessPointer, zeroPageAddr&0x00FF))
+	high := uint16(c.ReadAs(AccessPointer, (zeroPageAddr+1)&0x00FF))
 	return (high << 8) | low, false
 }
 
@@ -114,10 +125,10 @@ func (c *BaseCPU) AddrIndirectX() (uint16, bool) {
 // Used pattern: LDA ($40),Y where $0040-$0041 contains address, then add Y.
 // Returns true if adding Y crossed a page boundary.
 func (c *BaseCPU) AddrIndirectY() (uint16, bool) {
-	zeroPageAddr := uint16(c.Bus.Read(c.PC))
+	zeroPageAddr := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
-	low := uint16(c.Bus.Read(zeroPageAddr & 0x00FF))
-	high := uint16(c.Bus.Read((zeroPageAddr + 1) & 0x00FF))
+	low := uint16(c.ReadAs(AccessPointer, zeroPageAddr&0x00FF))
+	high := uint16(c.ReadAs(AccessPointer, (zeroPageAddr+1)&0x00FF))
 	addr := ((high << 8) | low) + uint16(c.Y)
 	return addr, (addr & 0xFF00) != (high << 8)
 }
@@ -127,7 +138,7 @@ func (c *BaseCPU) AddrIndirectY() (uint16, bool) {
 // Range: -128 to +127 bytes from the instruction following the branch.
 // Returns true if the branch crosses a page boundary.
 func (c *BaseCPU) AddrRelative() (uint16, bool) {
-	offset := uint16(c.Bus.Read(c.PC))
+	offset := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
 	if offset < 0x80 {
 		return c.PC + offset, (c.PC & 0xFF00) != ((c.PC + offset) & 0xFF00)
@@ -138,24 +149,157 @@ func (c *BaseCPU) AddrRelative() (uint16, bool) {
 // AddrZeroPageIndirect - zero page indirect addressing mode (($nn))
 // This is a NEW addressing mode in WDC65C02
 func (c *BaseCPU) AddrZeroPageIndirect() (uint16, bool) {
-	zeroPageAddr := uint16(c.Bus.Read(c.PC))
+	zeroPageAddr := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
-	low := uint16(c.Bus.Read(zeroPageAddr & 0x00FF))
-	high := uint16(c.Bus.Read((zeroPageAddr + 1) & 0x00FF))
+	low := uint16(c.ReadAs(AccessPointer, zeroPageAddr&0x00FF))
+	high := uint16(c.ReadAs(AccessPointer, (zeroPageAddr+1)&0x00FF))
 	return (high << 8) | low, false
 }
 
 // AddrAbsoluteIndexedIndirect - absolute indexed indirect addressing mode (($nnnn,X))
 // This is a NEW addressing mode in WDC65C02
 func (c *BaseCPU) AddrAbsoluteIndexedIndirect() (uint16, bool) {
-	low := uint16(c.Bus.Read(c.PC))
+	low := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
-	high := uint16(c.Bus.Read(c.PC))
+	high := uint16(c.ReadAs(AccessOperand, c.PC))
 	c.PC++
 	ptr := ((high << 8) | low) + uint16(c.X)
+	c.DummyRead(c.PC - 1)
 
 	// Read target address from pointer
-	targetLow := uint16(c.Bus.Read(ptr))
-	targetHigh := uint16(c.Bus.Read(ptr + 1))
+	targetLow := uint16(c.ReadAs(AccessPointer, ptr))
+	targetHigh := uint16(c.ReadAs(AccessPointer, ptr+1))
 	return (targetHigh << 8) | targetLow, false
 }
+
+// modeSteps lists the steps that resolve the operand of each addressing
+// mode and then run the handler. Implied, accumulator and immediate
+// operands need no step of their own: execute reads the byte after the
+// opcode, or points the handler at it.
+var modeSteps = [...][]func(c *BaseCPU, op *Opcode){
+	Implied:           {execute},
+	Accumulator:       {execute},
+	Immediate:         {execute},
+	ZeroPage:          {fetchOperand, execute},
+	ZeroPageRelative:  {fetchOperand, execute},
+	ZeroPageX:         {fetchOperand, indexZeroPageX, execute},
+	ZeroPageY:         {fetchOperand, indexZeroPageY, execute},
+	Absolute:          {fetchOperand, fetchHigh, execute},
+	AbsoluteX:         {fetchOperand, fetchHighX, fixPage, execute},
+	AbsoluteY:         {fetchOperand, fetchHighY, fixPage, execute},
+	Indirect:          {fetchOperand, fetchHigh, fixIndirect, readPointer, readPointerHigh, execute},
+	IndirectX:         {fetchOperand, indexZeroPageX, readPointer, readZeroPagePointerHigh, execute},
+	IndirectY:         {fetchOperand, readPointer, readZeroPagePointerHighY, fixPage, execute},
+	Relative:          {fetchOffset, execute},
+	ZeroPageIndirect:  {fetchOperand, readPointer, readZeroPagePointerHigh, execute},
+	AbsoluteXIndirect: {fetchOperand, fetchHighX, fixIndirect, readPointer, readPointerHigh, execute},
+}
+
+// fetchOperand reads the first operand byte: a zero page address, or the
+// low byte of an absolute one.
+func fetchOperand(c *BaseCPU, op *Opcode) {
+	c.seq.addr = uint16(c.ReadAs(AccessOperand, c.PC))
+	c.PC++
+}
+
+// fetchHigh reads the high byte of an absolute address.
+func fetchHigh(c *BaseCPU, op *Opcode) {
+	c.seq.addr |= uint16(c.ReadAs(AccessOperand, c.PC)) << 8
+	c.PC++
+	c.resolved(op)
+}
+
+// fetchHighX reads the high byte of an absolute address and adds X.
+func fetchHighX(c *BaseCPU, op *Opcode) {
+	c.fetchHighIndexed(c.X)
+}
+
+// fetchHighY reads the high byte of an absolute address and adds Y.
+func fetchHighY(c *BaseCPU, op *Opcode) {
+	c.fetchHighIndexed(c.Y)
+}
+
+func (c *BaseCPU) fetchHighIndexed(index byte) {
+	base := c.seq.addr | uint16(c.ReadAs(AccessOperand, c.PC))<<8
+	c.PC++
+	c.seq.addr = base + uint16(index)
+	c.seq.crossed = c.seq.addr&0xFF00 != base&0xFF00
+}
+
+// indexZeroPageX adds X to a zero page address, wrapping within zero page.
+func indexZeroPageX(c *BaseCPU, op *Opcode) {
+	c.IndexCycle(c.seq.addr)
+	c.seq.addr = uint16(byte(c.seq.addr) + c.X)
+}
+
+// indexZeroPageY adds Y to a zero page address, wrapping within zero page.
+func indexZeroPageY(c *BaseCPU, op *Opcode) {
+	c.IndexCycle(c.seq.addr)
+	c.seq.addr = uint16(byte(c.seq.addr) + c.Y)
+}
+
+// fixPage spends the cycle that fixes up the page of an indexed address.
+// Stores and read-modify-write instructions always take it; reads only when
+// the page changes, and otherwise go straight on to the handler.
+func fixPage(c *BaseCPU, op *Opcode) {
+	if c.seq.crossed || !op.PageCrossCycle {
+		unfixed := c.seq.addr
+		if c.seq.crossed {
+			unfixed -= 0x100
+		}
+		c.IndexCycle(unfixed)
+	}
+}
+
+// fixIndirect spends the cycle the 65C02 takes to fix the JMP indirect
+// page bug, re-reading the operand.
+func fixIndirect(c *BaseCPU, op *Opcode) {
+	if !c.Variant.HasJMPIndirectBug() {
+		c.DummyRead(c.PC - 1)
+	}
+}
+
+// readPointer reads the low byte of the address a pointer points to.
+func readPointer(c *BaseCPU, op *Opcode) {
+	c.seq.ptr = c.seq.addr
+	c.seq.addr = uint16(c.ReadAs(AccessPointer, c.seq.ptr))
+}
+
+// readPointerHigh reads the high byte through an absolute pointer. On the
+// NMOS 6502 the pointer does not carry into its page, so JMP ($10FF) reads
+// its high byte from $1000.
+func readPointerHigh(c *BaseCPU, op *Opcode) {
+	ptr := c.seq.ptr + 1
+	if c.Variant.HasJMPIndirectBug() {
+		ptr = c.seq.ptr&0xFF00 | ptr&0x00FF
+	}
+	c.seq.addr |= uint16(c.ReadAs(AccessPointer, ptr)) << 8
+	c.resolved(op)
+}
+
+// readZeroPagePointerHigh reads the high byte through a zero page pointer,
+// wrapping within zero page.
+func readZeroPagePointerHigh(c *BaseCPU, op *Opcode) {
+	c.seq.addr |= uint16(c.ReadAs(AccessPointer, (c.seq.ptr+1)&0x00FF)) << 8
+}
+
+// readZeroPagePointerHighY reads the high byte through a zero page pointer
+// and adds Y.
+func readZeroPagePointerHighY(c *BaseCPU, op *Opcode) {
+	base := c.seq.addr | uint16(c.ReadAs(AccessPointer, (c.seq.ptr+1)&0x00FF))<<8
+	c.seq.addr = base + uint16(c.Y)
+	c.seq.crossed = c.seq.addr&0xFF00 != base&0xFF00
+}
+
+// fetchOffset reads a branch offset and computes the target.
+func fetchOffset(c *BaseCPU, op *Opcode) {
+	offset := uint16(c.ReadAs(AccessOperand, c.PC))
+	c.PC++
+	target := c.PC + offset
+	if offset >= 0x80 {
+		target -= 0x100
+	}
+	c.seq.addr = target
+	c.seq.crossed = target&0xFF00 != c.PC&0xFF00
+	c.resolved(op)
+}
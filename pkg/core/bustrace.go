@@ -0,0 +1,50 @@
+package core
+
+// AccessKind classifies a bus access by what the CPU was doing when it made
+// it. The bus itself never sees the kind; only a bus trace does.
+type AccessKind byte
+
+const (
+	AccessData    AccessKind = iota // Instruction data read or write
+	AccessOpcode                    // Opcode fetch (SYNC high)
+	AccessOperand                   // Operand byte fetched from the instruction stream
+	AccessPointer                   // Indirect address read
+	AccessStack                     // Push or pull at 0x0100-0x01FF
+	AccessVector                    // Interrupt or reset vector read
+	AccessDummy                     // Read or write whose data the CPU discards
+)
+
+var accessKindNames = [...]string{
+	AccessData:    "data",
+	AccessOpcode:  "opcode",
+	AccessOperand: "operand",
+	AccessPointer: "pointer",
+	AccessStack:   "stack",
+	AccessVector:  "vector",
+	AccessDummy:   "dummy",
+}
+
+func (k AccessKind) String() string {
+	if int(k) < len(accessKindNames) {
+		return accessKindNames[k]
+	}
+	return "unknown"
+}
+
+// BusCycle is one bus access reported to a bus trace.
+type BusCycle struct {
+	Cycle uint64 // Clock cycle, on the same count as TotalCycles
+	Addr  uint16
+	Data  byte
+	Write bool
+	Kind  AccessKind
+}
+
+// SetBusTrace installs f to be called after every bus access, or removes
+// the trace if f is nil.
+//
+// Accesses are numbered on the same count as TotalCycles. Every cycle of an
+// instruction is a bus access, so each Step reports exactly one.
+func (c *BaseCPU) SetBusTrace(f func(BusCycle)) {
+	c.busTrace = f
+}
@@ -3,6 +3,7 @@
 // This package implements the common architecture shared by NMOS 6502 and WDC65C02
 // processors. It provides:
 //   - BaseCPU: Core CPU state and operations
+//   - CPU: Interface implemented by every variant, built with NewCPU
 //   - Bus: Memory interface abstraction
 //   - Variant: CPU variant identification and behavior
 //   - Common addressing modes
@@ -28,8 +29,13 @@ type BaseCPU struct {
 
 	Bus Bus // Memory and I/O interface
 
-	Cycles byte // Remaining cycles for current instruction
-	Halted bool // CPU halted (e.g., STP instruction on WDC65C02)
+	Cycles      byte   // Cycles run so far in the sequence in progress; zero between instructions
+	TotalCycles uint64 // Clock cycles executed since the CPU was created
+	Halted      bool   // CPU halted (e.g., STP instruction on WDC65C02)
+	State       State  // Execution state (running, jammed by JAM, waiting in WAI, stopped by STP)
+
+	InstructionPC uint16 // Address of the most recently fetched instruction
+	Opcode        byte   // Most recently fetched opcode
 
 	// Interrupt pending flags
 	NMIPending   bool // Non-Maskable Interrupt pending
@@ -37,6 +43,21 @@ type BaseCPU struct {
 	ResetPending bool // Reset pending
 
 	Variant Variant // CPU variant (NMOS vs WDC65C02)
+
+	Unstable UnstableConfig // Behavior of the unstable NMOS opcodes
+
+	// BreakFunc, if set, is called before each instruction is fetched.
+	// Returning true stops execution with ErrBreakpoint.
+	BreakFunc   func(pc uint16) bool
+	breakResume bool
+
+	busTrace func(BusCycle)
+	access   AccessKind // Kind of the bus access in progress, for busTrace
+
+	seq       Sequence // Instruction or interrupt sequence in progress
+	stepping  bool     // Step is running a step of seq
+	stepCycle uint64   // TotalCycles when Step began the step; later accesses are queued
+	more      bool     // The handler called Continue
 }
 
 // Processor Status Register flags (8 bits: NV-BDIZC)
@@ -57,12 +78,14 @@ const (
 //   - SP = 0xFD (stack pointer starts 3 bytes below top)
 //   - Status = 0x34 (Interrupt Disable and Unused flags set)
 //   - All other registers = 0
+//   - Unstable = DefaultUnstableConfig()
 func NewBaseCPU(bus Bus, variant Variant) *BaseCPU {
 	return &BaseCPU{
-		SP:      0xFD,
-		Status:  0x34, // I flag set, unused bit set
-		Bus:     bus,
-		Variant: variant,
+		SP:       0xFD,
+		Status:   0x34, // I flag set, unused bit set
+		Bus:      bus,
+		Variant:  variant,
+		Unstable: DefaultUnstableConfig(),
 	}
 }
 
@@ -83,14 +106,14 @@ func (c *BaseCPU) SetFlag(flag byte, value bool) {
 // Push writes a byte to the stack and decrements the stack pointer.
 // The stack is located at 0x0100-0x01FF and grows downward.
 func (c *BaseCPU) Push(data byte) {
-	c.Bus.Write(0x0100+uint16(c.SP), data)
+	c.WriteAs(AccessStack, 0x0100+uint16(c.SP), data)
 	c.SP--
 }
 
 // Pull increments the stack pointer and reads a byte from the stack.
 func (c *BaseCPU) Pull() byte {
 	c.SP++
-	return c.Bus.Read(0x0100 + uint16(c.SP))
+	return c.ReadAs(AccessStack, 0x0100+uint16(c.SP))
 }
 
 // SetZN sets the Zero and Negative flags based on the given value.
@@ -102,78 +125,108 @@ func (c *BaseCPU) SetZN(value byte) {
 	c.SetFlag(FlagNegative, value&0x80 != 0)
 }
 
-// GetCycles returns the number of cycles remaining for the current instruction.
+// GetCycles returns the number of cycles run so far in the instruction or
+// interrupt sequence in progress, or zero between instructions.
 // This is primarily used for testing and debugging.
 func (c *BaseCPU) GetCycles() byte {
 	return c.Cycles
 }
 
-// Reset initializes the CPU to its power-on state.
+// Idle reports whether the CPU is waiting in WAI with no interrupt or reset
+// pending. Every Step while idle is a wasted clock tick, so a host loop can
+// skip straight to its next device event instead of stepping through them.
+func (c *BaseCPU) Idle() bool {
+	return c.State == StateWaiting && !c.IRQPending && !c.NMIPending && !c.ResetPending
+}
+
+// SkipIdle fast-forwards up to n idle clock cycles, advancing TotalCycles as
+// if Step had been called for each, and returns how many were skipped. It
+// skips nothing unless Idle reports true.
+func (c *BaseCPU) SkipIdle(n uint64) uint64 {
+	if !c.Idle() {
+		return 0
+	}
+	c.TotalCycles += n
+	return n
+}
+
+// Reset initializes the CPU to its power-on state, abandoning any
+// instruction in progress, and runs the reset sequence at once.
 // Registers are cleared, status is set to 0x34, and the PC is loaded from
 // the reset vector at 0xFFFC-0xFFFD.
-// Cycle count is set based on variant (6 for NMOS, 7 for WDC65C02).
+// The sequence takes 6 cycles on NMOS and 7 on WDC65C02, each a bus access.
 func (c *BaseCPU) Reset() {
-	c.A = 0
-	c.X = 0
-	c.Y = 0
-	c.SP = 0xFD
-	c.Status = 0x34 | FlagUnused // Set I flag and unused bit
-
-	low := uint16(c.Bus.Read(0xFFFC))
-	high := uint16(c.Bus.Read(0xFFFD))
-	c.PC = (high << 8) | low
-
-	c.Cycles = c.Variant.ResetCycles()
-	c.Halted = false
+	c.runEvent(EventReset)
 }
 
-// HandleNMI processes a Non-Maskable Interrupt.
+// reset runs the next stage of the reset sequence. Like an interrupt, it
+// reads at PC and then spends three cycles on the stack, but reads it
+// rather than writing the return address, counting SP down from zero to
+// 0xFD.
+func (c *BaseCPU) reset() {
+	switch c.seq.stage {
+	case 0:
+		for i := c.Variant.ResetCycles() - 5; i > 0; i-- {
+			c.DummyRead(c.PC)
+		}
+		c.SP = 0x00
+		for i := 0; i < 3; i++ {
+			c.DummyStackRead()
+			c.SP--
+		}
+	case 1:
+		c.seq.addr = uint16(c.ReadAs(AccessVector, 0xFFFC))
+	default:
+		c.PC = uint16(c.ReadAs(AccessVector, 0xFFFD))<<8 | c.seq.addr
+		c.A = 0
+		c.X = 0
+		c.Y = 0
+		c.Status = 0x34 | FlagUnused // Set I flag and unused bit
+		c.Halted = false
+		c.State = StateRunning
+		c.seq.done = true
+	}
+	c.seq.stage++
+}
+
+// HandleNMI runs the Non-Maskable Interrupt sequence at once.
 // The NMI cannot be disabled and takes priority over IRQ.
 // Saves PC and Status to stack, sets Interrupt Disable flag,
 // and loads PC from the NMI vector at 0xFFFA-0xFFFB.
-// Takes 7 cycles.
+// Takes 7 cycles. BeginSequence clears NMIPending.
 func (c *BaseCPU) HandleNMI() {
-	c.Push(byte(c.PC >> 8))
-	c.Push(byte(c.PC))
-	c.Push(c.Status)
-	c.SetFlag(FlagInterruptDisable, true)
-
-	if c.Variant.ClearsDecimalOnInterrupt() {
-		c.SetFlag(FlagDecimal, false)
-	}
-
-	low := uint16(c.Bus.Read(0xFFFA))
-	high := uint16(c.Bus.Read(0xFFFB))
-	c.PC = (high << 8) | low
-	c.Cycles = 7
-	c.NMIPending = false
+	c.runEvent(EventNMI)
 }
 
-// HandleIRQ processes an Interrupt Request.
+// HandleIRQ runs the Interrupt Request sequence at once.
 // Only executed if the Interrupt Disable flag is clear.
 // Saves PC and Status to stack, sets Interrupt Disable flag,
 // and loads PC from the IRQ vector at 0xFFFE-0xFFFF.
-// Takes 7 cycles.
+// Takes 7 cycles. BeginSequence clears IRQPending.
 func (c *BaseCPU) HandleIRQ() {
-	c.Push(byte(c.PC >> 8))
-	c.Push(byte(c.PC))
-	c.Push(c.Status)
-	c.SetFlag(FlagInterruptDisable, true)
-
-	if c.Variant.ClearsDecimalOnInterrupt() {
-		c.SetFlag(FlagDecimal, false)
-	}
-
-	low := uint16(c.Bus.Read(0xFFFE))
-	high := uint16(c.Bus.Read(0xFFFF))
-	c.PC = (high << 8) | low
-	c.Cycles = 7
-	c.IRQPending = false
+	c.runEvent(EventIRQ)
 }
 
-// HandleReset processes a pending reset request.
-// Calls Reset() and clears the ResetPending flag.
-func (c *BaseCPU) HandleReset() {
-	c.Reset()
-	c.ResetPending = false
+// interrupt runs the next stage of an interrupt sequence, which pushes PC
+// and Status and jumps through vector.
+func (c *BaseCPU) interrupt(vector uint16) {
+	switch c.seq.stage {
+	case 0:
+		c.DummyRead(c.PC)
+		c.DummyRead(c.PC)
+		c.Push(byte(c.PC >> 8))
+		c.Push(byte(c.PC))
+		c.Push(c.Status)
+		c.SetFlag(FlagInterruptDisable, true)
+
+		if c.Variant.ClearsDecimalOnInterrupt() {
+			c.SetFlag(FlagDecimal, false)
+		}
+	case 1:
+		c.seq.addr = uint16(c.ReadAs(AccessVector, vector))
+	default:
+		c.PC = uint16(c.ReadAs(AccessVector, vector+1))<<8 | c.seq.addr
+		c.seq.done = true
+	}
+	c.seq.stage++
 }
@@ -0,0 +1,484 @@
+package core
+
+// Bus cycles
+//
+// Every clock cycle of an instruction is a bus access, including the
+// cycles in which the CPU is busy internally and discards what it reads.
+// Instructions make all their accesses through Read and Write, or the
+// helpers below, so that memory-mapped devices see the same sequence of
+// reads and writes as on real hardware:
+//
+//   - Implied instructions read the byte after the opcode.
+//   - Indexed addressing spends a cycle adding the index. The NMOS 6502
+//     reads from the address before it is fixed up, which may be on the
+//     wrong page; the 65C02 reads the last byte of the instruction again.
+//   - Read-modify-write instructions write the unmodified value back
+//     before the result on the NMOS 6502; the 65C02 reads the address
+//     again instead.
+//   - Pulls, JSR and RTS spend a cycle reading the stack at SP.
+//
+// Step makes one of these accesses per call. A sequence is run as a series
+// of steps that the CPU keeps its place in: the opcode fetch, one step for
+// each operand byte, pointer byte or index cycle, then the handler. Each
+// step makes at most one access whose data it uses, as its first access;
+// the rest are writes and reads whose data is discarded, which Step queues
+// and makes one per call before going on to the next step. A handler that
+// needs the data of more than one read is called in stages, asking for
+// each next one with Continue. StepInstruction and RunCycles run the same
+// steps, making every access at once.
+//
+// Registers change in the cycle that changes them, so partway through an
+// instruction PC may already be past the operand bytes read so far.
+
+// MaxSequenceCycles is the length in clock cycles of the longest
+// instruction or interrupt sequence.
+const MaxSequenceCycles = 8
+
+// Sequence is an instruction or interrupt sequence that Step has left
+// partway through. It is opaque, carried by Snapshot so that Restore can
+// finish it.
+type Sequence struct {
+	cursor
+
+	// Accesses queued for the cycles after the step that made them;
+	// entries outside head:tail are left over and ignored
+	queue      [MaxSequenceCycles]queuedAccess
+	head, tail byte
+}
+
+// cursor is where a sequence is, kept apart from its queue so that it can
+// be cleared cheaply.
+type cursor struct {
+	active bool
+	event  Event          // EventInstruction, EventReset, EventNMI or EventIRQ, or EventUnknownOpcode once fetched
+	step   byte           // Steps begun; zero before the opcode fetch
+	mode   AddressingMode // Selects the steps after the fetch
+	stage  byte           // Stages of the handler finished
+	done   bool           // Every step has run; the sequence ends once the queue is empty
+
+	addr    uint16 // Address being resolved, then the handler's addr
+	ptr     uint16 // Pointer being read through
+	crossed bool   // Indexing addr crossed a page
+}
+
+// queued returns s with the entries left over in its queue cleared.
+func (s Sequence) queued() Sequence {
+	for i := range s.queue {
+		if byte(i) < s.head || byte(i) >= s.tail {
+			s.queue[i] = queuedAccess{}
+		}
+	}
+	return s
+}
+
+// queuedAccess is an access left by a step for a later cycle.
+type queuedAccess struct {
+	addr  uint16
+	data  byte
+	write bool
+	kind  AccessKind
+}
+
+// BeginSequence starts an instruction or interrupt sequence for event at an
+// instruction boundary, clearing the reset or interrupt request it answers,
+// and returns event. The variants call it from their dispatch, then run the
+// sequence with StepSequence or RunSequence.
+func (c *BaseCPU) BeginSequence(event Event) Event {
+	switch event {
+	case EventReset:
+		c.ResetPending = false
+	case EventNMI:
+		c.NMIPending = false
+	case EventIRQ:
+		c.IRQPending = false
+	}
+	c.seq.cursor = cursor{active: true, event: event}
+	c.Cycles = 0
+	return event
+}
+
+// InSequence reports whether an instruction or interrupt sequence is in
+// progress, begun but not yet run to its end.
+func (c *BaseCPU) InSequence() bool {
+	return c.seq.active
+}
+
+// SequencePC returns the address of the instruction in progress, or PC
+// between instructions and during a reset or interrupt sequence, which
+// changes PC only on its last cycle.
+func (c *BaseCPU) SequencePC() uint16 {
+	if c.seq.active && c.seq.step > 0 && (c.seq.event == EventInstruction || c.seq.event == EventUnknownOpcode) {
+		return c.InstructionPC
+	}
+	return c.PC
+}
+
+// StepSequence runs the next clock cycle of the sequence in progress,
+// making exactly one bus access, and decodes instructions through opcodes.
+// Once the sequence has ended it returns the event it ended with and true.
+func (c *BaseCPU) StepSequence(opcodes *[256]Opcode) (Event, bool) {
+	c.Cycles++
+	if c.seq.head != c.seq.tail {
+		c.dequeue()
+	} else {
+		// Steps with nothing to access, like the page fix-up of a read
+		// that stays on its page, run with the next one
+		c.stepping, c.stepCycle = true, c.TotalCycles
+		for c.TotalCycles == c.stepCycle && !c.seq.done {
+			c.runStep(opcodes)
+		}
+		c.stepping = false
+	}
+	if !c.seq.done || c.seq.head != c.seq.tail {
+		return c.seq.event, false
+	}
+	event := c.seq.event
+	c.seq.cursor = cursor{}
+	c.Cycles = 0
+	return event, true
+}
+
+// RunSequence runs the sequence in progress to its end, decoding
+// instructions through opcodes, and returns the event it ended with.
+func (c *BaseCPU) RunSequence(opcodes *[256]Opcode) Event {
+	if c.seq.step == 0 && c.seq.event == EventInstruction {
+		c.runInstruction(opcodes)
+	}
+	for c.seq.head != c.seq.tail {
+		c.dequeue()
+	}
+	for !c.seq.done {
+		c.runStep(opcodes)
+	}
+	event := c.seq.event
+	c.seq.cursor = cursor{}
+	c.Cycles = 0
+	return event
+}
+
+// runEvent runs a reset or interrupt sequence at once, abandoning any
+// sequence in progress.
+func (c *BaseCPU) runEvent(event Event) {
+	c.seq = Sequence{cursor: cursor{active: true, event: event}}
+	c.Cycles = 0
+	c.RunSequence(nil)
+}
+
+// runInstruction runs an instruction from its opcode fetch to its end. It
+// calls the steps listed in modeSteps directly, which is faster than going
+// through the table one step at a time.
+func (c *BaseCPU) runInstruction(opcodes *[256]Opcode) {
+	c.seq.step = 1
+	c.fetch(opcodes)
+	if !c.seq.done {
+		c.finish(&opcodes[c.Opcode])
+	}
+}
+
+// finish runs an instruction from the step after its opcode fetch to its
+// end.
+func (c *BaseCPU) finish(op *Opcode) {
+	if !op.FetchesOperand {
+		switch op.Mode {
+		case ZeroPage, ZeroPageRelative:
+			fetchOperand(c, op)
+		case ZeroPageX:
+			fetchOperand(c, op)
+			indexZeroPageX(c, op)
+		case ZeroPageY:
+			fetchOperand(c, op)
+			indexZeroPageY(c, op)
+		case Absolute:
+			fetchOperand(c, op)
+			fetchHigh(c, op)
+		case AbsoluteX:
+			fetchOperand(c, op)
+			fetchHighX(c, op)
+			fixPage(c, op)
+		case AbsoluteY:
+			fetchOperand(c, op)
+			fetchHighY(c, op)
+			fixPage(c, op)
+		case Indirect:
+			fetchOperand(c, op)
+			fetchHigh(c, op)
+			fixIndirect(c, op)
+			readPointer(c, op)
+			readPointerHigh(c, op)
+		case IndirectX:
+			fetchOperand(c, op)
+			indexZeroPageX(c, op)
+			readPointer(c, op)
+			readZeroPagePointerHigh(c, op)
+		case IndirectY:
+			fetchOperand(c, op)
+			readPointer(c, op)
+			readZeroPagePointerHighY(c, op)
+			fixPage(c, op)
+		case Relative:
+			fetchOffset(c, op)
+		case ZeroPageIndirect:
+			fetchOperand(c, op)
+			readPointer(c, op)
+			readZeroPagePointerHigh(c, op)
+		case AbsoluteXIndirect:
+			fetchOperand(c, op)
+			fetchHighX(c, op)
+			fixIndirect(c, op)
+			readPointer(c, op)
+			readPointerHigh(c, op)
+		}
+	}
+	for !c.seq.done {
+		execute(c, op)
+	}
+}
+
+// runStep runs the next step of the sequence in progress.
+func (c *BaseCPU) runStep(opcodes *[256]Opcode) {
+	step := c.seq.step
+	c.seq.step++
+	switch c.seq.event {
+	case EventInstruction:
+		if step == 0 {
+			c.fetch(opcodes)
+			return
+		}
+		op := &opcodes[c.Opcode]
+		modeSteps[c.seq.mode][step-1](c, op)
+	case EventReset:
+		c.reset()
+	case EventNMI:
+		c.interrupt(0xFFFA)
+	case EventIRQ:
+		c.interrupt(0xFFFE)
+	}
+}
+
+// decode picks the steps that follow the fetch of op.
+func (c *BaseCPU) decode(op *Opcode) {
+	c.seq.mode = op.Mode
+	if op.FetchesOperand {
+		c.seq.mode = Implied // The handler fetches its operand
+	}
+}
+
+// fetch reads and decodes the opcode.
+func (c *BaseCPU) fetch(opcodes *[256]Opcode) {
+	opcode := c.ReadAs(AccessOpcode, c.PC)
+	c.InstructionPC = c.PC
+	c.Opcode = opcode
+	c.PC++
+
+	op := &opcodes[opcode]
+	if op.Handler == nil {
+		c.PC-- // Leave PC on the opcode
+		c.seq.event = EventUnknownOpcode
+		c.seq.done = true
+		return
+	}
+	c.decode(op)
+	c.resolved(op)
+}
+
+// resolved ends the step that completes the operand address. If that has
+// spent all of the opcode's base cycles, the handler makes no access of its
+// own, as in JMP or a one-cycle NOP, and under Step it runs in the same
+// cycle.
+func (c *BaseCPU) resolved(op *Opcode) {
+	if c.stepping && c.Cycles == op.Cycles {
+		c.seq.step++
+		execute(c, op)
+	}
+}
+
+// execute runs the handler, or its next stage, as the last step of an
+// instruction.
+func execute(c *BaseCPU, op *Opcode) {
+	if c.seq.stage == 0 {
+		switch op.Mode {
+		case Implied, Accumulator:
+			if op.Cycles > 1 {
+				c.DummyRead(c.PC) // The byte after the opcode is read and ignored
+			}
+		case Immediate:
+			c.seq.addr = c.PC
+			c.PC++
+			c.access = AccessOperand // The handler reads the operand itself
+		}
+	}
+	op.Handler(c, c.seq.addr, c.seq.crossed)
+	c.access = AccessData
+
+	if c.more {
+		c.more = false
+		c.seq.stage++
+		c.seq.step-- // Run this step again for the next stage
+	} else {
+		c.seq.done = true
+	}
+}
+
+// Stage returns which call this is to a handler that calls Continue,
+// counting from zero.
+func (c *BaseCPU) Stage() int {
+	return int(c.seq.stage)
+}
+
+// Continue is called by a handler that needs the data of a read after its
+// first access, such as a pull after the stack cycle or the second byte of
+// a vector. The handler returns, and is called again on the cycle after the
+// accesses it has made, with Stage one higher and next as addr.
+func (c *BaseCPU) Continue(next uint16) {
+	c.more = true
+	c.seq.addr = next
+}
+
+// CurrentCycle returns the clock cycle of the bus access in progress, on
+// the same count as TotalCycles. Devices can call it from their Read and
+// Write methods; since every access is made in its own cycle, it is the
+// value TotalCycles has during the access.
+func (c *BaseCPU) CurrentCycle() uint64 {
+	return c.TotalCycles
+}
+
+// Read performs a read cycle.
+func (c *BaseCPU) Read(addr uint16) byte {
+	if c.deferring() {
+		readDeferred()
+	}
+	data := c.Bus.Read(addr)
+	c.endCycle(addr, data, false)
+	return data
+}
+
+// Write performs a write cycle.
+func (c *BaseCPU) Write(addr uint16, data byte) {
+	if c.deferring() {
+		c.enqueue(addr, data, true, c.access)
+		return
+	}
+	c.Bus.Write(addr, data)
+	c.endCycle(addr, data, true)
+}
+
+// readDeferred reports a handler that reads data after its first access
+// without calling Continue, which Step cannot run a cycle at a time.
+func readDeferred() {
+	panic("core: read of data after the first access of a step; the handler must Continue")
+}
+
+// enqueue leaves an access for a later cycle.
+func (c *BaseCPU) enqueue(addr uint16, data byte, write bool, kind AccessKind) {
+	c.seq.queue[c.seq.tail] = queuedAccess{addr: addr, data: data, write: write, kind: kind}
+	c.seq.tail++
+}
+
+// dequeue makes the oldest queued access.
+func (c *BaseCPU) dequeue() {
+	a := c.seq.queue[c.seq.head]
+	if c.seq.head++; c.seq.head == c.seq.tail {
+		c.seq.head, c.seq.tail = 0, 0
+	}
+	c.access = a.kind
+	if a.write {
+		c.Bus.Write(a.addr, a.data)
+	} else {
+		a.data = c.Bus.Read(a.addr)
+	}
+	c.endCycle(a.addr, a.data, a.write)
+	c.access = AccessData
+}
+
+// deferring reports whether Step has made the access of the step it is
+// running, so that the rest must be queued.
+func (c *BaseCPU) deferring() bool {
+	return c.stepping && c.TotalCycles != c.stepCycle
+}
+
+// endCycle reports an access to the bus trace and counts its cycle.
+func (c *BaseCPU) endCycle(addr uint16, data byte, write bool) {
+	if c.busTrace != nil {
+		c.trace(addr, data, write)
+	}
+	c.TotalCycles++
+}
+
+// trace reports an access to the bus trace. It is kept out of line so
+// that endCycle stays cheap enough to inline.
+//
+//go:noinline
+func (c *BaseCPU) trace(addr uint16, data byte, write bool) {
+	c.busTrace(BusCycle{Cycle: c.TotalCycles, Addr: addr, Data: data, Write: write, Kind: c.access})
+}
+
+// ReadAs performs a read cycle, reporting it to a bus trace as kind.
+func (c *BaseCPU) ReadAs(kind AccessKind, addr uint16) byte {
+	c.access = kind
+	data := c.Read(addr)
+	c.access = AccessData
+	return data
+}
+
+// WriteAs performs a write cycle, reporting it to a bus trace as kind.
+func (c *BaseCPU) WriteAs(kind AccessKind, addr uint16, data byte) {
+	c.access = kind
+	c.Write(addr, data)
+	c.access = AccessData
+}
+
+// DummyRead performs a read cycle whose data is discarded.
+func (c *BaseCPU) DummyRead(addr uint16) {
+	if c.deferring() {
+		c.enqueue(addr, 0, false, AccessDummy)
+		return
+	}
+	c.ReadAs(AccessDummy, addr)
+}
+
+// DummyStackRead performs the internal cycle before a pull, and before
+// JSR pushes its return address: a read of the stack at SP.
+func (c *BaseCPU) DummyStackRead() {
+	c.DummyRead(0x0100 + uint16(c.SP))
+}
+
+// IndexCycle performs the cycle spent adding an index register. unfixed is
+// the address the NMOS 6502 reads: the base address for zero page
+// indexing, or the indexed address on the base page for absolute and
+// indirect indexing. The 65C02 reads the last byte of the instruction
+// again, so PC must already be past the operand.
+func (c *BaseCPU) IndexCycle(unfixed uint16) {
+	if c.Variant.HasFalseReads() {
+		c.DummyRead(unfixed)
+	} else {
+		c.DummyRead(c.PC - 1)
+	}
+}
+
+// WriteModified writes the result of a read-modify-write instruction that
+// read old from addr. On the NMOS 6502 the unmodified value is written
+// back first; the 65C02 reads the address again instead.
+func (c *BaseCPU) WriteModified(addr uint16, old, data byte) {
+	if c.Variant.HasRMWDoubleWrite() {
+		c.WriteAs(AccessDummy, addr, old)
+	} else {
+		c.DummyRead(addr)
+	}
+	c.Write(addr, data)
+}
+
+// Branch takes a branch to target, spending a cycle for a taken branch
+// and another if target is on a different page. The NMOS 6502 reads the
+// next opcode and then the target on the old page; the 65C02 reads the
+// next opcode twice.
+func (c *BaseCPU) Branch(target uint16) {
+	c.DummyRead(c.PC)
+	if target&0xFF00 != c.PC&0xFF00 {
+		if c.Variant.HasFalseReads() {
+			c.DummyRead(c.PC&0xFF00 | target&0x00FF)
+		} else {
+			c.DummyRead(c.PC)
+		}
+	}
+	c.PC = target
+}
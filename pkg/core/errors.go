@@ -0,0 +1,96 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Reasons a CPU stops executing. Step, StepInstruction, RunCycles and Run
+// return them wrapped in a *StepError; test for them with errors.Is.
+var (
+	// ErrUnknownOpcode means the fetched opcode has no handler. PC is left
+	// on the opcode.
+	ErrUnknownOpcode = errors.New("unknown opcode")
+
+	// ErrJammed means an NMOS JAM opcode has locked up the CPU. Only a
+	// reset recovers it.
+	ErrJammed = errors.New("CPU jammed")
+
+	// ErrStopped means the 65C02 STP instruction has stopped the clock.
+	// Only a reset recovers it.
+	ErrStopped = errors.New("CPU stopped")
+
+	// ErrBreakpoint means BaseCPU.BreakFunc asked to stop before the
+	// instruction at PC. Stepping again executes that instruction.
+	ErrBreakpoint = errors.New("breakpoint hit")
+)
+
+// StepError describes why a CPU could not continue.
+type StepError struct {
+	Err    error  // ErrUnknownOpcode, ErrJammed, ErrStopped or ErrBreakpoint
+	PC     uint16 // Address of the instruction involved
+	Opcode byte   // Opcode at PC; zero for breakpoints, which stop before the fetch
+	Cycles uint64 // Value of TotalCycles when execution stopped
+}
+
+func (e *StepError) Error() string {
+	if e.Err == ErrBreakpoint {
+		return fmt.Sprintf("%v at $%04X (cycle %d)", e.Err, e.PC, e.Cycles)
+	}
+	return fmt.Sprintf("%v at $%04X (opcode $%02X, cycle %d)", e.Err, e.PC, e.Opcode, e.Cycles)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// StepError returns the error for an event that stops execution, or nil for
+// events that do not.
+func (c *BaseCPU) StepError(event Event) error {
+	var err error
+	pc := c.InstructionPC
+
+	switch event {
+	case EventUnknownOpcode:
+		err = ErrUnknownOpcode
+	case EventJammed:
+		err = ErrJammed
+	case EventStopped:
+		err = ErrStopped
+	case EventBreakpoint:
+		err = ErrBreakpoint
+		pc = c.PC
+	default:
+		return nil
+	}
+
+	opcode := c.Opcode
+	if event == EventBreakpoint {
+		opcode = 0
+	}
+	return &StepError{Err: err, PC: pc, Opcode: opcode, Cycles: c.TotalCycles}
+}
+
+// SetBreakFunc installs f as BreakFunc, or removes it if f is nil.
+func (c *BaseCPU) SetBreakFunc(f func(pc uint16) bool) {
+	c.BreakFunc = f
+	c.breakResume = false
+}
+
+// CheckBreakpoint reports whether BreakFunc asks to stop before the
+// instruction at PC. The check is skipped once after a hit, so that stepping
+// again executes the instruction instead of stopping on it forever.
+func (c *BaseCPU) CheckBreakpoint() bool {
+	if c.BreakFunc == nil {
+		return false
+	}
+	if c.breakResume {
+		c.breakResume = false
+		return false
+	}
+	if c.BreakFunc(c.PC) {
+		c.breakResume = true
+		return true
+	}
+	return false
+}
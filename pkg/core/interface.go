@@ -0,0 +1,165 @@
+package core
+
+import "fmt"
+
+// CPU is the interface implemented by every 6502-family CPU variant, so that
+// tools such as debuggers and tracers can drive any of them. The stepping
+// methods report conditions that stop execution as a *StepError.
+//
+// Both mos6502.CPU and wdc65c02.CPU satisfy it. Use NewCPU to construct one
+// from a Variant.
+type CPU interface {
+	// Step executes a single clock cycle, making one bus access.
+	Step() error
+
+	// StepInstruction executes one instruction or interrupt sequence, or
+	// the rest of one that Step has started.
+	StepInstruction() (StepResult, error)
+
+	// RunCycles executes up to n clock cycles and returns how many ran.
+	RunCycles(n int) (int, error)
+
+	// Run executes instructions until an error stops the CPU.
+	Run() error
+
+	// Reset loads the reset vector immediately; TriggerReset requests a
+	// reset at the next instruction boundary instead.
+	Reset()
+	TriggerReset()
+	TriggerNMI()
+	TriggerIRQ()
+
+	Registers() Registers
+	SetRegisters(r Registers)
+
+	Snapshot() Snapshot
+	Restore(s Snapshot) error
+
+	GetVariant() Variant
+	GetFlag(flag byte) bool
+	SetFlag(flag byte, value bool)
+
+	// SetBreakFunc installs a function called before each instruction
+	// fetch; returning true stops execution with ErrBreakpoint.
+	SetBreakFunc(f func(pc uint16) bool)
+
+	// SetBusTrace installs a function called after every bus access, or
+	// removes it if f is nil.
+	SetBusTrace(f func(BusCycle))
+
+	// CurrentCycle returns the clock cycle of the bus access in progress.
+	CurrentCycle() uint64
+
+	// LookupOpcode describes an opcode in this variant's instruction set.
+	LookupOpcode(opcode byte) (Opcode, bool)
+}
+
+// Registers holds the programmer-visible registers.
+type Registers struct {
+	PC     uint16
+	SP     byte
+	A      byte
+	X      byte
+	Y      byte
+	Status byte
+}
+
+// Snapshot holds the complete execution state of a CPU, apart from its bus.
+type Snapshot struct {
+	Registers
+
+	Variant     Variant
+	Cycles      byte
+	TotalCycles uint64
+	Halted      bool
+	State       State
+	Unstable    UnstableConfig
+
+	InstructionPC uint16
+	Opcode        byte
+
+	NMIPending   bool
+	IRQPending   bool
+	ResetPending bool
+
+	// BreakResume is set after BreakFunc has stopped at PC, so that the
+	// next step runs the instruction instead of stopping again
+	BreakResume bool
+
+	// An instruction or interrupt sequence that Step has left partway
+	// through, after Cycles bus accesses
+	Sequence Sequence
+}
+
+// TriggerReset requests a reset, taken before the next instruction.
+func (c *BaseCPU) TriggerReset() {
+	c.ResetPending = true
+}
+
+// TriggerNMI requests a non-maskable interrupt.
+func (c *BaseCPU) TriggerNMI() {
+	c.NMIPending = true
+}
+
+// TriggerIRQ requests an interrupt, taken once the I flag is clear.
+func (c *BaseCPU) TriggerIRQ() {
+	c.IRQPending = true
+}
+
+// Registers returns a copy of the programmer-visible registers.
+func (c *BaseCPU) Registers() Registers {
+	return Registers{PC: c.PC, SP: c.SP, A: c.A, X: c.X, Y: c.Y, Status: c.Status}
+}
+
+// SetRegisters loads the programmer-visible registers.
+func (c *BaseCPU) SetRegisters(r Registers) {
+	c.PC, c.SP, c.A, c.X, c.Y, c.Status = r.PC, r.SP, r.A, r.X, r.Y, r.Status
+}
+
+// GetVariant returns the CPU variant.
+func (c *BaseCPU) GetVariant() Variant {
+	return c.Variant
+}
+
+// Snapshot captures the CPU state. The bus is not included.
+func (c *BaseCPU) Snapshot() Snapshot {
+	return Snapshot{
+		Registers:     c.Registers(),
+		Variant:       c.Variant,
+		Cycles:        c.Cycles,
+		TotalCycles:   c.TotalCycles,
+		Halted:        c.Halted,
+		State:         c.State,
+		Unstable:      c.Unstable,
+		InstructionPC: c.InstructionPC,
+		Opcode:        c.Opcode,
+		NMIPending:    c.NMIPending,
+		IRQPending:    c.IRQPending,
+		ResetPending:  c.ResetPending,
+		BreakResume:   c.breakResume,
+		Sequence:      c.seq.queued(),
+	}
+}
+
+// Restore loads a state captured by Snapshot. It fails if the snapshot was
+// taken from a different variant.
+func (c *BaseCPU) Restore(s Snapshot) error {
+	if s.Variant != c.Variant {
+		return fmt.Errorf("core: cannot restore %v snapshot into %v CPU", s.Variant, c.Variant)
+	}
+
+	c.SetRegisters(s.Registers)
+	c.Cycles = s.Cycles
+	c.TotalCycles = s.TotalCycles
+	c.Halted = s.Halted
+	c.State = s.State
+	c.Unstable = s.Unstable
+	c.InstructionPC = s.InstructionPC
+	c.Opcode = s.Opcode
+	c.NMIPending = s.NMIPending
+	c.IRQPending = s.IRQPending
+	c.ResetPending = s.ResetPending
+	c.breakResume = s.BreakResume
+	c.seq = s.Sequence
+	return nil
+}
@@ -1,8 +1,17 @@
 package core
 
 type (
-	AddressingMode     uint8
-	InstructionHandler func(cpu interface{}, opcode *Opcode) uint8
+	AddressingMode uint8
+
+	// InstructionHandler implements an instruction. It receives the
+	// effective address resolved for the opcode's addressing mode and
+	// whether resolving it crossed a page boundary.
+	//
+	// Its first bus access is a cycle of its own; any later ones must be
+	// writes or dummy reads, which Step makes on the cycles after. To use
+	// the data of a later read, the handler calls BaseCPU.Continue and is
+	// called again for that read.
+	InstructionHandler func(c *BaseCPU, addr uint16, pageCrossed bool)
 )
 
 const (
@@ -23,8 +32,31 @@ const (
 	// 65C02 SPECIFIC MODES
 	ZeroPageIndirect
 	AbsoluteXIndirect
+	ZeroPageRelative // BBRn/BBSn: zero page operand followed by a branch offset
 )
 
+// Length returns the instruction length in bytes, including the opcode,
+// for an instruction using this addressing mode.
+func (m AddressingMode) Length() uint8 {
+	switch m {
+	case Implied, Accumulator:
+		return 1
+	case Absolute, AbsoluteX, AbsoluteY, Indirect, AbsoluteXIndirect, ZeroPageRelative:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// Opcode describes one entry of a variant's 256-entry opcode table.
+//
+// Cycles is the base cycle count. PageCrossCycle adds one cycle when the
+// indexed address crosses a page; BranchCycle marks branches, which add one
+// cycle when taken and another when the target is on a different page.
+// Other penalties (such as 65C02 decimal mode) are added by the handler.
+// FetchesOperand leaves fetching the operand to the handler, which
+// receives zero for the address; JSR uses it to fetch its high byte after
+// pushing the return address, as the hardware does.
 type Opcode struct {
 	Mnemonic       string
 	Code           uint8
@@ -35,4 +67,19 @@ type Opcode struct {
 	Length         uint8
 	Handler        InstructionHandler
 	Illegal        bool
+	FetchesOperand bool
+}
+
+// Execute runs a decoded instruction whose opcode byte has already been
+// fetched, at an instruction boundary. It resolves the operand address for
+// op.Mode, makes the accesses of the mode and calls the handler. The CPUs
+// decode through their opcode tables instead; Execute is for callers that
+// decode opcodes themselves.
+func (c *BaseCPU) Execute(op *Opcode) {
+	c.seq.cursor = cursor{active: true, event: EventInstruction, step: 1}
+	c.InstructionPC = c.PC - 1
+	c.Opcode = op.Code
+	c.decode(op)
+	c.finish(op)
+	c.seq.cursor = cursor{}
 }
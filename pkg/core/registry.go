@@ -0,0 +1,57 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory constructs a CPU of one variant attached to bus.
+type Factory func(bus Bus) CPU
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[Variant]Factory)
+)
+
+// Register makes a variant available to NewCPU. Variant packages call it
+// from init, so importing mos6502 or wdc65c02 is enough to register them.
+// Registering the same variant twice panics.
+func Register(variant Variant, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("core: Register factory is nil")
+	}
+	if _, dup := factories[variant]; dup {
+		panic(fmt.Sprintf("core: Register called twice for %v", variant))
+	}
+	factories[variant] = factory
+}
+
+// NewCPU constructs a CPU for variant, attached to bus. The variant's
+// package must have been imported so that it is registered.
+func NewCPU(variant Variant, bus Bus) (CPU, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[variant]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("core: no CPU registered for variant %v", variant)
+	}
+	return factory(bus), nil
+}
+
+// Variants returns the registered variants in ascending order.
+func Variants() []Variant {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	variants := make([]Variant, 0, len(factories))
+	for v := range factories {
+		variants = append(variants, v)
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i] < variants[j] })
+	return variants
+}
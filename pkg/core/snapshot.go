@@ -0,0 +1,150 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// snapshotVersion is the version of the binary snapshot encoding. Increment
+// it when the layout of snapshotV1 changes, and keep decoding old versions.
+const snapshotVersion = 1
+
+// snapshotV1 is the binary layout of a Snapshot, little-endian.
+type snapshotV1 struct {
+	PC                  uint16
+	SP, A, X, Y, Status byte
+	Variant             byte
+	Cycles              byte
+	TotalCycles         uint64
+	Halted              bool
+	State               byte
+	UnstableMagic       byte
+	SHxDropAND          bool
+	InstructionPC       uint16
+	Opcode              byte
+	NMIPending          bool
+	IRQPending          bool
+	ResetPending        bool
+	BreakResume         bool
+	Sequence            sequenceV1
+}
+
+// sequenceV1 is the binary layout of a Sequence.
+type sequenceV1 struct {
+	Active     bool
+	Event      byte
+	Step       byte
+	Mode       byte
+	Stage      byte
+	Done       bool
+	Addr       uint16
+	Ptr        uint16
+	Crossed    bool
+	Queue      [MaxSequenceCycles]queuedAccessV1
+	Head, Tail byte
+}
+
+// queuedAccessV1 is the binary layout of a queued bus access.
+type queuedAccessV1 struct {
+	Addr  uint16
+	Data  byte
+	Write bool
+	Kind  byte
+}
+
+func encodeSequence(s Sequence) sequenceV1 {
+	v := sequenceV1{
+		Active: s.active, Event: byte(s.event), Step: s.step, Mode: byte(s.mode), Stage: s.stage, Done: s.done,
+		Addr: s.addr, Ptr: s.ptr, Crossed: s.crossed, Head: s.head, Tail: s.tail,
+	}
+	for i, a := range s.queue {
+		v.Queue[i] = queuedAccessV1{Addr: a.addr, Data: a.data, Write: a.write, Kind: byte(a.kind)}
+	}
+	return v
+}
+
+func decodeSequence(v sequenceV1) (Sequence, error) {
+	if v.Head > v.Tail || v.Tail > MaxSequenceCycles || int(v.Mode) >= len(modeSteps) {
+		return Sequence{}, errors.New("core: corrupt access queue in CPU snapshot")
+	}
+	s := Sequence{
+		cursor: cursor{
+			active: v.Active, event: Event(v.Event), step: v.Step, mode: AddressingMode(v.Mode), stage: v.Stage, done: v.Done,
+			addr: v.Addr, ptr: v.Ptr, crossed: v.Crossed,
+		},
+		head: v.Head, tail: v.Tail,
+	}
+	for i, a := range v.Queue {
+		s.queue[i] = queuedAccess{addr: a.Addr, data: a.Data, write: a.Write, kind: AccessKind(a.Kind)}
+	}
+	return s, nil
+}
+
+// MarshalBinary encodes the snapshot in a versioned binary format.
+func (s Snapshot) MarshalBinary() ([]byte, error) {
+	v := snapshotV1{
+		PC: s.PC, SP: s.SP, A: s.A, X: s.X, Y: s.Y, Status: s.Status,
+		Variant:       byte(s.Variant),
+		Cycles:        s.Cycles,
+		TotalCycles:   s.TotalCycles,
+		Halted:        s.Halted,
+		State:         byte(s.State),
+		UnstableMagic: s.Unstable.Magic,
+		SHxDropAND:    s.Unstable.SHxDropAND,
+		InstructionPC: s.InstructionPC,
+		Opcode:        s.Opcode,
+		NMIPending:    s.NMIPending,
+		IRQPending:    s.IRQPending,
+		ResetPending:  s.ResetPending,
+		BreakResume:   s.BreakResume,
+		Sequence:      encodeSequence(s.Sequence),
+	}
+
+	var b bytes.Buffer
+	b.WriteByte(snapshotVersion)
+	if err := binary.Write(&b, binary.LittleEndian, &v); err != nil {
+		return nil, fmt.Errorf("core: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a snapshot encoded by MarshalBinary.
+func (s *Snapshot) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("core: empty CPU snapshot")
+	}
+	if data[0] != snapshotVersion {
+		return fmt.Errorf("core: unsupported CPU snapshot version %d", data[0])
+	}
+	var v snapshotV1
+	if len(data)-1 != binary.Size(&v) {
+		return fmt.Errorf("core: CPU snapshot is %d bytes, want %d", len(data)-1, binary.Size(&v))
+	}
+	if err := binary.Read(bytes.NewReader(data[1:]), binary.LittleEndian, &v); err != nil {
+		return fmt.Errorf("core: %w", err)
+	}
+	seq, err := decodeSequence(v.Sequence)
+	if err != nil {
+		return err
+	}
+
+	*s = Snapshot{
+		Registers:     Registers{PC: v.PC, SP: v.SP, A: v.A, X: v.X, Y: v.Y, Status: v.Status},
+		Variant:       Variant(v.Variant),
+		Cycles:        v.Cycles,
+		TotalCycles:   v.TotalCycles,
+		Halted:        v.Halted,
+		State:         State(v.State),
+		Unstable:      UnstableConfig{Magic: v.UnstableMagic, SHxDropAND: v.SHxDropAND},
+		InstructionPC: v.InstructionPC,
+		Opcode:        v.Opcode,
+		NMIPending:    v.NMIPending,
+		IRQPending:    v.IRQPending,
+		ResetPending:  v.ResetPending,
+		BreakResume:   v.BreakResume,
+		Sequence:      seq,
+	}
+	return nil
+}
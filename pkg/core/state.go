@@ -0,0 +1,36 @@
+package core
+
+// State describes whether the CPU is executing instructions.
+type State int
+
+const (
+	// StateRunning is normal instruction execution.
+	StateRunning State = iota
+
+	// StateJammed is entered by the NMOS JAM opcodes. The CPU stops
+	// fetching instructions and ignores IRQ and NMI until it is reset.
+	StateJammed
+
+	// StateWaiting is entered by the 65C02 WAI instruction. Instruction
+	// fetch is suspended until IRQ, NMI or RESET is asserted.
+	StateWaiting
+
+	// StateStopped is entered by the 65C02 STP instruction. The clock is
+	// stopped and only a reset restarts the CPU.
+	StateStopped
+)
+
+func (s State) String() string {
+	switch s {
+	case StateRunning:
+		return "Running"
+	case StateJammed:
+		return "Jammed"
+	case StateWaiting:
+		return "Waiting"
+	case StateStopped:
+		return "Stopped"
+	default:
+		return "Unknown"
+	}
+}
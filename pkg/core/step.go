@@ -0,0 +1,67 @@
+package core
+
+// Event identifies what a call to StepInstruction did.
+type Event int
+
+const (
+	// EventInstruction means one instruction was executed.
+	EventInstruction Event = iota
+
+	// EventReset means the reset sequence was run.
+	EventReset
+
+	// EventNMI means a non-maskable interrupt was serviced.
+	EventNMI
+
+	// EventIRQ means an interrupt request was serviced.
+	EventIRQ
+
+	// EventWaiting means the CPU sat idle in WAI for one cycle.
+	EventWaiting
+
+	// EventJammed means the CPU sat jammed for one cycle.
+	EventJammed
+
+	// EventStopped means nothing was executed because STP stopped the CPU.
+	EventStopped
+
+	// EventBreakpoint means BaseCPU.BreakFunc stopped execution before
+	// the instruction at PC.
+	EventBreakpoint
+
+	// EventUnknownOpcode means the fetched opcode could not be executed.
+	EventUnknownOpcode
+)
+
+func (e Event) String() string {
+	switch e {
+	case EventInstruction:
+		return "Instruction"
+	case EventReset:
+		return "Reset"
+	case EventNMI:
+		return "NMI"
+	case EventIRQ:
+		return "IRQ"
+	case EventWaiting:
+		return "Waiting"
+	case EventJammed:
+		return "Jammed"
+	case EventStopped:
+		return "Stopped"
+	case EventBreakpoint:
+		return "Breakpoint"
+	case EventUnknownOpcode:
+		return "UnknownOpcode"
+	default:
+		return "Unknown"
+	}
+}
+
+// StepResult describes one call to StepInstruction.
+type StepResult struct {
+	Event  Event
+	PC     uint16 // Address of the instruction, or the PC the interrupt interrupted
+	Opcode byte   // Opcode fetched, for EventInstruction and EventUnknownOpcode
+	Cycles int    // Clock cycles consumed
+}
@@ -0,0 +1,23 @@
+package core
+
+// UnstableConfig selects how the unstable NMOS undocumented opcodes behave.
+// Their results depend on analog effects that differ between chip revisions,
+// so the values that match a particular part have to be chosen by the host.
+//
+// The WDC65C02 has no unstable opcodes and ignores this configuration.
+type UnstableConfig struct {
+	// Magic is the constant ORed into the accumulator by ANE ($8B) and
+	// LXA ($AB). Common values are $EE, $EF, $FF and $00.
+	Magic byte
+
+	// SHxDropAND makes SHA, SHX, SHY and TAS store the register value
+	// without ANDing it with the high byte of the address plus one, as
+	// happens when RDY is pulled low during the instruction.
+	SHxDropAND bool
+}
+
+// DefaultUnstableConfig returns the behavior of a typical NMOS 6502/6510:
+// a magic constant of $EE with the AND applied by the SHx instructions.
+func DefaultUnstableConfig() UnstableConfig {
+	return UnstableConfig{Magic: 0xEE}
+}
@@ -20,7 +20,7 @@ const (
 	//   - JMP indirect page boundary bug
 	//   - 6 cycle reset
 	//   - Decimal mode does NOT clear on interrupts
-	//   - Undefined behavior for illegal opcodes
+	//   - Undocumented opcodes, including JAM opcodes that lock the CPU
 	VariantNMOS Variant = iota
 
 	// VariantWDC65C02 represents the Western Design Center 65C02 processor
@@ -29,7 +29,7 @@ const (
 	//   - JMP indirect bug is FIXED
 	//   - 7 cycle reset
 	//   - Decimal mode CLEARS on interrupts
-	//   - All illegal opcodes become NOPs
+	//   - Reserved opcodes are NOPs of 1-3 bytes and 1-8 cycles
 	//   - New addressing modes: Zero Page Indirect, Absolute Indexed Indirect
 	VariantWDC65C02
 )
@@ -64,3 +64,17 @@ func (v Variant) HasJMPIndirectBug() bool {
 func (v Variant) ClearsDecimalOnInterrupt() bool {
 	return v == VariantWDC65C02
 }
+
+// HasFalseReads reports whether internal cycles read from partly computed
+// addresses, such as an indexed address before its page is fixed up. The
+// 65C02 reads the last byte of the instruction again instead.
+func (v Variant) HasFalseReads() bool {
+	return v == VariantNMOS
+}
+
+// HasRMWDoubleWrite reports whether read-modify-write instructions write
+// the unmodified value back before the result. The 65C02 reads it again
+// instead.
+func (v Variant) HasRMWDoubleWrite() bool {
+	return v == VariantNMOS
+}
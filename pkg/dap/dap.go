@@ -0,0 +1,226 @@
+// Package dap serves a CPU over the Debug Adapter Protocol, so 6502 programs
+// can be debugged from VS Code and other DAP clients.
+//
+// A client either launches a program, which loads a binary into 64K of RAM
+// on a new CPU, or attaches to the Server's Debugger. The adapter supports:
+//   - Source breakpoints, mapped to addresses through a ca65/ld65 debug
+//     info file, with conditions and hit counts
+//   - Continue, pause, and stepping by instruction: stepIn executes one
+//     instruction, next steps over JSR and stepOut runs to the return
+//   - A call stack rebuilt from JSR, BRK and interrupts as they execute,
+//     and unwound when RTS or RTI raises the stack pointer past them
+//   - A Registers scope with the flags under P, editable with setVariable
+//   - readMemory and writeMemory for memory views
+//
+// There is a single thread, and the CPU stops as a whole. Messages are
+// framed with Content-Length headers, over stdio or a TCP connection.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/dbginfo"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/debugger"
+)
+
+// Server serves DAP clients, one connection at a time.
+type Server struct {
+	// Debugger is the machine attach requests debug, and DebugInfo its
+	// source mapping. Both may be nil; launch requests create their own.
+	Debugger  *debugger.Debugger
+	DebugInfo *dbginfo.Info
+
+	// Logf, if set, receives every message sent and received
+	Logf func(format string, args ...any)
+}
+
+// NewServer returns a server that attaches clients to d.
+func NewServer(d *debugger.Debugger) *Server {
+	return &Server{Debugger: d}
+}
+
+// ListenAndServe listens on the TCP address addr and serves clients until
+// the listener fails.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l and serves them one at a time.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		if err := s.ServeConn(conn); err != nil && s.Logf != nil {
+			s.Logf("dap: %v", err)
+		}
+		conn.Close()
+	}
+}
+
+// request is a message from the client.
+type request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type response struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+type event struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+// input is a request from the client, or the error that ended the stream.
+type input struct {
+	req *request
+	err error
+}
+
+// errDisconnect ends a session without an error.
+var errDisconnect = errors.New("disconnect")
+
+// ServeConn serves a single client over rw until it disconnects. For stdio,
+// pass a value that reads os.Stdin and writes os.Stdout.
+func (s *Server) ServeConn(rw io.ReadWriter) error {
+	ss := &session{
+		s:      s,
+		w:      rw,
+		in:     make(chan input, 16),
+		done:   make(chan struct{}),
+		breaks: make(map[string][]*debugger.Breakpoint),
+	}
+	defer close(ss.done)
+	defer ss.detach()
+
+	go ss.read(rw)
+	for {
+		select {
+		case in, ok := <-ss.in:
+			if !ok || in.err == io.EOF {
+				return nil
+			}
+			if in.err != nil {
+				return in.err
+			}
+			if err := ss.dispatch(in.req); err != nil {
+				if err == errDisconnect {
+					return nil
+				}
+				return err
+			}
+		case r := <-ss.running:
+			ss.running = nil
+			ss.stopped(r)
+		}
+	}
+}
+
+// deliver passes input to the session, unless it has ended.
+func (ss *session) deliver(in input) bool {
+	select {
+	case ss.in <- in:
+		return true
+	case <-ss.done:
+		return false
+	}
+}
+
+// read parses messages from r into ss.in.
+func (ss *session) read(r io.Reader) {
+	defer close(ss.in)
+	tr := textproto.NewReader(bufio.NewReader(r))
+	for {
+		header, err := tr.ReadMIMEHeader()
+		if err != nil {
+			if len(header) == 0 && err == io.EOF {
+				ss.deliver(input{err: io.EOF})
+			} else {
+				ss.deliver(input{err: fmt.Errorf("dap: reading header: %w", err)})
+			}
+			return
+		}
+		n, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil || n < 0 {
+			ss.deliver(input{err: fmt.Errorf("dap: bad Content-Length %q", header.Get("Content-Length"))})
+			return
+		}
+		body := make([]byte, n)
+		if _, err := io.ReadFull(tr.R, body); err != nil {
+			ss.deliver(input{err: err})
+			return
+		}
+		ss.logf("dap: <- %s", body)
+
+		req := &request{}
+		if err := json.Unmarshal(body, req); err != nil || req.Type != "request" {
+			ss.deliver(input{err: fmt.Errorf("dap: bad message %s", body)})
+			return
+		}
+		if !ss.deliver(input{req: req}) {
+			return
+		}
+	}
+}
+
+// send writes a message with the next sequence number. Only the session's
+// goroutine sends, so no locking is needed.
+func (ss *session) send(msg any) error {
+	ss.seq++
+	switch m := msg.(type) {
+	case *response:
+		m.Seq, m.Type = ss.seq, "response"
+	case *event:
+		m.Seq, m.Type = ss.seq, "event"
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	ss.logf("dap: -> %s", body)
+	_, err = fmt.Fprintf(ss.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (ss *session) respond(req *request, body any) error {
+	return ss.send(&response{RequestSeq: req.Seq, Success: true, Command: req.Command, Body: body})
+}
+
+func (ss *session) fail(req *request, err error) error {
+	return ss.send(&response{RequestSeq: req.Seq, Command: req.Command, Message: err.Error()})
+}
+
+func (ss *session) event(name string, body any) error {
+	return ss.send(&event{Event: name, Body: body})
+}
+
+func (ss *session) logf(format string, args ...any) {
+	if ss.s.Logf != nil {
+		ss.s.Logf(format, args...)
+	}
+}
@@ -0,0 +1,544 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/asm"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/debugger"
+)
+
+// SimpleRAM is a simple RAM implementation for testing
+type SimpleRAM struct {
+	memory [0x10000]byte
+}
+
+func (r *SimpleRAM) Read(addr uint16) byte {
+	return r.memory[addr]
+}
+
+func (r *SimpleRAM) Write(addr uint16, data byte) {
+	r.memory[addr] = data
+}
+
+// message is any message from the adapter.
+type message struct {
+	Type       string          `json:"type"`
+	Command    string          `json:"command"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client is a minimal DAP client.
+type client struct {
+	t      *testing.T
+	conn   net.Conn
+	r      *textproto.Reader
+	seq    int
+	events []message // Events read while waiting for a response
+}
+
+// startServer serves s on one end of a pipe and returns a client on the
+// other.
+func startServer(t *testing.T, s *Server) *client {
+	t.Helper()
+	server, conn := net.Pipe()
+	go func() {
+		s.ServeConn(server)
+		server.Close()
+	}()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	t.Cleanup(func() { conn.Close() })
+	return &client{t: t, conn: conn, r: textproto.NewReader(bufio.NewReader(conn))}
+}
+
+func (c *client) send(command string, args any) int {
+	c.t.Helper()
+	c.seq++
+	body, err := json.Marshal(map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": args})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := fmt.Fprintf(c.conn, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.seq
+}
+
+func (c *client) read() message {
+	c.t.Helper()
+	header, err := c.r.ReadMIMEHeader()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	n, _ := strconv.Atoi(header.Get("Content-Length"))
+	body := make([]byte, n)
+	if _, err := io.ReadFull(c.r.R, body); err != nil {
+		c.t.Fatal(err)
+	}
+	var m message
+	if err := json.Unmarshal(body, &m); err != nil {
+		c.t.Fatal(err)
+	}
+	return m
+}
+
+// call sends a request and returns its response, keeping events for later.
+func (c *client) call(command string, args any) message {
+	c.t.Helper()
+	seq := c.send(command, args)
+	for {
+		m := c.read()
+		if m.Type == "event" {
+			c.events = append(c.events, m)
+			continue
+		}
+		if m.RequestSeq != seq || m.Command != command {
+			c.t.Fatalf("%s: unexpected response %+v", command, m)
+		}
+		return m
+	}
+}
+
+// request makes a request that must succeed and decodes the body into body.
+func (c *client) request(command string, args, body any) {
+	c.t.Helper()
+	m := c.call(command, args)
+	if !m.Success {
+		c.t.Fatalf("%s failed: %s", command, m.Message)
+	}
+	if body != nil {
+		if err := json.Unmarshal(m.Body, body); err != nil {
+			c.t.Fatalf("%s: %v", command, err)
+		}
+	}
+}
+
+// event waits for the named event and decodes its body into body.
+func (c *client) event(name string, body any) {
+	c.t.Helper()
+	for {
+		var m message
+		if len(c.events) > 0 {
+			m, c.events = c.events[0], c.events[1:]
+		} else {
+			m = c.read()
+		}
+		if m.Type == "event" && m.Event == name {
+			if body != nil {
+				json.Unmarshal(m.Body, body)
+			}
+			return
+		}
+		if m.Type != "event" {
+			c.t.Fatalf("waiting for %s: unexpected %+v", name, m)
+		}
+	}
+}
+
+type stopped struct {
+	Reason           string `json:"reason"`
+	Text             string `json:"text"`
+	HitBreakpointIDs []int  `json:"hitBreakpointIds"`
+}
+
+// expectStop waits for a stopped event with the given reason.
+func (c *client) expectStop(reason string) stopped {
+	c.t.Helper()
+	var s stopped
+	c.event("stopped", &s)
+	if s.Reason != reason {
+		c.t.Fatalf("expected stop for %s, got %+v", reason, s)
+	}
+	return s
+}
+
+// stack returns "name:line" for each frame, or "name" without a line.
+func (c *client) stack() []string {
+	c.t.Helper()
+	var body struct {
+		StackFrames []stackFrame `json:"stackFrames"`
+	}
+	c.request("stackTrace", map[string]any{"threadId": 1}, &body)
+	var out []string
+	for _, f := range body.StackFrames {
+		if f.Line == 0 {
+			out = append(out, f.Name)
+		} else {
+			out = append(out, fmt.Sprintf("%s:%d", f.Name, f.Line))
+		}
+	}
+	return out
+}
+
+// registers returns the Registers scope by name.
+func (c *client) registers() map[string]string {
+	c.t.Helper()
+	var body struct {
+		Variables []variable `json:"variables"`
+	}
+	c.request("variables", map[string]any{"variablesReference": refRegisters}, &body)
+	regs := make(map[string]string)
+	for _, v := range body.Variables {
+		regs[v.Name] = v.Value
+	}
+	return regs
+}
+
+func (c *client) expectStack(want ...string) {
+	c.t.Helper()
+	if got := c.stack(); strings.Join(got, " ") != strings.Join(want, " ") {
+		c.t.Errorf("got stack %q, want %q", got, want)
+	}
+}
+
+// bump calls a subroutine until X is 3. The debug info below matches it
+// line for line.
+const bump = `        .org $0200
+start:  ldx #0
+loop:   jsr bump
+        cpx #3
+        bne loop
+        jam
+bump:   inx
+        rts
+`
+
+const bumpDbg = `version	major=2,minor=0
+file	id=0,name="main.s",size=120,mtime=0x6523A1F0,mod=0
+seg	id=0,name="CODE",start=0x000200,size=0x000C,addrsize=absolute,type=ro
+span	id=0,seg=0,start=0,size=2
+span	id=1,seg=0,start=2,size=3
+span	id=2,seg=0,start=5,size=2
+span	id=3,seg=0,start=7,size=2
+span	id=4,seg=0,start=9,size=1
+span	id=5,seg=0,start=10,size=1
+span	id=6,seg=0,start=11,size=1
+line	id=0,file=0,line=2,span=0
+line	id=1,file=0,line=3,span=1
+line	id=2,file=0,line=4,span=2
+line	id=3,file=0,line=5,span=3
+line	id=4,file=0,line=6,span=4
+line	id=5,file=0,line=7,span=5
+line	id=6,file=0,line=8,span=6
+sym	id=0,name="start",addrsize=absolute,scope=0,def=0,val=0x200,seg=0,type=lab
+sym	id=1,name="loop",addrsize=absolute,scope=0,def=1,val=0x202,seg=0,type=lab
+sym	id=2,name="bump",addrsize=absolute,scope=0,def=5,val=0x20A,seg=0,type=lab
+`
+
+// writeProgram assembles src into dir and returns the binary's path.
+func writeProgram(t *testing.T, dir string, variant core.Variant, src string) string {
+	t.Helper()
+	_, image := asm.MustAssemble(variant, src).Image()
+	path := filepath.Join(dir, "main.bin")
+	if err := os.WriteFile(path, image, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// launch starts bump stopped on entry with a breakpoint on each line given.
+func launch(t *testing.T, lines ...int) (*client, string) {
+	t.Helper()
+	dir := t.TempDir()
+	program := writeProgram(t, dir, core.VariantNMOS, bump)
+	if err := os.WriteFile(filepath.Join(dir, "main.dbg"), []byte(bumpDbg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := startServer(t, &Server{})
+	c.request("initialize", map[string]any{"adapterID": "6502"}, nil)
+	c.event("initialized", nil)
+	c.request("launch", map[string]any{
+		"program":     program,
+		"loadAddress": "$0200",
+		"start":       0x0200,
+		"debugInfo":   filepath.Join(dir, "main.dbg"),
+		"stopOnEntry": true,
+	}, nil)
+	src := filepath.Join(dir, "main.s")
+	if len(lines) > 0 {
+		c.setBreakpoints(src, lines...)
+	}
+	c.request("configurationDone", nil, nil)
+	c.expectStop("entry")
+	return c, src
+}
+
+func (c *client) setBreakpoints(path string, lines ...int) []breakpoint {
+	c.t.Helper()
+	bps := []map[string]any{}
+	for _, l := range lines {
+		bps = append(bps, map[string]any{"line": l})
+	}
+	var body struct {
+		Breakpoints []breakpoint `json:"breakpoints"`
+	}
+	c.request("setBreakpoints", map[string]any{"source": map[string]any{"path": path}, "breakpoints": bps}, &body)
+	return body.Breakpoints
+}
+
+func TestBreakpointsAndStack(t *testing.T) {
+	c, src := launch(t)
+	c.expectStack("start:2")
+
+	bps := c.setBreakpoints(src, 7, 9)
+	if len(bps) != 2 || !bps[0].Verified || bps[0].Line != 7 || bps[0].InstructionReference != "0x020A" {
+		t.Fatalf("unexpected breakpoint %+v", bps[0])
+	}
+	if bps[1].Verified || bps[1].Message == "" {
+		t.Errorf("expected line 9 to be unverified, got %+v", bps[1])
+	}
+
+	c.request("continue", map[string]any{"threadId": 1}, nil)
+	if s := c.expectStop("breakpoint"); len(s.HitBreakpointIDs) != 1 || s.HitBreakpointIDs[0] != bps[0].ID {
+		t.Errorf("unexpected hit breakpoints %v", s.HitBreakpointIDs)
+	}
+	c.expectStack("bump:7", "loop:3")
+	if x := c.registers()["X"]; x != "$00" {
+		t.Errorf("expected X=$00, got %s", x)
+	}
+
+	// Frame 2 is the call site
+	var frames struct {
+		StackFrames []stackFrame `json:"stackFrames"`
+		TotalFrames int          `json:"totalFrames"`
+	}
+	c.request("stackTrace", map[string]any{"threadId": 1, "startFrame": 1, "levels": 1}, &frames)
+	if len(frames.StackFrames) != 1 || frames.TotalFrames != 2 || frames.StackFrames[0].InstructionPointerReference != "0x0202" ||
+		frames.StackFrames[0].Source == nil || frames.StackFrames[0].Source.Path != src {
+		t.Errorf("unexpected frames %+v", frames)
+	}
+
+	c.setBreakpoints(src)
+	c.request("stepOut", map[string]any{"threadId": 1}, nil)
+	c.expectStop("step")
+	c.expectStack("loop:4")
+	if x := c.registers()["X"]; x != "$01" {
+		t.Errorf("expected X=$01 after returning, got %s", x)
+	}
+
+	// next steps over the JSR
+	for _, want := range []string{"loop:5", "loop:3", "loop:4"} {
+		c.request("next", map[string]any{"threadId": 1}, nil)
+		c.expectStop("step")
+		c.expectStack(want)
+	}
+	if x := c.registers()["X"]; x != "$02" {
+		t.Errorf("expected X=$02 after next, got %s", x)
+	}
+
+	c.request("continue", map[string]any{"threadId": 1}, nil)
+	if s := c.expectStop("exception"); !strings.Contains(s.Text, "jam") {
+		t.Errorf("expected a jam, got %q", s.Text)
+	}
+}
+
+func TestStepIn(t *testing.T) {
+	c, _ := launch(t)
+	for _, want := range [][]string{
+		{"loop:3"},
+		{"bump:7", "loop:3"},
+		{"bump:8", "loop:3"},
+		{"loop:4"},
+	} {
+		c.request("stepIn", map[string]any{"threadId": 1}, nil)
+		c.expectStop("step")
+		c.expectStack(want...)
+	}
+}
+
+func TestConditions(t *testing.T) {
+	c, src := launch(t)
+	var body struct {
+		Breakpoints []breakpoint `json:"breakpoints"`
+	}
+	c.request("setBreakpoints", map[string]any{
+		"source": map[string]any{"path": src},
+		"breakpoints": []map[string]any{
+			{"line": 7, "condition": "X == 1"},
+			{"line": 4, "hitCondition": "3"},
+			{"line": 8, "condition": "X =="},
+		},
+	}, &body)
+	if !body.Breakpoints[0].Verified || !body.Breakpoints[1].Verified || body.Breakpoints[2].Verified {
+		t.Fatalf("unexpected breakpoints %+v", body.Breakpoints)
+	}
+
+	c.request("continue", nil, nil)
+	c.expectStop("breakpoint")
+	if got := c.registers()["X"]; got != "$01" {
+		t.Errorf("expected the conditional stop with X=$01, got %s", got)
+	}
+	c.request("continue", nil, nil)
+	c.expectStop("breakpoint")
+	if got := c.registers()["X"]; got != "$03" {
+		t.Errorf("expected the third hit of line 4 with X=$03, got %s", got)
+	}
+}
+
+func TestRegistersAndMemory(t *testing.T) {
+	c, _ := launch(t)
+
+	regs := c.registers()
+	if regs["PC"] != "$0200" || regs["SP"] != "$FD" || regs["P"] != "$34 nv-BdIzc" {
+		t.Errorf("unexpected registers %v", regs)
+	}
+
+	var value struct {
+		Value string `json:"value"`
+	}
+	c.request("setVariable", map[string]any{"variablesReference": refRegisters, "name": "A", "value": "$42"}, &value)
+	if value.Value != "$42" {
+		t.Errorf("expected $42, got %q", value.Value)
+	}
+	c.request("setVariable", map[string]any{"variablesReference": refFlags, "name": "C", "value": "1"}, nil)
+	c.request("setVariable", map[string]any{"variablesReference": refRegisters, "name": "PC", "value": "0x0205"}, nil)
+	if regs := c.registers(); regs["A"] != "$42" || regs["P"] != "$35 nv-BdIzC" || regs["PC"] != "$0205" {
+		t.Errorf("unexpected registers after setVariable %v", regs)
+	}
+	if m := c.call("setVariable", map[string]any{"variablesReference": refRegisters, "name": "A", "value": "256"}); m.Success {
+		t.Error("expected an error setting A to 256")
+	}
+
+	var mem struct {
+		Address         string `json:"address"`
+		Data            []byte `json:"data"`
+		UnreadableBytes int    `json:"unreadableBytes"`
+	}
+	c.request("readMemory", map[string]any{"memoryReference": "0x0200", "count": 3}, &mem)
+	if mem.Address != "0x0200" || string(mem.Data) != "\xa2\x00\x20" {
+		t.Errorf("unexpected memory %+v", mem)
+	}
+	c.request("writeMemory", map[string]any{"memoryReference": "0x0300", "offset": 1, "data": []byte{0xBE, 0xEF}}, nil)
+	c.request("readMemory", map[string]any{"memoryReference": "$0300", "count": 3}, &mem)
+	if string(mem.Data) != "\x00\xbe\xef" {
+		t.Errorf("unexpected memory after write %x", mem.Data)
+	}
+	c.request("readMemory", map[string]any{"memoryReference": "0xFFFE", "count": 4}, &mem)
+	if len(mem.Data) != 2 || mem.UnreadableBytes != 2 {
+		t.Errorf("expected 2 bytes and 2 unreadable at the top of memory, got %+v", mem)
+	}
+}
+
+func TestInterruptFrames(t *testing.T) {
+	c := startServer(t, &Server{})
+	c.request("initialize", nil, nil)
+	c.request("launch", map[string]any{
+		"program":     writeProgram(t, t.TempDir(), core.VariantWDC65C02, brk),
+		"cpu":         "65c02",
+		"loadAddress": 0x0200,
+		"stopOnEntry": true,
+	}, nil)
+	c.request("configurationDone", nil, nil)
+	c.expectStop("entry")
+
+	for _, want := range [][]string{
+		{"$0210", "$0200"}, // No debug info, so no names or lines
+		{"$0300", "$0210", "$0200"},
+	} {
+		c.request("stepIn", nil, nil)
+		c.expectStop("step")
+		c.expectStack(want...)
+	}
+
+	c.request("stepOut", nil, nil)
+	c.expectStop("step")
+	c.expectStack("$0210", "$0200")
+	if pc := c.registers()["PC"]; pc != "$0212" {
+		t.Errorf("expected RTI to return past the BRK signature byte, got %s", pc)
+	}
+}
+
+// brk is reset through $0200, which calls a subroutine that executes BRK.
+const brk = `        .org $0200
+start:  jsr sub
+        stp
+        .res 12
+sub:    brk
+        .byte 0
+        rts
+        .res $0300-$0213
+irq:    rti
+        .res $FFFC-$0301
+        .word start, irq
+`
+
+func TestAttachAndPause(t *testing.T) {
+	ram := &SimpleRAM{}
+	prog := asm.MustAssemble(core.VariantNMOS, "        .org $0200\nstart:  jmp start")
+	prog.Load(ram)
+	d, err := debugger.New(core.VariantNMOS, ram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := d.CPU.Registers()
+	r.PC = 0x0200
+	d.CPU.SetRegisters(r)
+
+	c := startServer(t, NewServer(d))
+	c.request("initialize", nil, nil)
+	c.event("initialized", nil)
+	c.request("attach", map[string]any{"stopOnEntry": false}, nil)
+	c.request("configurationDone", nil, nil)
+
+	// Requests that need the CPU stop it briefly; the client sees no stop
+	c.request("threads", nil, nil)
+	var body struct {
+		Breakpoints []breakpoint `json:"breakpoints"`
+	}
+	c.request("setBreakpoints", map[string]any{"source": map[string]any{"path": "main.s"}, "breakpoints": []map[string]any{{"line": 1}}}, &body)
+	if len(body.Breakpoints) != 1 || body.Breakpoints[0].Verified {
+		t.Errorf("expected an unverified breakpoint without debug info, got %+v", body.Breakpoints)
+	}
+	c.registers()
+	if len(c.events) != 0 {
+		t.Errorf("unexpected events %+v", c.events)
+	}
+
+	c.request("pause", map[string]any{"threadId": 1}, nil)
+	c.expectStop("pause")
+	c.request("disconnect", nil, nil)
+	if d.OnStep != nil {
+		t.Error("expected disconnect to remove the step hook")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c := startServer(t, &Server{})
+	for _, tt := range []struct {
+		command string
+		args    any
+	}{
+		{"stackTrace", nil},
+		{"evaluate", map[string]any{"expression": "A"}},
+		{"attach", nil},
+		{"launch", map[string]any{"program": filepath.Join(t.TempDir(), "missing.bin")}},
+		{"launch", map[string]any{"program": "x", "cpu": "z80"}},
+		{"launch", map[string]any{"program": "x", "loadAddress": "$10000"}},
+	} {
+		if m := c.call(tt.command, tt.args); m.Success || m.Message == "" {
+			t.Errorf("%s: expected an error, got %+v", tt.command, m)
+		}
+	}
+
+	// A malformed header ends the session
+	fmt.Fprint(c.conn, "Content-Length: x\r\n\r\n")
+	if _, err := c.r.ReadLine(); err != io.EOF {
+		t.Errorf("expected the connection to close, got %v", err)
+	}
+}
@@ -0,0 +1,864 @@
+package dap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/dbginfo"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/debugger"
+)
+
+// Variable references for the Registers scope and the flags under P.
+const (
+	refRegisters = 1
+	refFlags     = 2
+)
+
+// maxFrames bounds the call stack. The 6502 stack holds at most 128 return
+// addresses, so deeper frames are ones whose stack space was reused.
+const maxFrames = 128
+
+// session is one client connection.
+type session struct {
+	s    *Server
+	w    io.Writer
+	seq  int
+	in   chan input
+	done chan struct{} // Closed when the session ends
+
+	d           *debugger.Debugger // Nil until launch or attach
+	info        *dbginfo.Info
+	labels      map[uint16]string
+	srcDir      string // Directory source names in info are relative to
+	stopOnEntry bool
+
+	breaks map[string][]*debugger.Breakpoint // Source breakpoints by path
+	temp   *debugger.Breakpoint              // Return breakpoint for next and stepOut
+	frames []frame
+
+	running chan result // Receives the result of Continue while it runs
+	pausing bool        // A pause request interrupted Continue
+
+	after func() error // Runs after the response to the current request
+}
+
+// frame is a subroutine or interrupt handler the CPU has entered but not
+// returned from.
+type frame struct {
+	call  uint16 // Address of the JSR or BRK, or the PC an interrupt interrupted
+	entry uint16 // Subroutine or handler address
+	ret   uint16 // Address RTS or RTI returns to
+	sp    byte   // SP with the return address pushed
+	retSP byte   // SP after returning
+}
+
+type result struct {
+	stop *debugger.Stop
+	err  error
+}
+
+var errNoProgram = errors.New("no program: launch or attach first")
+
+// handler executes a request and returns the response body.
+type handler func(ss *session, args json.RawMessage) (any, error)
+
+var handlers map[string]handler
+
+// runningOK lists the requests that do not stop a running CPU.
+var runningOK = map[string]bool{"pause": true, "threads": true}
+
+func init() {
+	handlers = map[string]handler{
+		"initialize":        (*session).initialize,
+		"launch":            (*session).launch,
+		"attach":            (*session).attach,
+		"disconnect":        (*session).disconnect,
+		"configurationDone": (*session).configurationDone,
+		"setBreakpoints":    (*session).setBreakpoints,
+		"threads":           (*session).threads,
+		"stackTrace":        (*session).stackTrace,
+		"scopes":            (*session).scopes,
+		"variables":         (*session).variables,
+		"setVariable":       (*session).setVariable,
+		"continue":          (*session).continue_,
+		"next":              (*session).next,
+		"stepIn":            (*session).stepIn,
+		"stepOut":           (*session).stepOut,
+		"pause":             (*session).pause,
+		"readMemory":        (*session).readMemory,
+		"writeMemory":       (*session).writeMemory,
+	}
+}
+
+// dispatch handles one request. Requests that need a stopped CPU
+// interrupt a running one and let it carry on afterwards.
+func (ss *session) dispatch(req *request) error {
+	h, ok := handlers[req.Command]
+	if !ok {
+		return ss.fail(req, fmt.Errorf("unsupported request %q", req.Command))
+	}
+
+	resume := false
+	if ss.running != nil && !runningOK[req.Command] {
+		ss.d.Interrupt()
+		r := <-ss.running
+		ss.running = nil
+		if r.err == nil && r.stop.Breakpoint == nil && !ss.pausing {
+			resume = true
+		} else if err := ss.stopped(r); err != nil {
+			return err
+		}
+	}
+
+	ss.after = nil
+	body, err := h(ss, req.Arguments)
+	if err != nil {
+		err = ss.fail(req, err)
+	} else if err = ss.respond(req, body); err == nil && ss.after != nil {
+		err = ss.after()
+	}
+	if resume && err == nil && ss.d != nil && ss.running == nil {
+		ss.run()
+	}
+	return err
+}
+
+func (ss *session) initialize(args json.RawMessage) (any, error) {
+	ss.after = func() error { return ss.event("initialized", nil) }
+	return map[string]any{
+		"supportsConfigurationDoneRequest":  true,
+		"supportsConditionalBreakpoints":    true,
+		"supportsHitConditionalBreakpoints": true,
+		"supportsSetVariable":               true,
+		"supportsReadMemoryRequest":         true,
+		"supportsWriteMemoryRequest":        true,
+	}, nil
+}
+
+// address is a number or a string such as "$0200" or "0x0200".
+type address uint16
+
+func (a *address) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		s = string(b)
+	}
+	n, err := parseNumber(s)
+	if err != nil || n < 0 || n > 0xFFFF {
+		return fmt.Errorf("bad address %s", b)
+	}
+	*a = address(n)
+	return nil
+}
+
+// parseNumber parses $hex, 0xhex or decimal.
+func parseNumber(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "$") {
+		s = "0x" + s[1:]
+	}
+	n, err := strconv.ParseInt(s, 0, 32)
+	return int(n), err
+}
+
+type launchArgs struct {
+	Program     string   `json:"program"`     // Binary image to load
+	LoadAddress *address `json:"loadAddress"` // Default $0200
+	CPU         string   `json:"cpu"`         // "nmos" (default) or "65c02"
+	DebugInfo   string   `json:"debugInfo"`   // ld65 --dbgfile output
+	Start       *address `json:"start"`       // Default the reset vector
+	StopOnEntry bool     `json:"stopOnEntry"`
+}
+
+// ram is the memory a launched program runs in.
+type ram struct {
+	memory [0x10000]byte
+}
+
+func (r *ram) Read(addr uint16) byte {
+	return r.memory[addr]
+}
+
+func (r *ram) Write(addr uint16, data byte) {
+	r.memory[addr] = data
+}
+
+func (ss *session) launch(raw json.RawMessage) (any, error) {
+	if ss.d != nil {
+		return nil, errors.New("already running a program")
+	}
+	var args launchArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	variant, err := parseVariant(args.CPU)
+	if err != nil {
+		return nil, err
+	}
+	image, err := os.ReadFile(args.Program)
+	if err != nil {
+		return nil, err
+	}
+	load := address(0x0200)
+	if args.LoadAddress != nil {
+		load = *args.LoadAddress
+	}
+	if int(load)+len(image) > 0x10000 {
+		return nil, fmt.Errorf("%s does not fit at $%04X", args.Program, load)
+	}
+
+	mem := &ram{}
+	copy(mem.memory[load:], image)
+	d, err := debugger.New(variant, mem)
+	if err != nil {
+		return nil, err
+	}
+	d.CPU.Reset()
+	if args.Start != nil {
+		r := d.CPU.Registers()
+		r.PC = uint16(*args.Start)
+		d.CPU.SetRegisters(r)
+	}
+
+	if err := ss.loadInfo(args.DebugInfo, nil); err != nil {
+		return nil, err
+	}
+	ss.use(d, args.StopOnEntry)
+	return nil, nil
+}
+
+func (ss *session) attach(raw json.RawMessage) (any, error) {
+	if ss.d != nil {
+		return nil, errors.New("already running a program")
+	}
+	if ss.s.Debugger == nil {
+		return nil, errors.New("nothing to attach to")
+	}
+	var args struct {
+		DebugInfo   string `json:"debugInfo"`
+		StopOnEntry *bool  `json:"stopOnEntry"` // Default true
+	}
+	if raw != nil {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, err
+		}
+	}
+	if err := ss.loadInfo(args.DebugInfo, ss.s.DebugInfo); err != nil {
+		return nil, err
+	}
+	ss.use(ss.s.Debugger, args.StopOnEntry == nil || *args.StopOnEntry)
+	return nil, nil
+}
+
+// loadInfo reads the debug info file at path, or uses def if path is empty.
+func (ss *session) loadInfo(path string, def *dbginfo.Info) error {
+	ss.info, ss.srcDir, ss.labels = def, "", nil
+	if path != "" {
+		info, err := dbginfo.ParseFile(path)
+		if err != nil {
+			return err
+		}
+		ss.info, ss.srcDir = info, filepath.Dir(path)
+	}
+	if ss.info != nil {
+		ss.labels = ss.info.Labels()
+	}
+	return nil
+}
+
+func (ss *session) use(d *debugger.Debugger, stopOnEntry bool) {
+	ss.d, ss.stopOnEntry = d, stopOnEntry
+	ss.frames = nil
+	d.OnStep = ss.track
+}
+
+func parseVariant(name string) (core.Variant, error) {
+	switch strings.ToLower(name) {
+	case "", "nmos", "6502":
+		return core.VariantNMOS, nil
+	case "65c02", "wdc", "cmos":
+		return core.VariantWDC65C02, nil
+	}
+	return 0, fmt.Errorf("unknown CPU %q (want nmos or 65c02)", name)
+}
+
+// detach stops the CPU and removes everything the session added to it.
+func (ss *session) detach() {
+	if ss.d == nil {
+		return
+	}
+	if ss.running != nil {
+		ss.d.Interrupt()
+		<-ss.running
+		ss.running = nil
+	}
+	for _, bps := range ss.breaks {
+		for _, bp := range bps {
+			ss.d.Delete(bp.ID)
+		}
+	}
+	ss.breaks = make(map[string][]*debugger.Breakpoint)
+	ss.clearTemp()
+	ss.d.OnStep = nil
+	ss.d = nil
+}
+
+func (ss *session) disconnect(args json.RawMessage) (any, error) {
+	ss.detach()
+	ss.after = func() error { return errDisconnect }
+	return nil, nil
+}
+
+func (ss *session) configurationDone(args json.RawMessage) (any, error) {
+	if ss.d == nil {
+		return nil, errNoProgram
+	}
+	ss.after = func() error {
+		if ss.stopOnEntry {
+			return ss.event("stopped", map[string]any{"reason": "entry", "threadId": 1, "allThreadsStopped": true})
+		}
+		ss.run()
+		return nil
+	}
+	return nil, nil
+}
+
+func (ss *session) threads(args json.RawMessage) (any, error) {
+	return map[string]any{"threads": []map[string]any{{"id": 1, "name": "CPU"}}}, nil
+}
+
+type source struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// source returns the client's view of a file named in the debug info.
+func (ss *session) source(name string) *source {
+	path := name
+	if ss.srcDir != "" && !filepath.IsAbs(name) {
+		path = filepath.Join(ss.srcDir, name)
+	}
+	return &source{Name: filepath.Base(name), Path: path}
+}
+
+type breakpoint struct {
+	ID                   int     `json:"id,omitempty"`
+	Verified             bool    `json:"verified"`
+	Message              string  `json:"message,omitempty"`
+	Source               *source `json:"source,omitempty"`
+	Line                 int     `json:"line,omitempty"`
+	InstructionReference string  `json:"instructionReference,omitempty"`
+}
+
+func (ss *session) setBreakpoints(raw json.RawMessage) (any, error) {
+	if ss.d == nil {
+		return nil, errNoProgram
+	}
+	var args struct {
+		Source      source `json:"source"`
+		Breakpoints []struct {
+			Line         int    `json:"line"`
+			Condition    string `json:"condition"`
+			HitCondition string `json:"hitCondition"`
+		} `json:"breakpoints"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+
+	path := args.Source.Path
+	for _, bp := range ss.breaks[path] {
+		ss.d.Delete(bp.ID)
+	}
+	delete(ss.breaks, path)
+
+	out := make([]breakpoint, 0, len(args.Breakpoints))
+	for _, b := range args.Breakpoints {
+		bp, err := ss.sourceBreakpoint(path, b.Line, b.Condition, b.HitCondition)
+		if err != nil {
+			out = append(out, breakpoint{Line: b.Line, Message: err.Error()})
+			continue
+		}
+		ss.breaks[path] = append(ss.breaks[path], bp)
+		l, _ := ss.info.LineForAddr(bp.Addr)
+		out = append(out, breakpoint{
+			ID:                   bp.ID,
+			Verified:             true,
+			Source:               ss.source(l.File),
+			Line:                 l.Line,
+			InstructionReference: fmt.Sprintf("0x%04X", bp.Addr),
+		})
+	}
+	return map[string]any{"breakpoints": out}, nil
+}
+
+// sourceBreakpoint adds an execution breakpoint for a source line. A hit
+// condition of n stops on the nth hit.
+func (ss *session) sourceBreakpoint(path string, line int, cond, hitCond string) (*debugger.Breakpoint, error) {
+	if ss.info == nil {
+		return nil, errors.New("no debug information")
+	}
+	addr, _, ok := ss.info.AddrForLine(path, line)
+	if !ok {
+		return nil, errors.New("no code at or after this line")
+	}
+	bp := debugger.Breakpoint{Kind: debugger.Exec, Addr: addr, Condition: cond}
+	if hitCond != "" {
+		n, err := parseNumber(strings.TrimLeft(hitCond, "=> "))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("bad hit count %q", hitCond)
+		}
+		bp.IgnoreCount = n - 1
+	}
+	return ss.d.Add(bp)
+}
+
+// track is the debugger's OnStep. It pushes a frame for each JSR, BRK and
+// interrupt and pops frames once SP rises above them.
+func (ss *session) track(r core.StepResult) {
+	regs := ss.d.CPU.Registers()
+	switch {
+	case r.Event == core.EventReset:
+		ss.frames = nil
+		return
+	case r.Event == core.EventInstruction && r.Opcode == 0x20: // JSR
+		ss.push(frame{call: r.PC, entry: regs.PC, ret: r.PC + 3, sp: regs.SP, retSP: regs.SP + 2})
+	case r.Event == core.EventInstruction && r.Opcode == 0x00: // BRK
+		ss.push(frame{call: r.PC, entry: regs.PC, ret: r.PC + 2, sp: regs.SP, retSP: regs.SP + 3})
+	case r.Event == core.EventIRQ || r.Event == core.EventNMI:
+		ss.push(frame{call: r.PC, entry: regs.PC, ret: r.PC, sp: regs.SP, retSP: regs.SP + 3})
+	}
+	for len(ss.frames) > 0 && regs.SP > ss.frames[len(ss.frames)-1].sp {
+		ss.frames = ss.frames[:len(ss.frames)-1]
+	}
+}
+
+func (ss *session) push(f frame) {
+	if len(ss.frames) == maxFrames {
+		ss.frames = ss.frames[1:]
+	}
+	ss.frames = append(ss.frames, f)
+}
+
+type stackFrame struct {
+	ID                          int     `json:"id"`
+	Name                        string  `json:"name"`
+	Source                      *source `json:"source,omitempty"`
+	Line                        int     `json:"line"`
+	Column                      int     `json:"column"`
+	InstructionPointerReference string  `json:"instructionPointerReference"`
+}
+
+func (ss *session) stackTrace(raw json.RawMessage) (any, error) {
+	if ss.d == nil {
+		return nil, errNoProgram
+	}
+	var args struct {
+		StartFrame int `json:"startFrame"`
+		Levels     int `json:"levels"`
+	}
+	if raw != nil {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, err
+		}
+	}
+
+	// Frame 0 is the current PC, each further frame the call site of the
+	// subroutine or handler above it
+	pc := ss.d.CPU.Registers().PC
+	var frames []stackFrame
+	for i := 0; i <= len(ss.frames); i++ {
+		var name string
+		if i < len(ss.frames) {
+			name = ss.name(ss.frames[len(ss.frames)-1-i].entry)
+		} else {
+			name = ss.nearestLabel(pc)
+		}
+		f := stackFrame{ID: i + 1, Name: name, InstructionPointerReference: fmt.Sprintf("0x%04X", pc)}
+		if ss.info != nil {
+			if l, ok := ss.info.LineForAddr(pc); ok {
+				f.Source, f.Line, f.Column = ss.source(l.File), l.Line, 1
+			}
+		}
+		frames = append(frames, f)
+		if i < len(ss.frames) {
+			pc = ss.frames[len(ss.frames)-1-i].call
+		}
+	}
+
+	total := len(frames)
+	frames = frames[min(args.StartFrame, total):]
+	if args.Levels > 0 && args.Levels < len(frames) {
+		frames = frames[:args.Levels]
+	}
+	return map[string]any{"stackFrames": frames, "totalFrames": total}, nil
+}
+
+// name returns the label at addr, or addr in hex.
+func (ss *session) name(addr uint16) string {
+	if label, ok := ss.labels[addr]; ok {
+		return label
+	}
+	return fmt.Sprintf("$%04X", addr)
+}
+
+// nearestLabel names the code at addr after the closest label before it.
+func (ss *session) nearestLabel(addr uint16) string {
+	best, found := uint16(0), false
+	for a := range ss.labels {
+		if a <= addr && (!found || a > best) {
+			best, found = a, true
+		}
+	}
+	if !found {
+		return fmt.Sprintf("$%04X", addr)
+	}
+	return ss.labels[best]
+}
+
+func (ss *session) scopes(args json.RawMessage) (any, error) {
+	if ss.d == nil {
+		return nil, errNoProgram
+	}
+	return map[string]any{"scopes": []map[string]any{
+		{"name": "Registers", "presentationHint": "registers", "variablesReference": refRegisters, "expensive": false},
+	}}, nil
+}
+
+type variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	VariablesReference int    `json:"variablesReference"`
+	MemoryReference    string `json:"memoryReference,omitempty"`
+}
+
+// flagNames lists the flags under P, most significant first.
+var flagNames = []struct {
+	name string
+	flag byte
+}{
+	{"N", core.FlagNegative},
+	{"V", core.FlagOverflow},
+	{"B", core.FlagBreak},
+	{"D", core.FlagDecimal},
+	{"I", core.FlagInterruptDisable},
+	{"Z", core.FlagZero},
+	{"C", core.FlagCarry},
+}
+
+// flagString shows the flags as letters, capitals for set: "nv-bdIzc".
+func flagString(p byte) string {
+	var b strings.Builder
+	for i, f := range flagNames {
+		if i == 2 {
+			b.WriteByte('-')
+		}
+		if p&f.flag != 0 {
+			b.WriteString(f.name)
+		} else {
+			b.WriteString(strings.ToLower(f.name))
+		}
+	}
+	return b.String()
+}
+
+func (ss *session) variables(raw json.RawMessage) (any, error) {
+	if ss.d == nil {
+		return nil, errNoProgram
+	}
+	var args struct {
+		VariablesReference int `json:"variablesReference"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+
+	r := ss.d.CPU.Registers()
+	var vars []variable
+	switch args.VariablesReference {
+	case refRegisters:
+		vars = []variable{
+			{Name: "A", Value: fmt.Sprintf("$%02X", r.A)},
+			{Name: "X", Value: fmt.Sprintf("$%02X", r.X)},
+			{Name: "Y", Value: fmt.Sprintf("$%02X", r.Y)},
+			{Name: "SP", Value: fmt.Sprintf("$%02X", r.SP), MemoryReference: fmt.Sprintf("0x%04X", 0x0100|uint16(r.SP))},
+			{Name: "PC", Value: fmt.Sprintf("$%04X", r.PC), MemoryReference: fmt.Sprintf("0x%04X", r.PC)},
+			{Name: "P", Value: fmt.Sprintf("$%02X %s", r.Status, flagString(r.Status)), VariablesReference: refFlags},
+		}
+	case refFlags:
+		for _, f := range flagNames {
+			v := "0"
+			if r.Status&f.flag != 0 {
+				v = "1"
+			}
+			vars = append(vars, variable{Name: f.name, Value: v})
+		}
+	default:
+		return nil, fmt.Errorf("unknown variables reference %d", args.VariablesReference)
+	}
+	return map[string]any{"variables": vars}, nil
+}
+
+func (ss *session) setVariable(raw json.RawMessage) (any, error) {
+	if ss.d == nil {
+		return nil, errNoProgram
+	}
+	var args struct {
+		VariablesReference int    `json:"variablesReference"`
+		Name               string `json:"name"`
+		Value              string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	n, err := parseNumber(strings.Fields(args.Value + " ")[0])
+	if err != nil {
+		return nil, fmt.Errorf("bad value %q", args.Value)
+	}
+
+	r := ss.d.CPU.Registers()
+	limit := 0xFF
+	var value string
+	switch args.VariablesReference {
+	case refRegisters:
+		var reg *byte
+		switch args.Name {
+		case "A":
+			reg = &r.A
+		case "X":
+			reg = &r.X
+		case "Y":
+			reg = &r.Y
+		case "SP":
+			reg = &r.SP
+		case "P":
+			reg = &r.Status
+		case "PC":
+			limit = 0xFFFF
+		default:
+			return nil, fmt.Errorf("unknown register %q", args.Name)
+		}
+		if n < 0 || n > limit {
+			return nil, fmt.Errorf("%s out of range for %s", args.Value, args.Name)
+		}
+		if reg == nil {
+			r.PC = uint16(n)
+			value = fmt.Sprintf("$%04X", n)
+		} else {
+			*reg = byte(n)
+			r.Status |= core.FlagUnused
+			value = fmt.Sprintf("$%02X", *reg)
+			if reg == &r.Status {
+				value += " " + flagString(r.Status)
+			}
+		}
+	case refFlags:
+		i := 0
+		for i < len(flagNames) && flagNames[i].name != args.Name {
+			i++
+		}
+		if i == len(flagNames) || n < 0 || n > 1 {
+			return nil, fmt.Errorf("cannot set flag %s to %s", args.Name, args.Value)
+		}
+		r.Status &^= flagNames[i].flag
+		if n == 1 {
+			r.Status |= flagNames[i].flag
+		}
+		value = strconv.Itoa(n)
+	default:
+		return nil, fmt.Errorf("unknown variables reference %d", args.VariablesReference)
+	}
+	ss.d.CPU.SetRegisters(r)
+	return map[string]any{"value": value}, nil
+}
+
+// run continues in the background. The result arrives on ss.running.
+func (ss *session) run() {
+	done := make(chan result, 1)
+	ss.running = done
+	d := ss.d
+	go func() {
+		stop, err := d.Continue()
+		done <- result{stop, err}
+	}()
+}
+
+// step executes one instruction and reports the stop.
+func (ss *session) step() error {
+	stop, err := ss.d.Step()
+	return ss.stopped(result{stop, err})
+}
+
+// runTo continues until a temporary breakpoint at addr is hit with the
+// stack pointer at sp, or anything else stops the CPU.
+func (ss *session) runTo(addr uint16, sp byte) error {
+	bp, err := ss.d.Add(debugger.Breakpoint{
+		Kind:      debugger.Exec,
+		Addr:      addr,
+		Condition: fmt.Sprintf("SP == %d", sp),
+		Temporary: true,
+	})
+	if err != nil {
+		return err
+	}
+	ss.temp = bp
+	ss.run()
+	return nil
+}
+
+func (ss *session) clearTemp() {
+	if ss.temp != nil {
+		ss.d.Delete(ss.temp.ID) // Already gone if it was hit
+		ss.temp = nil
+	}
+}
+
+// stopped sends the stopped event for a Step or Continue.
+func (ss *session) stopped(r result) error {
+	body := map[string]any{"threadId": 1, "allThreadsStopped": true}
+	switch {
+	case r.err != nil:
+		body["reason"] = "exception"
+		body["description"] = "CPU halted"
+		body["text"] = r.err.Error()
+	case r.stop == nil || r.stop.Breakpoint != nil && r.stop.Breakpoint == ss.temp:
+		body["reason"] = "step"
+	case r.stop.Breakpoint == nil:
+		body["reason"] = "pause"
+	case r.stop.Breakpoint.Kind == debugger.Exec:
+		body["reason"] = "breakpoint"
+		body["hitBreakpointIds"] = []int{r.stop.Breakpoint.ID}
+	default:
+		body["reason"] = "data breakpoint"
+		body["description"] = r.stop.String()
+	}
+	ss.clearTemp()
+	ss.pausing = false
+	return ss.event("stopped", body)
+}
+
+func (ss *session) continue_(args json.RawMessage) (any, error) {
+	if ss.d == nil {
+		return nil, errNoProgram
+	}
+	ss.after = func() error { ss.run(); return nil }
+	return map[string]any{"allThreadsContinued": true}, nil
+}
+
+// next steps over JSR by running to the instruction after it with the
+// stack as it was.
+func (ss *session) next(args json.RawMessage) (any, error) {
+	if ss.d == nil {
+		return nil, errNoProgram
+	}
+	r := ss.d.CPU.Registers()
+	if ss.d.Peek(r.PC) == 0x20 {
+		ss.after = func() error { return ss.runTo(r.PC+3, r.SP) }
+	} else {
+		ss.after = ss.step
+	}
+	return nil, nil
+}
+
+func (ss *session) stepIn(args json.RawMessage) (any, error) {
+	if ss.d == nil {
+		return nil, errNoProgram
+	}
+	ss.after = ss.step
+	return nil, nil
+}
+
+// stepOut runs to the return from the innermost frame, or steps when there
+// is none.
+func (ss *session) stepOut(args json.RawMessage) (any, error) {
+	if ss.d == nil {
+		return nil, errNoProgram
+	}
+	if len(ss.frames) == 0 {
+		ss.after = ss.step
+		return nil, nil
+	}
+	f := ss.frames[len(ss.frames)-1]
+	ss.after = func() error { return ss.runTo(f.ret, f.retSP) }
+	return nil, nil
+}
+
+func (ss *session) pause(args json.RawMessage) (any, error) {
+	if ss.running != nil {
+		ss.pausing = true
+		ss.d.Interrupt()
+	}
+	return nil, nil
+}
+
+// memoryRange parses a memory reference and offset.
+func memoryRange(ref string, offset int) (int, error) {
+	base, err := parseNumber(ref)
+	if err != nil {
+		return 0, fmt.Errorf("bad memory reference %q", ref)
+	}
+	return base + offset, nil
+}
+
+func (ss *session) readMemory(raw json.RawMessage) (any, error) {
+	if ss.d == nil {
+		return nil, errNoProgram
+	}
+	var args struct {
+		MemoryReference string `json:"memoryReference"`
+		Offset          int    `json:"offset"`
+		Count           int    `json:"count"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	addr, err := memoryRange(args.MemoryReference, args.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bytes outside the 64K address space are unreadable
+	var data []byte
+	for a := max(addr, 0); a < addr+args.Count && a <= 0xFFFF; a++ {
+		data = append(data, ss.d.Peek(uint16(a)))
+	}
+	return map[string]any{
+		"address":         fmt.Sprintf("0x%04X", max(addr, 0)),
+		"data":            data, // Marshaled as base64
+		"unreadableBytes": args.Count - len(data),
+	}, nil
+}
+
+func (ss *session) writeMemory(raw json.RawMessage) (any, error) {
+	if ss.d == nil {
+		return nil, errNoProgram
+	}
+	var args struct {
+		MemoryReference string `json:"memoryReference"`
+		Offset          int    `json:"offset"`
+		Data            []byte `json:"data"` // Base64
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	addr, err := memoryRange(args.MemoryReference, args.Offset)
+	if err != nil {
+		return nil, err
+	}
+	if addr < 0 || addr+len(args.Data) > 0x10000 {
+		return nil, fmt.Errorf("write of %d bytes at %d is outside memory", len(args.Data), addr)
+	}
+	for i, v := range args.Data {
+		if err := ss.d.Poke(uint16(addr+i), v); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]any{"bytesWritten": len(args.Data)}, nil
+}
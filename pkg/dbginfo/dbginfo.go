@@ -0,0 +1,255 @@
+// Package dbginfo reads the debug information files written by the cc65
+// linker (ld65 --dbgfile). It maps source lines to addresses and back and
+// lists the symbols, which is what a source-level debugger needs.
+//
+// A file is a list of records, one per line, such as
+//
+//	file	id=0,name="main.s",size=420,mtime=0x6523A1F0,mod=0
+//	seg	id=0,name="CODE",start=0x008000,size=0x0042,addrsize=absolute,type=ro
+//	span	id=3,seg=0,start=6,size=3
+//	line	id=7,file=0,line=12,span=3
+//	sym	id=2,name="reset",addrsize=absolute,scope=0,def=5,val=0x8000,seg=0,type=lab
+//
+// Records and keys this package does not use are ignored.
+package dbginfo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Line is a source line and the code generated for it.
+type Line struct {
+	File string
+	Line int
+	Addr uint16 // Address of the first byte of code
+	Size int    // Bytes of code
+}
+
+// Symbol is a label or constant.
+type Symbol struct {
+	Name  string
+	Value int
+	Label bool // A label ("lab") rather than an equate ("equ")
+}
+
+// Info is parsed debug information.
+type Info struct {
+	Files   []string // Source file names as given to the assembler
+	Lines   []Line   // Lines that generated code, ordered by address
+	Symbols []Symbol
+}
+
+// ParseFile reads a debug information file.
+func ParseFile(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads debug information.
+func Parse(r io.Reader) (*Info, error) {
+	type span struct{ seg, start, size int }
+	type line struct {
+		file, line int
+		spans      []int
+	}
+	files := make(map[int]string)
+	segs := make(map[int]int) // Segment ID to start address
+	spans := make(map[int]span)
+	var lines []line
+	info := &Info{}
+
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		kind, fields, ok := strings.Cut(strings.TrimSpace(sc.Text()), "\t")
+		if !ok {
+			continue
+		}
+		rec, err := parseRecord(fields)
+		if err != nil {
+			return nil, fmt.Errorf("dbginfo: line %d: %w", n, err)
+		}
+
+		switch kind {
+		case "file":
+			files[rec.int("id")] = rec.str("name")
+		case "seg":
+			segs[rec.int("id")] = rec.int("start")
+		case "span":
+			spans[rec.int("id")] = span{rec.int("seg"), rec.int("start"), rec.int("size")}
+		case "line":
+			if rec.has("span") {
+				lines = append(lines, line{rec.int("file"), rec.int("line"), rec.ints("span")})
+			}
+		case "sym":
+			if rec.has("val") {
+				info.Symbols = append(info.Symbols, Symbol{
+					Name:  rec.str("name"),
+					Value: rec.int("val"),
+					Label: rec.str("type") == "lab",
+				})
+			}
+		}
+		if rec.err != nil {
+			return nil, fmt.Errorf("dbginfo: line %d: %w", n, rec.err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(files))
+	for id := range files {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		info.Files = append(info.Files, files[id])
+	}
+
+	for _, l := range lines {
+		for _, id := range l.spans {
+			sp, ok := spans[id]
+			if !ok || sp.size == 0 {
+				continue
+			}
+			info.Lines = append(info.Lines, Line{
+				File: files[l.file],
+				Line: l.line,
+				Addr: uint16(segs[sp.seg] + sp.start),
+				Size: sp.size,
+			})
+		}
+	}
+	// Macro expansions and .include produce several lines per span; keep
+	// them in file order so the innermost (last) line wins in LineForAddr
+	sort.SliceStable(info.Lines, func(i, j int) bool { return info.Lines[i].Addr < info.Lines[j].Addr })
+	return info, nil
+}
+
+// sameFile compares file names, allowing either to be a path relative to
+// the other's directory.
+func sameFile(a, b string) bool {
+	if a == b {
+		return true
+	}
+	a, b = filepath.ToSlash(filepath.Clean(a)), filepath.ToSlash(filepath.Clean(b))
+	return a == b || strings.HasSuffix(a, "/"+b) || strings.HasSuffix(b, "/"+a)
+}
+
+// AddrForLine returns the address of the first code generated for a source
+// line. If the line generated no code, the next line in the same file that
+// did is used, as debuggers do when a breakpoint is set on a comment.
+func (info *Info) AddrForLine(file string, line int) (addr uint16, actual int, ok bool) {
+	for _, l := range info.Lines {
+		if !sameFile(l.File, file) || l.Line < line {
+			continue
+		}
+		if !ok || l.Line < actual {
+			addr, actual, ok = l.Addr, l.Line, true
+		}
+	}
+	return addr, actual, ok
+}
+
+// LineForAddr returns the source line whose code contains addr.
+func (info *Info) LineForAddr(addr uint16) (Line, bool) {
+	i := sort.Search(len(info.Lines), func(i int) bool { return info.Lines[i].Addr > addr })
+	for i--; i >= 0; i-- {
+		if l := info.Lines[i]; int(addr) < int(l.Addr)+l.Size {
+			return l, true
+		}
+	}
+	return Line{}, false
+}
+
+// Labels returns the labels by address, for disasm.Disassembler.Labels.
+func (info *Info) Labels() map[uint16]string {
+	labels := make(map[uint16]string)
+	for _, s := range info.Symbols {
+		if s.Label && s.Value >= 0 && s.Value <= 0xFFFF {
+			if _, ok := labels[uint16(s.Value)]; !ok {
+				labels[uint16(s.Value)] = s.Name
+			}
+		}
+	}
+	return labels
+}
+
+// record is the key=value list of one line of the file. The accessors keep
+// the first conversion error in err.
+type record struct {
+	fields map[string]string
+	err    error
+}
+
+// parseRecord splits key=value pairs, allowing commas in quoted values.
+func parseRecord(s string) (*record, error) {
+	rec := &record{fields: make(map[string]string)}
+	for s != "" {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value in %q", s)
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in %q", s)
+			}
+			value, rest = rest[1:end+1], strings.TrimPrefix(rest[end+2:], ",")
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		rec.fields[key] = value
+		s = rest
+	}
+	return rec, nil
+}
+
+func (r *record) has(key string) bool {
+	_, ok := r.fields[key]
+	return ok
+}
+
+func (r *record) str(key string) string {
+	return r.fields[key]
+}
+
+func (r *record) int(key string) int {
+	v, err := strconv.ParseInt(r.fields[key], 0, 64)
+	if err != nil {
+		r.fail(key)
+	}
+	return int(v)
+}
+
+// ints parses a list such as "3+4+7".
+func (r *record) ints(key string) []int {
+	var out []int
+	for _, f := range strings.Split(r.fields[key], "+") {
+		v, err := strconv.Atoi(f)
+		if err != nil {
+			r.fail(key)
+			return nil
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+func (r *record) fail(key string) {
+	if r.err == nil {
+		r.err = fmt.Errorf("bad %s value %q", key, r.fields[key])
+	}
+}
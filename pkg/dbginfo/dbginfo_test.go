@@ -0,0 +1,128 @@
+package dbginfo
+
+import (
+	"strings"
+	"testing"
+)
+
+// sample is trimmed from the output of
+//
+//	ca65 -g main.s && ld65 -C sim.cfg --dbgfile main.dbg main.o
+//
+// for a program with a subroutine in a second file.
+const sample = `version	major=2,minor=0
+info	csym=0,file=2,lib=0,line=9,mod=1,scope=2,seg=2,span=6,sym=3,type=4
+file	id=0,name="main.s",size=181,mtime=0x6523A1F0,mod=0
+file	id=1,name="lib/delay.s",size=64,mtime=0x6523A1F0,mod=0
+line	id=0,file=0,line=1
+line	id=1,file=0,line=4,span=0
+line	id=2,file=0,line=5,span=1
+line	id=3,file=0,line=6,span=2
+line	id=4,file=0,line=8,span=3
+line	id=5,file=1,line=2,span=4
+line	id=6,file=1,line=3,span=5
+line	id=7,file=0,line=10,type=2,count=1
+mod	id=0,name="main.o",file=0
+seg	id=0,name="CODE",start=0x008000,size=0x000D,addrsize=absolute,type=ro,oname="main.bin",ooffs=0
+seg	id=1,name="ZEROPAGE",start=0x000010,size=0x0002,addrsize=zeropage,type=rw
+span	id=0,seg=0,start=0,size=2
+span	id=1,seg=0,start=2,size=3
+span	id=2,seg=0,start=5,size=3
+span	id=3,seg=0,start=8,size=3
+span	id=4,seg=0,start=11,size=1
+span	id=5,seg=0,start=12,size=1
+scope	id=0,name="",mod=0,size=13,span=0+1+2+3
+sym	id=0,name="reset",addrsize=absolute,scope=0,def=1,ref=4,val=0x8000,seg=0,type=lab
+sym	id=1,name="delay",addrsize=absolute,scope=0,def=5,val=0x800B,seg=0,type=lab
+sym	id=2,name="COUNT",addrsize=zeropage,scope=0,def=0,val=0x10,type=equ
+sym	id=3,name="putc",addrsize=absolute,scope=0,type=imp
+`
+
+func TestParse(t *testing.T) {
+	info, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(info.Files) != 2 || info.Files[0] != "main.s" || info.Files[1] != "lib/delay.s" {
+		t.Errorf("unexpected files %q", info.Files)
+	}
+	if len(info.Lines) != 6 {
+		t.Fatalf("expected 6 lines with code, got %d", len(info.Lines))
+	}
+	if len(info.Symbols) != 3 {
+		t.Errorf("expected 3 symbols with values, got %d", len(info.Symbols))
+	}
+
+	labels := info.Labels()
+	if labels[0x8000] != "reset" || labels[0x800B] != "delay" || labels[0x0010] != "" {
+		t.Errorf("unexpected labels %v", labels)
+	}
+}
+
+func TestLines(t *testing.T) {
+	info, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file   string
+		line   int
+		addr   uint16
+		actual int
+	}{
+		{"main.s", 4, 0x8000, 4},
+		{"main.s", 6, 0x8005, 6},
+		{"main.s", 7, 0x8008, 8}, // No code, so the next line
+		{"/home/me/project/main.s", 5, 0x8002, 5},
+		{"delay.s", 3, 0x800C, 3},
+		{"lib/delay.s", 2, 0x800B, 2},
+	}
+	for _, tt := range tests {
+		addr, actual, ok := info.AddrForLine(tt.file, tt.line)
+		if !ok || addr != tt.addr || actual != tt.actual {
+			t.Errorf("%s:%d: got $%04X line %d %v, want $%04X line %d", tt.file, tt.line, addr, actual, ok, tt.addr, tt.actual)
+		}
+	}
+	if _, _, ok := info.AddrForLine("main.s", 9); ok {
+		t.Error("expected no code after line 8")
+	}
+	if _, _, ok := info.AddrForLine("other.s", 1); ok {
+		t.Error("expected no code in an unknown file")
+	}
+
+	for _, tt := range []struct {
+		addr uint16
+		file string
+		line int
+	}{
+		{0x8000, "main.s", 4},
+		{0x8001, "main.s", 4},
+		{0x8007, "main.s", 6},
+		{0x800C, "lib/delay.s", 3},
+	} {
+		l, ok := info.LineForAddr(tt.addr)
+		if !ok || l.File != tt.file || l.Line != tt.line {
+			t.Errorf("$%04X: got %s:%d %v, want %s:%d", tt.addr, l.File, l.Line, ok, tt.file, tt.line)
+		}
+	}
+	if l, ok := info.LineForAddr(0x800D); ok {
+		t.Errorf("expected no line after the code, got %v", l)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, src := range []string{
+		"file\tid=x,name=\"a.s\"",
+		"file\tid=0,name=\"a.s",
+		"span\tid=0,seg=0,start",
+		"line\tid=0,file=0,line=1,span=1+x",
+	} {
+		if _, err := Parse(strings.NewReader(src)); err == nil {
+			t.Errorf("%q: expected an error", src)
+		} else if !strings.HasPrefix(err.Error(), "dbginfo: line 1: ") {
+			t.Errorf("%q: unexpected error %v", src, err)
+		}
+	}
+}
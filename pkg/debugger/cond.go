@@ -0,0 +1,335 @@
+package debugger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// Conditions are C-like expressions over the CPU state, for example
+//
+//	A == $FF && X > 3
+//	[$10] & $80 && !C
+//	PC >= $C000 || [SP+$101] == $12
+//
+// Names (case insensitive):
+//
+//	A X Y SP P PC           registers (S is a synonym for SP)
+//	C Z I D B V N           flags, 0 or 1
+//	[expr]                  the byte at expr, read without side effects
+//
+// Numbers are decimal, $hex, 0xhex or %binary. Operators, from highest to
+// lowest precedence:
+//
+//	!  -  ~                 unary
+//	*  /  %  &  <<  >>
+//	+  -  |  ^
+//	==  !=  <  <=  >  >=
+//	&&
+//	||
+//
+// A condition is true when it evaluates to a non-zero value.
+
+// env is what a condition is evaluated against.
+type env struct {
+	regs core.Registers
+	bus  core.Bus // Unwatched bus, so conditions never trigger watchpoints
+}
+
+// cond is a compiled condition.
+type cond func(e *env) int
+
+type condParser struct {
+	src  string
+	toks []string
+	pos  int
+}
+
+// compileCondition parses a condition expression.
+func compileCondition(src string) (cond, error) {
+	toks, err := tokenizeCondition(src)
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: %w", src, err)
+	}
+	p := &condParser{src: src, toks: toks}
+	c, err := p.or()
+	if err == nil && p.pos < len(p.toks) {
+		err = fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: %w", src, err)
+	}
+	return c, nil
+}
+
+// condOps lists the operators, longest first so "<=" wins over "<".
+var condOps = []string{"==", "!=", "<=", ">=", "&&", "||", "<<", ">>", "<", ">", "+", "-", "*", "/", "%", "&", "|", "^", "!", "~", "(", ")", "[", "]"}
+
+func tokenizeCondition(s string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+			continue
+		case c == '$' || c == '%' && i+1 < len(s) && (s[i+1] == '0' || s[i+1] == '1') || isAlnum(c):
+			j := i + 1
+			for j < len(s) && isAlnum(s[j]) {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+			continue
+		}
+
+		matched := false
+		for _, op := range condOps {
+			if strings.HasPrefix(s[i:], op) {
+				toks = append(toks, op)
+				i += len(op)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return toks, nil
+}
+
+func isAlnum(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func (p *condParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *condParser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	for _, op := range ops {
+		if t == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// binary parses a left-associative level of binary operators.
+func (p *condParser) binary(next func() (cond, error), ops ...string) (cond, error) {
+	lhs, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return lhs, nil
+		}
+		rhs, err := next()
+		if err != nil {
+			return nil, err
+		}
+		lhs = binaryOp(op, lhs, rhs)
+	}
+}
+
+func binaryOp(op string, l, r cond) cond {
+	switch op {
+	case "||":
+		return func(e *env) int { return boolInt(l(e) != 0 || r(e) != 0) }
+	case "&&":
+		return func(e *env) int { return boolInt(l(e) != 0 && r(e) != 0) }
+	case "==":
+		return func(e *env) int { return boolInt(l(e) == r(e)) }
+	case "!=":
+		return func(e *env) int { return boolInt(l(e) != r(e)) }
+	case "<":
+		return func(e *env) int { return boolInt(l(e) < r(e)) }
+	case "<=":
+		return func(e *env) int { return boolInt(l(e) <= r(e)) }
+	case ">":
+		return func(e *env) int { return boolInt(l(e) > r(e)) }
+	case ">=":
+		return func(e *env) int { return boolInt(l(e) >= r(e)) }
+	case "+":
+		return func(e *env) int { return l(e) + r(e) }
+	case "-":
+		return func(e *env) int { return l(e) - r(e) }
+	case "|":
+		return func(e *env) int { return l(e) | r(e) }
+	case "^":
+		return func(e *env) int { return l(e) ^ r(e) }
+	case "*":
+		return func(e *env) int { return l(e) * r(e) }
+	case "/":
+		return func(e *env) int {
+			if d := r(e); d != 0 {
+				return l(e) / d
+			}
+			return 0
+		}
+	case "%":
+		return func(e *env) int {
+			if d := r(e); d != 0 {
+				return l(e) % d
+			}
+			return 0
+		}
+	case "&":
+		return func(e *env) int { return l(e) & r(e) }
+	case "<<":
+		return func(e *env) int { return l(e) << uint(r(e)&31) }
+	default: // ">>"
+		return func(e *env) int { return l(e) >> uint(r(e)&31) }
+	}
+}
+
+func (p *condParser) or() (cond, error) {
+	return p.binary(p.and, "||")
+}
+
+func (p *condParser) and() (cond, error) {
+	return p.binary(p.compare, "&&")
+}
+
+func (p *condParser) compare() (cond, error) {
+	return p.binary(p.sum, "==", "!=", "<=", ">=", "<", ">")
+}
+
+func (p *condParser) sum() (cond, error) {
+	return p.binary(p.product, "+", "-", "|", "^")
+}
+
+func (p *condParser) product() (cond, error) {
+	return p.binary(p.unary, "*", "/", "%", "&", "<<", ">>")
+}
+
+func (p *condParser) unary() (cond, error) {
+	op, ok := p.accept("!", "-", "~")
+	if !ok {
+		return p.primary()
+	}
+	v, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "!":
+		return func(e *env) int { return boolInt(v(e) == 0) }, nil
+	case "-":
+		return func(e *env) int { return -v(e) }, nil
+	}
+	return func(e *env) int { return ^v(e) }, nil
+}
+
+func (p *condParser) primary() (cond, error) {
+	if _, ok := p.accept("("); ok {
+		v, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("missing )")
+		}
+		return v, nil
+	}
+
+	if _, ok := p.accept("["); ok {
+		addr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept("]"); !ok {
+			return nil, fmt.Errorf("missing ]")
+		}
+		return func(e *env) int { return int(e.bus.Read(uint16(addr(e)))) }, nil
+	}
+
+	t := p.peek()
+	if t == "" {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+
+	if v, ok := parseNumber(t); ok {
+		return func(*env) int { return v }, nil
+	}
+	if c := register(t); c != nil {
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown name %q", t)
+}
+
+func parseNumber(t string) (int, bool) {
+	var v uint64
+	var err error
+	switch {
+	case t[0] == '$':
+		v, err = strconv.ParseUint(t[1:], 16, 32)
+	case t[0] == '%':
+		v, err = strconv.ParseUint(t[1:], 2, 32)
+	case strings.HasPrefix(t, "0x") || strings.HasPrefix(t, "0X"):
+		v, err = strconv.ParseUint(t[2:], 16, 32)
+	case t[0] >= '0' && t[0] <= '9':
+		v, err = strconv.ParseUint(t, 10, 32)
+	default:
+		return 0, false
+	}
+	return int(v), err == nil
+}
+
+func flag(mask byte) cond {
+	return func(e *env) int { return boolInt(e.regs.Status&mask != 0) }
+}
+
+// register returns the accessor for a register or flag name.
+func register(name string) cond {
+	switch strings.ToUpper(name) {
+	case "A":
+		return func(e *env) int { return int(e.regs.A) }
+	case "X":
+		return func(e *env) int { return int(e.regs.X) }
+	case "Y":
+		return func(e *env) int { return int(e.regs.Y) }
+	case "SP", "S":
+		return func(e *env) int { return int(e.regs.SP) }
+	case "P":
+		return func(e *env) int { return int(e.regs.Status) }
+	case "PC":
+		return func(e *env) int { return int(e.regs.PC) }
+	case "C":
+		return flag(core.FlagCarry)
+	case "Z":
+		return flag(core.FlagZero)
+	case "I":
+		return flag(core.FlagInterruptDisable)
+	case "D":
+		return flag(core.FlagDecimal)
+	case "B":
+		return flag(core.FlagBreak)
+	case "V":
+		return flag(core.FlagOverflow)
+	case "N":
+		return flag(core.FlagNegative)
+	}
+	return nil
+}
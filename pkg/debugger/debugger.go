@@ -0,0 +1,463 @@
+// Package debugger adds breakpoints and watchpoints to a CPU of either
+// variant.
+//
+// A Debugger builds its CPU on a bus that watches every access, so it can
+// stop on:
+//   - Execution of an address (through BaseCPU.BreakFunc)
+//   - Reads, writes or both within an address range
+//   - Execution of a particular opcode
+//   - Entry to an IRQ, NMI or reset sequence
+//
+// Any breakpoint can carry a condition over registers, flags and memory, such
+// as "A == $FF && X > 3", an ignore count and a temporary flag that deletes
+// it after it first stops execution.
+//
+// Execution and opcode breakpoints stop before the instruction runs.
+// Watchpoints and interrupt breakpoints stop after the instruction or
+// interrupt sequence that triggered them has completed.
+//
+// After Record, execution can also be reversed: StepBack and StepBackCycle
+// go back an instruction or a cycle, ReverseContinue runs back to the
+// previous breakpoint hit and LastWrite finds the instruction that last
+// wrote an address.
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// Kind selects what a breakpoint matches.
+type Kind int
+
+const (
+	// Exec stops before the instruction at Addr is executed.
+	Exec Kind = iota
+
+	// Read, Write and Access stop after an instruction reads, writes or
+	// accesses any address from Addr to End inclusive. They match every
+	// bus access, as a device on the bus would see it, including the
+	// dummy reads whose data the CPU discards.
+	Read
+	Write
+	Access
+
+	// Opcode stops before any instruction with the opcode byte Opcode.
+	Opcode
+
+	// Interrupt stops once the CPU has entered the handler for Event,
+	// which is core.EventIRQ, core.EventNMI or core.EventReset.
+	Interrupt
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Exec:
+		return "exec"
+	case Read:
+		return "read"
+	case Write:
+		return "write"
+	case Access:
+		return "access"
+	case Opcode:
+		return "opcode"
+	case Interrupt:
+		return "interrupt"
+	default:
+		return "unknown"
+	}
+}
+
+// Breakpoint describes a breakpoint or watchpoint.
+type Breakpoint struct {
+	ID   int // Assigned by Add
+	Kind Kind
+
+	Addr   uint16     // Exec address, or first address of a watch range
+	End    uint16     // Last address of a watch range; zero means Addr
+	Opcode byte       // Opcode for Kind Opcode
+	Event  core.Event // Interrupt for Kind Interrupt
+
+	// Condition, if set, must evaluate true for the breakpoint to count
+	// as hit. See the package documentation for the syntax.
+	Condition string
+
+	IgnoreCount int  // Hits to let pass before stopping
+	Temporary   bool // Delete after the first stop
+	Disabled    bool
+
+	Hits int // Times the breakpoint matched with its condition true
+
+	cond cond
+}
+
+func (b *Breakpoint) String() string {
+	var s string
+	switch b.Kind {
+	case Exec:
+		s = fmt.Sprintf("#%d exec $%04X", b.ID, b.Addr)
+	case Read, Write, Access:
+		s = fmt.Sprintf("#%d %v $%04X-$%04X", b.ID, b.Kind, b.Addr, b.End)
+	case Opcode:
+		s = fmt.Sprintf("#%d opcode $%02X", b.ID, b.Opcode)
+	case Interrupt:
+		s = fmt.Sprintf("#%d interrupt %v", b.ID, b.Event)
+	}
+	if b.Condition != "" {
+		s += " if " + b.Condition
+	}
+	return s
+}
+
+// covers reports whether a watchpoint matches an access.
+func (b *Breakpoint) covers(addr uint16, write bool) bool {
+	switch b.Kind {
+	case Read:
+		if write {
+			return false
+		}
+	case Write:
+		if !write {
+			return false
+		}
+	case Access:
+	default:
+		return false
+	}
+	return addr >= b.Addr && addr <= b.End
+}
+
+// Stop describes why execution stopped.
+type Stop struct {
+	Breakpoint *Breakpoint // Nil when stopped by Interrupt
+	PC         uint16      // PC after stopping
+
+	// Access is the access that triggered a watchpoint
+	Access struct {
+		Addr  uint16
+		Value byte
+		Write bool
+	}
+}
+
+func (s *Stop) String() string {
+	if s.Breakpoint == nil {
+		return fmt.Sprintf("interrupted at $%04X", s.PC)
+	}
+	switch s.Breakpoint.Kind {
+	case Read, Write, Access:
+		dir := "read"
+		if s.Access.Write {
+			dir = "write"
+		}
+		return fmt.Sprintf("watchpoint %d: %s $%02X at $%04X, PC $%04X",
+			s.Breakpoint.ID, dir, s.Access.Value, s.Access.Addr, s.PC)
+	}
+	return fmt.Sprintf("breakpoint %d at $%04X", s.Breakpoint.ID, s.PC)
+}
+
+// Debugger controls a CPU through breakpoints. It is not safe for
+// concurrent use, apart from Interrupt.
+type Debugger struct {
+	CPU core.CPU
+	Bus core.Bus // The watched bus the CPU is attached to
+
+	// OnStep, if set, is called after every instruction or interrupt
+	// sequence that Step and Continue execute
+	OnStep func(result core.StepResult)
+
+	inner  core.Bus
+	bps    map[int]*Breakpoint
+	nextID int
+
+	// Enabled breakpoints by how they are checked, rebuilt by update
+	execs   []*Breakpoint // Exec and Opcode
+	watches []*Breakpoint // Read, Write and Access
+	irqs    []*Breakpoint // Interrupt
+
+	pending     *Stop  // Breakpoint hit during the current instruction
+	resumePC    uint16 // Address whose execution breakpoints are skipped...
+	resuming    bool   // ...for the first instruction after Step or Continue
+	interrupted atomic.Bool
+
+	history *history   // Set by Record
+	replay  replayMode // What the hooks do while history is re-executed
+}
+
+// New creates a CPU of variant attached to bus through a watching bus.
+func New(variant core.Variant, bus core.Bus) (*Debugger, error) {
+	d := &Debugger{inner: bus, bps: make(map[int]*Breakpoint), nextID: 1}
+	d.Bus = &watchBus{d: d}
+
+	cpu, err := core.NewCPU(variant, d.Bus)
+	if err != nil {
+		return nil, err
+	}
+	d.CPU = cpu
+	cpu.SetBreakFunc(d.checkExec)
+	return d, nil
+}
+
+// Peek reads memory without triggering watchpoints.
+func (d *Debugger) Peek(addr uint16) byte {
+	return d.inner.Read(addr)
+}
+
+// Poke writes memory without triggering watchpoints. While recording, it
+// saves a keyframe so that the change is kept when stepping back; if that
+// fails, the write has still been made but the error is returned.
+func (d *Debugger) Poke(addr uint16, data byte) error {
+	d.inner.Write(addr, data)
+	if d.history != nil {
+		return d.Checkpoint()
+	}
+	return nil
+}
+
+// Add validates and installs a breakpoint and returns it with its ID set.
+func (d *Debugger) Add(bp Breakpoint) (*Breakpoint, error) {
+	switch bp.Kind {
+	case Exec, Opcode:
+	case Read, Write, Access:
+		if bp.End == 0 {
+			bp.End = bp.Addr
+		}
+		if bp.End < bp.Addr {
+			return nil, fmt.Errorf("debugger: watch range $%04X-$%04X is empty", bp.Addr, bp.End)
+		}
+	case Interrupt:
+		if bp.Event != core.EventIRQ && bp.Event != core.EventNMI && bp.Event != core.EventReset {
+			return nil, fmt.Errorf("debugger: %v is not an interrupt", bp.Event)
+		}
+	default:
+		return nil, fmt.Errorf("debugger: unknown breakpoint kind %d", bp.Kind)
+	}
+
+	if bp.Condition != "" {
+		c, err := compileCondition(bp.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("debugger: %w", err)
+		}
+		bp.cond = c
+	}
+
+	bp.ID = d.nextID
+	d.nextID++
+	d.bps[bp.ID] = &bp
+	d.update()
+	return &bp, nil
+}
+
+// Break adds an execution breakpoint at addr.
+func (d *Debugger) Break(addr uint16) *Breakpoint {
+	bp, _ := d.Add(Breakpoint{Kind: Exec, Addr: addr})
+	return bp
+}
+
+// Watch adds a watchpoint of kind Read, Write or Access on start..end.
+func (d *Debugger) Watch(kind Kind, start, end uint16) (*Breakpoint, error) {
+	if kind != Read && kind != Write && kind != Access {
+		return nil, fmt.Errorf("debugger: %v is not a watchpoint kind", kind)
+	}
+	return d.Add(Breakpoint{Kind: kind, Addr: start, End: end})
+}
+
+// Delete removes a breakpoint.
+func (d *Debugger) Delete(id int) error {
+	if _, ok := d.bps[id]; !ok {
+		return fmt.Errorf("debugger: no breakpoint %d", id)
+	}
+	delete(d.bps, id)
+	d.update()
+	return nil
+}
+
+// Enable enables or disables a breakpoint.
+func (d *Debugger) Enable(id int, enabled bool) error {
+	bp, ok := d.bps[id]
+	if !ok {
+		return fmt.Errorf("debugger: no breakpoint %d", id)
+	}
+	bp.Disabled = !enabled
+	d.update()
+	return nil
+}
+
+// Breakpoints returns the installed breakpoints ordered by ID.
+func (d *Debugger) Breakpoints() []*Breakpoint {
+	out := make([]*Breakpoint, 0, len(d.bps))
+	for _, bp := range d.bps {
+		out = append(out, bp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// update rebuilds the lists of enabled breakpoints. They are rebuilt rather
+// than reused, so a loop over the old list is unaffected.
+func (d *Debugger) update() {
+	d.execs, d.watches, d.irqs = nil, nil, nil
+	for _, bp := range d.Breakpoints() {
+		switch {
+		case bp.Disabled:
+		case bp.Kind == Exec || bp.Kind == Opcode:
+			d.execs = append(d.execs, bp)
+		case bp.Kind == Interrupt:
+			d.irqs = append(d.irqs, bp)
+		default:
+			d.watches = append(d.watches, bp)
+		}
+	}
+}
+
+// hit counts a match and reports whether the breakpoint stops execution.
+func (d *Debugger) hit(bp *Breakpoint) bool {
+	if bp.cond != nil && bp.cond(&env{regs: d.CPU.Registers(), bus: d.inner}) == 0 {
+		return false
+	}
+	bp.Hits++
+	if bp.Hits <= bp.IgnoreCount {
+		return false
+	}
+	if bp.Temporary {
+		d.Delete(bp.ID)
+	}
+	return true
+}
+
+// checkExec is the CPU's BreakFunc.
+func (d *Debugger) checkExec(pc uint16) bool {
+	if d.replay != live {
+		if d.replay == searching && len(d.execs) > 0 {
+			d.searchExec(pc)
+		}
+		return false
+	}
+	if len(d.execs) == 0 || d.resuming && pc == d.resumePC {
+		return false
+	}
+	opcode := d.inner.Read(pc)
+	for _, bp := range d.execs {
+		if bp.Kind == Exec && bp.Addr == pc || bp.Kind == Opcode && bp.Opcode == opcode {
+			if d.hit(bp) {
+				d.pending = &Stop{Breakpoint: bp}
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// access checks the watchpoints for a bus access.
+func (d *Debugger) access(addr uint16, value byte, write bool) {
+	switch d.replay {
+	case replaying:
+		return
+	case searching:
+		d.searchAccess(addr, value, write)
+		return
+	}
+	if d.pending != nil {
+		return
+	}
+	for _, bp := range d.watches {
+		if bp.covers(addr, write) && d.hit(bp) {
+			d.pending = &Stop{Breakpoint: bp}
+			d.pending.Access.Addr = addr
+			d.pending.Access.Value = value
+			d.pending.Access.Write = write
+			return
+		}
+	}
+}
+
+// Interrupt makes a running Continue return, or the next one if none is
+// running. It may be called from another goroutine.
+func (d *Debugger) Interrupt() {
+	d.interrupted.Store(true)
+}
+
+// Step executes one instruction or interrupt sequence. Execution
+// breakpoints at the current PC are stepped over. It returns a Stop if a
+// watchpoint or interrupt breakpoint triggered.
+func (d *Debugger) Step() (*Stop, error) {
+	d.resume()
+	return d.finish(d.CPU.StepInstruction())
+}
+
+// Continue runs until a breakpoint stops execution, the CPU returns an error
+// or Interrupt is called. Like Step, it first steps over execution
+// breakpoints at the current PC.
+func (d *Debugger) Continue() (*Stop, error) {
+	d.resume()
+	for !d.interrupted.Swap(false) {
+		stop, err := d.finish(d.CPU.StepInstruction())
+		if stop != nil || err != nil {
+			return stop, err
+		}
+	}
+	return &Stop{PC: d.CPU.Registers().PC}, nil
+}
+
+func (d *Debugger) resume() {
+	d.resumePC = d.CPU.Registers().PC
+	d.resuming = true
+}
+
+// finish turns the result of StepInstruction into a Stop.
+func (d *Debugger) finish(result core.StepResult, err error) (*Stop, error) {
+	stop := d.pending
+	d.pending = nil
+	d.resuming = false
+
+	if d.OnStep != nil && result.Event != core.EventBreakpoint {
+		d.OnStep(result)
+	}
+
+	if errors.Is(err, core.ErrBreakpoint) {
+		err = nil
+	}
+	if stop == nil && err == nil && result.Event != core.EventInstruction {
+		for _, bp := range d.irqs {
+			if bp.Event == result.Event && d.hit(bp) {
+				stop = &Stop{Breakpoint: bp}
+				break
+			}
+		}
+	}
+	if stop != nil {
+		stop.PC = d.CPU.Registers().PC
+	}
+	if d.history != nil && err == nil {
+		err = d.record()
+	}
+	return stop, err
+}
+
+// watchBus forwards to the debugger's bus and checks watchpoints.
+type watchBus struct {
+	d *Debugger
+}
+
+func (b *watchBus) Read(addr uint16) byte {
+	value := b.d.inner.Read(addr)
+	if len(b.d.watches) > 0 {
+		b.d.access(addr, value, false)
+	}
+	return value
+}
+
+func (b *watchBus) Write(addr uint16, data byte) {
+	b.d.inner.Write(addr, data)
+	if b.d.history != nil && b.d.replay != searching {
+		b.d.journal(addr, data)
+	}
+	if len(b.d.watches) > 0 {
+		b.d.access(addr, data, true)
+	}
+}
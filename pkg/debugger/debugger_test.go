@@ -0,0 +1,307 @@
+package debugger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/asm"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// SimpleRAM is a simple RAM implementation for testing
+type SimpleRAM struct {
+	memory [0x10000]byte
+}
+
+func (r *SimpleRAM) Read(addr uint16) byte {
+	return r.memory[addr]
+}
+
+func (r *SimpleRAM) Write(addr uint16, data byte) {
+	r.memory[addr] = data
+}
+
+// loop counts X down from 5, storing each value to $10 and reading $20.
+const loop = `
+        .org $0200
+start:  ldx #5
+next:   stx $10
+        lda $20
+        dex
+        bne next
+        jam
+`
+
+func newDebugger(t *testing.T, variant core.Variant, src string) (*Debugger, *asm.Program) {
+	t.Helper()
+	ram := &SimpleRAM{}
+	prog, err := asm.Assemble(variant, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog.Load(ram)
+
+	d, err := New(variant, ram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := d.CPU.Registers()
+	r.PC = prog.Symbols["start"]
+	d.CPU.SetRegisters(r)
+	return d, prog
+}
+
+func mustAdd(t *testing.T, d *Debugger, bp Breakpoint) *Breakpoint {
+	t.Helper()
+	added, err := d.Add(bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return added
+}
+
+func TestExecBreakpoint(t *testing.T) {
+	d, prog := newDebugger(t, core.VariantNMOS, loop)
+	bp := d.Break(prog.Symbols["next"])
+
+	for want := byte(5); want >= 1; want-- {
+		stop, err := d.Continue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stop.Breakpoint != bp || stop.PC != 0x0202 {
+			t.Fatalf("expected stop at $0202 on #%d, got %v", bp.ID, stop)
+		}
+		if x := d.CPU.Registers().X; x != want {
+			t.Errorf("expected X=%d, got %d", want, x)
+		}
+	}
+	if bp.Hits != 5 {
+		t.Errorf("expected 5 hits, got %d", bp.Hits)
+	}
+
+	if _, err := d.Continue(); !errors.Is(err, core.ErrJammed) {
+		t.Errorf("expected jam, got %v", err)
+	}
+}
+
+func TestConditionsAndIgnoreCount(t *testing.T) {
+	d, prog := newDebugger(t, core.VariantNMOS, loop)
+	mustAdd(t, d, Breakpoint{Kind: Exec, Addr: prog.Symbols["next"], Condition: "X < 4 && !Z"})
+
+	stop, err := d.Continue()
+	if err != nil || stop == nil {
+		t.Fatalf("expected stop, got %v, %v", stop, err)
+	}
+	if x := d.CPU.Registers().X; x != 3 {
+		t.Errorf("expected first stop with X=3, got %d", x)
+	}
+
+	d, prog = newDebugger(t, core.VariantNMOS, loop)
+	bp := mustAdd(t, d, Breakpoint{Kind: Exec, Addr: prog.Symbols["next"], IgnoreCount: 2})
+	d.Continue()
+	if x := d.CPU.Registers().X; x != 3 || bp.Hits != 3 {
+		t.Errorf("expected stop with X=3 on the third hit, got X=%d hits=%d", x, bp.Hits)
+	}
+}
+
+func TestTemporaryBreakpoint(t *testing.T) {
+	d, prog := newDebugger(t, core.VariantNMOS, loop)
+	mustAdd(t, d, Breakpoint{Kind: Exec, Addr: prog.Symbols["next"], Temporary: true})
+
+	if stop, _ := d.Continue(); stop == nil || stop.PC != 0x0202 {
+		t.Fatalf("expected stop at $0202, got %v", stop)
+	}
+	if n := len(d.Breakpoints()); n != 0 {
+		t.Errorf("expected the temporary breakpoint to be deleted, %d left", n)
+	}
+	if _, err := d.Continue(); !errors.Is(err, core.ErrJammed) {
+		t.Errorf("expected to run to the jam, got %v", err)
+	}
+}
+
+func TestWatchpoints(t *testing.T) {
+	d, _ := newDebugger(t, core.VariantNMOS, loop)
+	w, err := d.Watch(Write, 0x0010, 0x001F)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := d.Watch(Read, 0x0020, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Watchpoints stop after the instruction that made the access
+	stop, _ := d.Continue()
+	if stop.Breakpoint != w || !stop.Access.Write || stop.Access.Addr != 0x10 || stop.Access.Value != 5 || stop.PC != 0x0204 {
+		t.Errorf("unexpected write stop: %v", stop)
+	}
+	stop, _ = d.Continue()
+	if stop.Breakpoint != r || stop.Access.Write || stop.Access.Addr != 0x20 || stop.PC != 0x0206 {
+		t.Errorf("unexpected read stop: %v", stop)
+	}
+
+	// Conditions on watchpoints see the registers during the access
+	d, _ = newDebugger(t, core.VariantNMOS, loop)
+	mustAdd(t, d, Breakpoint{Kind: Access, Addr: 0x10, Condition: "X == 2"})
+	d.Continue()
+	if got := d.CPU.Registers().X; got != 2 {
+		t.Errorf("expected stop with X=2, got %d", got)
+	}
+}
+
+// TestWatchpointDummyRead pins that a read watchpoint fires on a dummy
+// read: the NMOS 6502 reads $0210 before fixing up LDA $02F0,X to $0310.
+func TestWatchpointDummyRead(t *testing.T) {
+	d, _ := newDebugger(t, core.VariantNMOS, `
+        .org $0200
+start:  ldx #$20
+        lda $02f0,x
+        jam
+`)
+	r, err := d.Watch(Read, 0x0210, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop, _ := d.Continue()
+	if stop == nil || stop.Breakpoint != r || stop.Access.Addr != 0x0210 || stop.PC != 0x0205 {
+		t.Errorf("expected a stop after the LDA on the read of $0210, got %v", stop)
+	}
+}
+
+func TestOpcodeBreakpoint(t *testing.T) {
+	d, _ := newDebugger(t, core.VariantNMOS, loop)
+	mustAdd(t, d, Breakpoint{Kind: Opcode, Opcode: 0xCA, Condition: "[$10] == 1"}) // DEX
+
+	stop, _ := d.Continue()
+	if stop == nil || stop.PC != 0x0206 || d.CPU.Registers().X != 1 {
+		t.Errorf("expected stop before DEX with X=1, got %v", stop)
+	}
+}
+
+func TestInterruptBreakpoint(t *testing.T) {
+	d, _ := newDebugger(t, core.VariantWDC65C02, `
+        .org $0200
+start:  cli
+:       bra :-
+        .org $0300
+irq:    rti
+        .org $FFFE
+        .word irq
+`)
+	mustAdd(t, d, Breakpoint{Kind: Interrupt, Event: core.EventIRQ})
+
+	// Run a while, then raise an IRQ and continue into the handler
+	for range 5 {
+		if stop, err := d.Step(); stop != nil || err != nil {
+			t.Fatalf("unexpected stop %v, %v", stop, err)
+		}
+	}
+	d.CPU.TriggerIRQ()
+	stop, err := d.Continue()
+	if err != nil || stop == nil || stop.PC != 0x0300 {
+		t.Errorf("expected stop at the handler, got %v, %v", stop, err)
+	}
+
+	if _, err := d.Add(Breakpoint{Kind: Interrupt, Event: core.EventWaiting}); err == nil {
+		t.Error("expected an error for a non-interrupt event")
+	}
+}
+
+func TestStepOverBreakpoint(t *testing.T) {
+	d, prog := newDebugger(t, core.VariantNMOS, loop)
+	bp := d.Break(prog.Symbols["start"])
+
+	// Stepping from a breakpoint executes it without counting a hit
+	if stop, err := d.Step(); stop != nil || err != nil {
+		t.Fatalf("unexpected stop %v, %v", stop, err)
+	}
+	if pc := d.CPU.Registers().PC; pc != 0x0202 || bp.Hits != 0 {
+		t.Errorf("expected PC $0202 with no hits, got $%04X and %d", pc, bp.Hits)
+	}
+
+	if err := d.Enable(bp.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Delete(bp.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Delete(bp.ID); err == nil {
+		t.Error("expected an error deleting twice")
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	d, _ := newDebugger(t, core.VariantNMOS, "        .org $0200\nstart:  jmp start")
+
+	done := make(chan *Stop)
+	go func() {
+		stop, _ := d.Continue()
+		done <- stop
+	}()
+	d.Interrupt()
+	if stop := <-done; stop.Breakpoint != nil {
+		t.Errorf("expected an interrupted stop, got %v", stop)
+	}
+}
+
+func TestConditions(t *testing.T) {
+	ram := &SimpleRAM{}
+	ram.memory[0x10] = 0x80
+	ram.memory[0x01FE] = 0x12
+	e := &env{
+		regs: core.Registers{PC: 0xC000, SP: 0xFD, A: 0xFF, X: 4, Y: 0, Status: core.FlagCarry | core.FlagUnused},
+		bus:  ram,
+	}
+
+	tests := []struct {
+		src  string
+		want int
+	}{
+		{"A == $FF && X > 3", 1},
+		{"A == $FF && X > 4", 0},
+		{"a == 255 || x == 0", 1},
+		{"C && !Z", 1},
+		{"[$10] & $80", 0x80},
+		{"[$10] & %10000000 != 0", 1},
+		{"[SP + $101] == $12", 1},
+		{"PC >= 0xC000", 1},
+		{"(X + 1) * 2", 10},
+		{"X << 2 | 1", 17},
+		{"-1 == ~0", 1},
+		{"P", 0x21},
+		{"7 % 4 / 0", 0},
+	}
+	for _, tt := range tests {
+		c, err := compileCondition(tt.src)
+		if err != nil {
+			t.Errorf("%q: %v", tt.src, err)
+			continue
+		}
+		if got := c(e); got != tt.want {
+			t.Errorf("%q: got %d, want %d", tt.src, got, tt.want)
+		}
+	}
+
+	for _, src := range []string{"", "A ==", "Q > 1", "(A", "[A", "A @ 1", "A B"} {
+		if _, err := compileCondition(src); err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
+
+func TestOnStep(t *testing.T) {
+	d, prog := newDebugger(t, core.VariantNMOS, loop)
+	d.Break(prog.Symbols["next"] + 4) // DEX
+
+	var pcs []uint16
+	d.OnStep = func(r core.StepResult) { pcs = append(pcs, r.PC) }
+	d.Step()
+	d.Continue()
+
+	// The stop at the breakpoint itself is not reported
+	if len(pcs) != 3 || pcs[0] != 0x0200 || pcs[1] != 0x0202 || pcs[2] != 0x0204 {
+		t.Errorf("unexpected steps %04X", pcs)
+	}
+}
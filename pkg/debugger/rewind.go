@@ -0,0 +1,384 @@
+package debugger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/savestate"
+)
+
+// Rewinding
+//
+// While recording, the debugger saves a keyframe of the whole machine every
+// so many cycles and journals every bus write. Going back in time restores
+// the last keyframe before the target and runs forward to it again, so the
+// machine must be deterministic: its state has to be entirely in the CPU and
+// the sections registered with the savestate.Machine. Interrupts raised by
+// devices on the bus replay correctly; TriggerIRQ calls made by the host
+// between steps do not.
+//
+// Moving back discards the recorded future. Running forward again records
+// it afresh, so state changed while stopped, through Poke or after a call to
+// Checkpoint, takes effect from that point on.
+
+// ErrHistoryStart is returned when there is no recorded history to go back
+// to.
+var ErrHistoryStart = errors.New("debugger: at the start of the recorded history")
+
+// ErrNotRecording is returned by the rewind methods when Record has not
+// been called.
+var ErrNotRecording = errors.New("debugger: not recording")
+
+// replayMode selects what the debugger's hooks do while history is being
+// executed again.
+type replayMode int
+
+const (
+	live      replayMode = iota // Normal execution
+	replaying                   // Running forward to a point in history
+	searching                   // Looking for breakpoint hits and instruction starts
+)
+
+// WriteRecord is a bus write recorded in the journal.
+type WriteRecord struct {
+	Cycle uint64 // Clock cycle of the write
+	Addr  uint16
+	Value byte
+
+	// PC is the address of the instruction that made the write. For the
+	// stack writes of an interrupt sequence it is the instruction that was
+	// interrupted.
+	PC uint16
+}
+
+func (w WriteRecord) String() string {
+	return fmt.Sprintf("$%02X to $%04X by $%04X at cycle %d", w.Value, w.Addr, w.PC, w.Cycle)
+}
+
+type keyframe struct {
+	cycle uint64
+	state []byte
+}
+
+// history is the recording made by Record.
+type history struct {
+	m        *savestate.Machine
+	interval uint64
+	keep     int
+
+	keyframes []keyframe    // Oldest first
+	writes    []WriteRecord // Oldest first
+
+	// While searching, the latest breakpoint hit before limit
+	limit uint64
+	hit   *Stop
+	hitAt uint64
+}
+
+// Record starts recording execution so that it can be stepped backwards. m
+// saves the machine the debugger's CPU belongs to: its CPU must be d.CPU,
+// with the memory and devices registered. A keyframe is saved every
+// interval cycles, and only the last keep are kept, or all of them if keep
+// is zero. Recording starts with a keyframe of the current state.
+//
+// Only execution through Step and Continue is recorded.
+func (d *Debugger) Record(m *savestate.Machine, interval uint64, keep int) error {
+	if m.CPU != d.CPU {
+		return errors.New("debugger: Record needs a machine built on the debugger's CPU")
+	}
+	if interval == 0 {
+		return errors.New("debugger: keyframe interval is zero")
+	}
+	d.history = &history{m: m, interval: interval, keep: keep}
+	return d.Checkpoint()
+}
+
+// StopRecording discards the recorded history.
+func (d *Debugger) StopRecording() {
+	d.history = nil
+}
+
+// Checkpoint saves a keyframe of the current state. Call it after changing
+// registers or devices while recording, so that going back to a later
+// point keeps the change.
+func (d *Debugger) Checkpoint() error {
+	h := d.history
+	if h == nil {
+		return ErrNotRecording
+	}
+
+	var b bytes.Buffer
+	if err := h.m.Save(&b); err != nil {
+		return fmt.Errorf("debugger: %w", err)
+	}
+	k := keyframe{cycle: d.cycle(), state: b.Bytes()}
+	if n := len(h.keyframes); n > 0 && h.keyframes[n-1].cycle == k.cycle {
+		h.keyframes[n-1] = k
+	} else {
+		h.keyframes = append(h.keyframes, k)
+	}
+
+	if h.keep > 0 && len(h.keyframes) > h.keep {
+		h.keyframes = h.keyframes[len(h.keyframes)-h.keep:]
+		start := h.keyframes[0].cycle
+		i := sort.Search(len(h.writes), func(i int) bool { return h.writes[i].Cycle >= start })
+		h.writes = h.writes[i:]
+	}
+	return nil
+}
+
+// record is called after each instruction executed live, and saves a
+// keyframe when one is due.
+func (d *Debugger) record() error {
+	h := d.history
+	if d.cycle()-h.keyframes[len(h.keyframes)-1].cycle < h.interval {
+		return nil
+	}
+	return d.Checkpoint()
+}
+
+// journal records a live or replayed bus write.
+func (d *Debugger) journal(addr uint16, data byte) {
+	d.history.writes = append(d.history.writes, WriteRecord{
+		Cycle: d.CPU.CurrentCycle(),
+		Addr:  addr,
+		Value: data,
+		PC:    d.CPU.Snapshot().InstructionPC,
+	})
+}
+
+// LastWrite returns the most recent recorded write to addr.
+func (d *Debugger) LastWrite(addr uint16) (WriteRecord, bool) {
+	if d.history == nil {
+		return WriteRecord{}, false
+	}
+	writes := d.history.writes
+	for i := len(writes) - 1; i >= 0; i-- {
+		if writes[i].Addr == addr {
+			return writes[i], true
+		}
+	}
+	return WriteRecord{}, false
+}
+
+// StepBack goes back to the start of the previous instruction or
+// interrupt sequence. If execution is partway through an instruction, it
+// goes back to the start of that instruction.
+func (d *Debugger) StepBack() error {
+	now, i, err := d.before()
+	if err != nil {
+		return err
+	}
+
+	target := d.history.keyframes[i].cycle
+	err = d.scan(i, now, func(start uint64, _ core.StepResult) {
+		target = start
+	})
+	if err != nil {
+		return err
+	}
+	return d.seek(target)
+}
+
+// StepBackCycle goes back one clock cycle.
+func (d *Debugger) StepBackCycle() error {
+	now, _, err := d.before()
+	if err != nil {
+		return err
+	}
+	return d.seek(now - 1)
+}
+
+// ReverseContinue runs backwards to the most recent point at which Continue
+// would have stopped: before an instruction with an execution or opcode
+// breakpoint, or after one that triggered a watchpoint or an interrupt
+// breakpoint. Conditions are checked; ignore counts are not, and hit counts
+// are left unchanged.
+//
+// If no breakpoint was hit, it stops at the start of the recorded history
+// and returns ErrHistoryStart.
+func (d *Debugger) ReverseContinue() (*Stop, error) {
+	now, last, err := d.before()
+	if err != nil {
+		return nil, err
+	}
+	h := d.history
+	h.limit = now
+	defer func() { h.limit, h.hit = 0, nil }()
+
+	// Search the stretch between each pair of keyframes, latest first
+	for i := last; i >= 0; i-- {
+		end := now
+		if i < last {
+			end = h.keyframes[i+1].cycle
+		}
+		err := d.scan(i, end, func(_ uint64, result core.StepResult) {
+			d.searchAfter(result)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if stop := h.hit; stop != nil {
+			if err := d.seek(h.hitAt); err != nil {
+				return nil, err
+			}
+			stop.PC = d.CPU.Registers().PC
+			return stop, nil
+		}
+	}
+
+	if err := d.seek(h.keyframes[0].cycle); err != nil {
+		return nil, err
+	}
+	return nil, ErrHistoryStart
+}
+
+// before returns the current cycle and the latest keyframe before it.
+func (d *Debugger) before() (now uint64, prev int, err error) {
+	h := d.history
+	if h == nil {
+		return 0, 0, ErrNotRecording
+	}
+	now = d.cycle()
+	i := sort.Search(len(h.keyframes), func(i int) bool { return h.keyframes[i].cycle >= now })
+	if i == 0 {
+		return 0, 0, ErrHistoryStart
+	}
+	return now, i - 1, nil
+}
+
+// load restores keyframe i.
+func (d *Debugger) load(i int) error {
+	if err := d.history.m.Load(bytes.NewReader(d.history.keyframes[i].state)); err != nil {
+		return fmt.Errorf("debugger: %w", err)
+	}
+	return nil
+}
+
+// scan restores keyframe i and runs instructions with the hooks searching,
+// until the cycle count reaches end. after is called with the cycle each
+// instruction started at and its result.
+func (d *Debugger) scan(i int, end uint64, after func(start uint64, result core.StepResult)) error {
+	if err := d.load(i); err != nil {
+		return err
+	}
+	// A keyframe saved while stopped at a breakpoint would skip the check
+	// before its first instruction; installing the hook again clears that
+	d.CPU.SetBreakFunc(d.checkExec)
+	d.replay = searching
+	defer func() { d.replay = live }()
+
+	for start := d.cycle(); start < end; {
+		result, _ := d.CPU.StepInstruction()
+		next := d.cycle()
+		if next == start {
+			break // Stopped by STP
+		}
+		after(start, result)
+		start = next
+	}
+	return nil
+}
+
+// seek moves to cycle target, which must be no earlier than the first
+// keyframe, by restoring the last keyframe before it and running forward
+// one bus cycle at a time. Everything recorded after that keyframe is
+// discarded and recorded again, and the journal ends at target, so writes
+// made later in an instruction that target falls inside are forgotten.
+func (d *Debugger) seek(target uint64) error {
+	h := d.history
+	i := sort.Search(len(h.keyframes), func(i int) bool { return h.keyframes[i].cycle > target }) - 1
+	if err := d.load(i); err != nil {
+		return err
+	}
+	start := h.keyframes[i].cycle
+	h.keyframes = h.keyframes[:i+1]
+	w := sort.Search(len(h.writes), func(w int) bool { return h.writes[w].Cycle >= start })
+	h.writes = h.writes[:w]
+
+	d.replay = replaying
+	defer func() { d.replay = live }()
+	for cycle := start; cycle < target; {
+		d.CPU.Step()
+		next := d.cycle()
+		if next == cycle {
+			break
+		}
+		cycle = next
+	}
+	w = sort.Search(len(h.writes), func(w int) bool { return h.writes[w].Cycle >= target })
+	h.writes = h.writes[:w]
+	d.pending = nil
+	return nil
+}
+
+// searchExec records an execution or opcode breakpoint that would stop
+// before the instruction at pc.
+func (d *Debugger) searchExec(pc uint16) {
+	h := d.history
+	at := d.cycle()
+	if at >= h.limit {
+		return
+	}
+	opcode := d.inner.Read(pc)
+	for _, bp := range d.execs {
+		if (bp.Kind == Exec && bp.Addr == pc || bp.Kind == Opcode && bp.Opcode == opcode) && d.matches(bp) {
+			h.hit, h.hitAt = &Stop{Breakpoint: bp}, at
+			return
+		}
+	}
+}
+
+// searchAccess notes the first watchpoint an instruction triggers, which
+// searchAfter records once the instruction has finished.
+func (d *Debugger) searchAccess(addr uint16, value byte, write bool) {
+	if d.pending != nil {
+		return
+	}
+	for _, bp := range d.watches {
+		if bp.covers(addr, write) && d.matches(bp) {
+			d.pending = &Stop{Breakpoint: bp}
+			d.pending.Access.Addr = addr
+			d.pending.Access.Value = value
+			d.pending.Access.Write = write
+			return
+		}
+	}
+}
+
+// searchAfter records a watchpoint or interrupt breakpoint triggered by the
+// instruction or interrupt sequence that has just run.
+func (d *Debugger) searchAfter(result core.StepResult) {
+	h := d.history
+	stop := d.pending
+	d.pending = nil
+	at := d.cycle()
+	if at >= h.limit {
+		return
+	}
+	if stop == nil && result.Event != core.EventInstruction {
+		for _, bp := range d.irqs {
+			if bp.Event == result.Event && d.matches(bp) {
+				stop = &Stop{Breakpoint: bp}
+				break
+			}
+		}
+	}
+	if stop != nil {
+		h.hit, h.hitAt = stop, at
+	}
+}
+
+// matches reports whether a breakpoint's condition holds, without counting
+// a hit.
+func (d *Debugger) matches(bp *Breakpoint) bool {
+	return bp.cond == nil || bp.cond(&env{regs: d.CPU.Registers(), bus: d.inner}) != 0
+}
+
+// cycle returns the CPU's cycle count.
+func (d *Debugger) cycle() uint64 {
+	return d.CPU.Snapshot().TotalCycles
+}
@@ -0,0 +1,301 @@
+package debugger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/asm"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/savestate"
+)
+
+// machineState is everything that going back in time has to restore.
+type machineState struct {
+	cpu    core.Snapshot
+	memory [0x10000]byte
+}
+
+// newRecording returns a debugger for loop that is recording with a
+// keyframe every interval cycles.
+func newRecording(t *testing.T, interval uint64, keep int) (*Debugger, *asm.Program, *SimpleRAM) {
+	t.Helper()
+	ram := &SimpleRAM{}
+	prog := asm.MustAssemble(core.VariantNMOS, loop)
+	prog.Load(ram)
+
+	d, err := New(core.VariantNMOS, ram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := d.CPU.Registers()
+	r.PC = prog.Symbols["start"]
+	d.CPU.SetRegisters(r)
+
+	m := savestate.New(d.CPU)
+	m.Register("ram", savestate.Memory(ram.memory[:]))
+	if err := d.Record(m, interval, keep); err != nil {
+		t.Fatal(err)
+	}
+	return d, prog, ram
+}
+
+// runLoop steps through loop up to the JAM, returning the state before each
+// instruction and at the end.
+func runLoop(t *testing.T, d *Debugger, ram *SimpleRAM) []machineState {
+	t.Helper()
+	var states []machineState
+	for range 21 {
+		states = append(states, machineState{d.CPU.Snapshot(), ram.memory})
+		if _, err := d.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return append(states, machineState{d.CPU.Snapshot(), ram.memory})
+}
+
+func TestStepBack(t *testing.T) {
+	d, _, ram := newRecording(t, 10, 0)
+	states := runLoop(t, d, ram)
+
+	for i := len(states) - 2; i >= 0; i-- {
+		if err := d.StepBack(); err != nil {
+			t.Fatal(err)
+		}
+		if got := (machineState{d.CPU.Snapshot(), ram.memory}); got != states[i] {
+			t.Fatalf("state after stepping back to instruction %d:\ngot  %+v\nwant %+v", i, got.cpu, states[i].cpu)
+		}
+	}
+	if err := d.StepBack(); !errors.Is(err, ErrHistoryStart) {
+		t.Errorf("expected ErrHistoryStart, got %v", err)
+	}
+
+	// Running forward again reaches the same end
+	runLoop(t, d, ram)
+	if got := d.CPU.Snapshot(); got != states[len(states)-1].cpu {
+		t.Errorf("state after replaying:\ngot  %+v\nwant %+v", got, states[len(states)-1].cpu)
+	}
+}
+
+func TestStepBackCycle(t *testing.T) {
+	d, _, ram := newRecording(t, 10, 0)
+	states := runLoop(t, d, ram)
+	end := states[len(states)-1].cpu
+
+	// BNE taken takes 3 cycles; stepping back one leaves 1 to run
+	if err := d.StepBackCycle(); err != nil {
+		t.Fatal(err)
+	}
+	s := d.CPU.Snapshot()
+	if s.TotalCycles != end.TotalCycles-1 || s.Cycles != 1 {
+		t.Fatalf("expected cycle %d with 1 cycle left, got cycle %d with %d left", end.TotalCycles-1, s.TotalCycles, s.Cycles)
+	}
+
+	d.CPU.Step()
+	if got := d.CPU.Snapshot(); got != end {
+		t.Errorf("state after stepping forward a cycle:\ngot  %+v\nwant %+v", got, end)
+	}
+}
+
+func TestReverseContinue(t *testing.T) {
+	d, prog, ram := newRecording(t, 10, 0)
+	runLoop(t, d, ram)
+
+	bp := d.Break(prog.Symbols["next"])
+	for want := byte(1); want <= 5; want++ {
+		stop, err := d.ReverseContinue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stop.Breakpoint != bp || stop.PC != prog.Symbols["next"] {
+			t.Fatalf("expected stop on #%d, got %v", bp.ID, stop)
+		}
+		if x := d.CPU.Registers().X; x != want {
+			t.Errorf("expected X=%d, got %d", want, x)
+		}
+	}
+	if bp.Hits != 0 {
+		t.Errorf("reverse execution counted %d hits", bp.Hits)
+	}
+
+	if _, err := d.ReverseContinue(); !errors.Is(err, ErrHistoryStart) {
+		t.Fatalf("expected ErrHistoryStart, got %v", err)
+	}
+	if pc := d.CPU.Registers().PC; pc != prog.Symbols["start"] {
+		t.Errorf("expected PC at start of history $%04X, got $%04X", prog.Symbols["start"], pc)
+	}
+}
+
+// TestReverseContinueFromBreakpointKeyframe checks that a breakpoint is
+// found at a keyframe that was saved while stopped on it, here by a Poke.
+func TestReverseContinueFromBreakpointKeyframe(t *testing.T) {
+	d, prog, _ := newRecording(t, 1000, 0)
+	bp := d.Break(prog.Symbols["next"])
+	for range 2 {
+		if _, err := d.Continue(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Poke(0x0300, 0x42); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Continue(); err != nil {
+		t.Fatal(err)
+	}
+
+	stop, err := d.ReverseContinue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stop.Breakpoint != bp || stop.PC != prog.Symbols["next"] {
+		t.Fatalf("expected stop on #%d, got %v", bp.ID, stop)
+	}
+	if x := d.CPU.Registers().X; x != 4 {
+		t.Errorf("expected to stop where the poke was made with X=4, got X=%d", x)
+	}
+}
+
+func TestReverseContinueWatchpoint(t *testing.T) {
+	d, _, ram := newRecording(t, 10, 0)
+	runLoop(t, d, ram)
+
+	mustAdd(t, d, Breakpoint{Kind: Write, Addr: 0x10, Condition: "X == 3"})
+	stop, err := d.ReverseContinue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stop.Access.Write || stop.Access.Addr != 0x10 || stop.Access.Value != 3 {
+		t.Errorf("expected write of 3 to $10, got %v", stop)
+	}
+	if stop.PC != 0x0204 || ram.memory[0x10] != 3 {
+		t.Errorf("expected to stop after the STX, got PC $%04X with $10 = %d", stop.PC, ram.memory[0x10])
+	}
+}
+
+func TestLastWrite(t *testing.T) {
+	d, prog, ram := newRecording(t, 10, 0)
+	runLoop(t, d, ram)
+
+	w, ok := d.LastWrite(0x10)
+	if !ok || w.Value != 1 || w.PC != prog.Symbols["next"] {
+		t.Fatalf("expected STX at $%04X writing 1, got %v", prog.Symbols["next"], w)
+	}
+	if _, ok := d.LastWrite(0x20); ok {
+		t.Error("expected no write to $20")
+	}
+
+	// Going back forgets the writes that have been undone
+	for range 4 {
+		d.StepBack()
+	}
+	if w, _ := d.LastWrite(0x10); w.Value != 2 {
+		t.Errorf("expected last write of 2 after stepping back, got %v", w)
+	}
+}
+
+func TestLastWriteStepBackCycle(t *testing.T) {
+	d, _, ram := newRecording(t, 10, 0)
+	runLoop(t, d, ram)
+
+	// Stepping back a cycle at a time into the STX forgets its write as
+	// soon as it is undone
+	for range 40 {
+		if err := d.StepBackCycle(); err != nil {
+			t.Fatal(err)
+		}
+		w, ok := d.LastWrite(0x10)
+		if ok && w.Value != ram.memory[0x10] || !ok && ram.memory[0x10] != 0 {
+			t.Fatalf("at cycle %d, last write %v but $10 = %d", d.CPU.Snapshot().TotalCycles, w, ram.memory[0x10])
+		}
+	}
+}
+
+func TestKeyframeLimit(t *testing.T) {
+	d, _, ram := newRecording(t, 10, 2)
+	states := runLoop(t, d, ram)
+
+	// Only the last two keyframes are kept, so history starts partway in
+	steps := 0
+	for d.StepBack() == nil {
+		steps++
+	}
+	start := d.CPU.Snapshot().TotalCycles
+	if steps == 0 || steps >= len(states)-1 || start == states[0].cpu.TotalCycles {
+		t.Errorf("expected history to be cut short, stepped back %d times to cycle %d", steps, start)
+	}
+}
+
+func TestPokeWhileRecording(t *testing.T) {
+	d, _, ram := newRecording(t, 1000, 0)
+	step := func(n int) {
+		for range n {
+			if _, err := d.Step(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The poke is kept back to the point it was made, and no further
+	step(5)
+	if err := d.Poke(0x0300, 0x42); err != nil {
+		t.Fatal(err)
+	}
+	step(5)
+	for range 5 {
+		d.StepBack()
+	}
+	if ram.memory[0x0300] != 0x42 {
+		t.Error("poke undone by stepping back to where it was made")
+	}
+	d.StepBack()
+	if ram.memory[0x0300] != 0 {
+		t.Error("poke kept after stepping back past it")
+	}
+}
+
+// brokenDevice is a section that stops saving once broken is set.
+type brokenDevice struct{ broken bool }
+
+func (b *brokenDevice) MarshalBinary() ([]byte, error) {
+	if b.broken {
+		return nil, errors.New("device broken")
+	}
+	return nil, nil
+}
+
+func (b *brokenDevice) UnmarshalBinary([]byte) error { return nil }
+
+func TestPokeCheckpointError(t *testing.T) {
+	ram := &SimpleRAM{}
+	asm.MustAssemble(core.VariantNMOS, loop).Load(ram)
+	d, err := New(core.VariantNMOS, ram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := &brokenDevice{}
+	m := savestate.New(d.CPU)
+	m.Register("ram", savestate.Memory(ram.memory[:]))
+	m.Register("dev", dev)
+	if err := d.Record(m, 1000, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	dev.broken = true
+	if err := d.Poke(0x0300, 0x42); err == nil {
+		t.Error("expected the failed keyframe to be reported")
+	}
+	if ram.memory[0x0300] != 0x42 {
+		t.Error("poke not written")
+	}
+}
+
+func TestRecordErrors(t *testing.T) {
+	d, _ := newDebugger(t, core.VariantNMOS, loop)
+	if err := d.StepBack(); !errors.Is(err, ErrNotRecording) {
+		t.Errorf("expected ErrNotRecording, got %v", err)
+	}
+
+	other, _ := newDebugger(t, core.VariantNMOS, loop)
+	if err := d.Record(savestate.New(other.CPU), 10, 0); err == nil {
+		t.Error("expected an error recording another debugger's CPU")
+	}
+}
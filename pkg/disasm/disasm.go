@@ -0,0 +1,258 @@
+// Package disasm turns 6502 and 65C02 machine code back into assembly.
+//
+// Instructions are decoded from a core.Bus using the same opcode tables the
+// CPUs execute, so undocumented NMOS opcodes and the 65C02 additions are
+// shown exactly as they run. Operands use standard syntax:
+//
+//	LDA #$10        immediate
+//	STA $20         zero page
+//	LDA ($20),Y     indirect indexed
+//	JMP ($1234)     indirect
+//	LDA ($20)       65C02 zero page indirect
+//	JMP ($1234,X)   65C02 absolute indexed indirect
+//	BBR0 $20,$0213  65C02 bit branch: zero page address and branch target
+//
+// Example usage:
+//
+//	d, err := disasm.New(bus, core.VariantNMOS)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	d.Symbols = disasm.SymbolTable{0xFFD2: "CHROUT"}
+//	for _, ins := range d.DecodeRange(0x0200, 10) {
+//	    fmt.Println(ins.Format())
+//	}
+package disasm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/mos6502"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/wdc65c02"
+)
+
+// SymbolTable maps addresses to the labels that replace them in operands.
+type SymbolTable map[uint16]string
+
+// Instruction is one decoded instruction.
+type Instruction struct {
+	Addr     uint16 // Address of the opcode
+	Bytes    []byte // Opcode and operand bytes
+	Mnemonic string
+	Operand  string // Operand text, empty for implied instructions
+	Mode     core.AddressingMode
+	Illegal  bool // Undocumented NMOS opcode or reserved 65C02 NOP
+
+	// Target is the effective address the instruction reads, writes or
+	// jumps to, or the destination of a branch. HasTarget is false when it
+	// cannot be known without register values (see Disassembler.Registers)
+	// and for immediate and implied instructions.
+	Target    uint16
+	HasTarget bool
+}
+
+// Len returns the instruction length in bytes.
+func (i Instruction) Len() int {
+	return len(i.Bytes)
+}
+
+// String returns the instruction as assembly source, e.g. "LDA ($20),Y".
+func (i Instruction) String() string {
+	if i.Operand == "" {
+		return i.Mnemonic
+	}
+	return i.Mnemonic + " " + i.Operand
+}
+
+// Format returns a listing line with the address and raw bytes, e.g.
+// "0200  B1 20     LDA ($20),Y".
+func (i Instruction) Format() string {
+	hex := make([]string, len(i.Bytes))
+	for n, b := range i.Bytes {
+		hex[n] = fmt.Sprintf("%02X", b)
+	}
+	return fmt.Sprintf("%04X  %-8s  %s", i.Addr, strings.Join(hex, " "), i.String())
+}
+
+var tables = map[core.Variant]*[256]core.Opcode{}
+
+func init() {
+	nmos := mos6502.Opcodes()
+	wdc := wdc65c02.Opcodes()
+	tables[core.VariantNMOS] = &nmos
+	tables[core.VariantWDC65C02] = &wdc
+}
+
+// Disassembler decodes instructions for one CPU variant.
+type Disassembler struct {
+	bus     core.Bus
+	variant core.Variant
+	table   *[256]core.Opcode
+
+	// Symbols, if set, replaces operand addresses that have a label.
+	Symbols SymbolTable
+
+	// Registers, if set, is used to resolve Target for indexed and indirect
+	// operands. Resolving indirect operands reads the pointer from the bus.
+	Registers *core.Registers
+}
+
+// New returns a disassembler that reads code from bus.
+func New(bus core.Bus, variant core.Variant) (*Disassembler, error) {
+	table, ok := tables[variant]
+	if !ok {
+		return nil, fmt.Errorf("disasm: unsupported variant %v", variant)
+	}
+	return &Disassembler{bus: bus, variant: variant, table: table}, nil
+}
+
+// Decode decodes the instruction at addr. Every opcode decodes to
+// something; bytes the variant does not execute are shown as ".byte".
+func (d *Disassembler) Decode(addr uint16) Instruction {
+	opcode := d.bus.Read(addr)
+	op := &d.table[opcode]
+
+	if op.Handler == nil {
+		return Instruction{
+			Addr:     addr,
+			Bytes:    []byte{opcode},
+			Mnemonic: ".byte",
+			Operand:  fmt.Sprintf("$%02X", opcode),
+			Illegal:  true,
+		}
+	}
+
+	ins := Instruction{
+		Addr:     addr,
+		Bytes:    make([]byte, op.Length),
+		Mnemonic: op.Mnemonic,
+		Mode:     op.Mode,
+		Illegal:  op.Illegal,
+	}
+	for n := range ins.Bytes {
+		ins.Bytes[n] = d.bus.Read(addr + uint16(n))
+	}
+	d.decodeOperand(&ins)
+	return ins
+}
+
+// DecodeRange decodes count consecutive instructions starting at addr.
+func (d *Disassembler) DecodeRange(addr uint16, count int) []Instruction {
+	out := make([]Instruction, 0, count)
+	for n := 0; n < count; n++ {
+		ins := d.Decode(addr)
+		out = append(out, ins)
+		addr += uint16(ins.Len())
+	}
+	return out
+}
+
+// decodeOperand fills in the operand text and target.
+func (d *Disassembler) decodeOperand(ins *Instruction) {
+	var b1, b2 byte
+	if len(ins.Bytes) > 1 {
+		b1 = ins.Bytes[1]
+	}
+	if len(ins.Bytes) > 2 {
+		b2 = ins.Bytes[2]
+	}
+	zp := uint16(b1)
+	abs := uint16(b2)<<8 | uint16(b1)
+	next := ins.Addr + uint16(len(ins.Bytes))
+	regs := d.Registers
+
+	switch ins.Mode {
+	case core.Implied:
+		// BRK and the 1-byte NOPs have no operand
+	case core.Accumulator:
+		ins.Operand = "A"
+	case core.Immediate:
+		ins.Operand = fmt.Sprintf("#$%02X", b1)
+	case core.ZeroPage:
+		ins.Operand = d.zeroPage(zp)
+		ins.Target, ins.HasTarget = zp, true
+	case core.ZeroPageX:
+		ins.Operand = d.zeroPage(zp) + ",X"
+		if regs != nil {
+			ins.Target, ins.HasTarget = (zp+uint16(regs.X))&0x00FF, true
+		}
+	case core.ZeroPageY:
+		ins.Operand = d.zeroPage(zp) + ",Y"
+		if regs != nil {
+			ins.Target, ins.HasTarget = (zp+uint16(regs.Y))&0x00FF, true
+		}
+	case core.Absolute:
+		ins.Operand = d.absolute(abs)
+		ins.Target, ins.HasTarget = abs, true
+	case core.AbsoluteX:
+		ins.Operand = d.absolute(abs) + ",X"
+		if regs != nil {
+			ins.Target, ins.HasTarget = abs+uint16(regs.X), true
+		}
+	case core.AbsoluteY:
+		ins.Operand = d.absolute(abs) + ",Y"
+		if regs != nil {
+			ins.Target, ins.HasTarget = abs+uint16(regs.Y), true
+		}
+	case core.Indirect:
+		ins.Operand = "(" + d.absolute(abs) + ")"
+		if regs != nil {
+			hi := abs + 1
+			if d.variant.HasJMPIndirectBug() {
+				hi = abs&0xFF00 | (abs+1)&0x00FF
+			}
+			ins.Target, ins.HasTarget = d.pointer(abs, hi), true
+		}
+	case core.IndirectX:
+		ins.Operand = "(" + d.zeroPage(zp) + ",X)"
+		if regs != nil {
+			ptr := (zp + uint16(regs.X)) & 0x00FF
+			ins.Target, ins.HasTarget = d.pointer(ptr, (ptr+1)&0x00FF), true
+		}
+	case core.IndirectY:
+		ins.Operand = "(" + d.zeroPage(zp) + "),Y"
+		if regs != nil {
+			ins.Target, ins.HasTarget = d.pointer(zp, (zp+1)&0x00FF)+uint16(regs.Y), true
+		}
+	case core.ZeroPageIndirect:
+		ins.Operand = "(" + d.zeroPage(zp) + ")"
+		if regs != nil {
+			ins.Target, ins.HasTarget = d.pointer(zp, (zp+1)&0x00FF), true
+		}
+	case core.AbsoluteXIndirect:
+		ins.Operand = "(" + d.absolute(abs) + ",X)"
+		if regs != nil {
+			ptr := abs + uint16(regs.X)
+			ins.Target, ins.HasTarget = d.pointer(ptr, ptr+1), true
+		}
+	case core.Relative:
+		target := next + uint16(int8(b1))
+		ins.Operand = d.absolute(target)
+		ins.Target, ins.HasTarget = target, true
+	case core.ZeroPageRelative:
+		target := next + uint16(int8(b2))
+		ins.Operand = d.zeroPage(zp) + "," + d.absolute(target)
+		ins.Target, ins.HasTarget = target, true
+	}
+}
+
+// pointer reads a little-endian address whose bytes are at lo and hi.
+func (d *Disassembler) pointer(lo, hi uint16) uint16 {
+	return uint16(d.bus.Read(hi))<<8 | uint16(d.bus.Read(lo))
+}
+
+func (d *Disassembler) zeroPage(addr uint16) string {
+	if label, ok := d.Symbols[addr]; ok {
+		return label
+	}
+	return fmt.Sprintf("$%02X", addr)
+}
+
+func (d *Disassembler) absolute(addr uint16) string {
+	if label, ok := d.Symbols[addr]; ok {
+		return label
+	}
+	return fmt.Sprintf("$%04X", addr)
+}
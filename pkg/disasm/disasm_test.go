@@ -0,0 +1,166 @@
+package disasm
+
+import (
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// SimpleRAM is a simple RAM implementation for testing
+type SimpleRAM struct {
+	memory [0x10000]byte
+}
+
+func (r *SimpleRAM) Read(addr uint16) byte {
+	return r.memory[addr]
+}
+
+func (r *SimpleRAM) Write(addr uint16, data byte) {
+	r.memory[addr] = data
+}
+
+func newDisassembler(t *testing.T, variant core.Variant, code ...byte) (*Disassembler, *SimpleRAM) {
+	t.Helper()
+	ram := &SimpleRAM{}
+	copy(ram.memory[0x0200:], code)
+	d, err := New(ram, variant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, ram
+}
+
+func TestDecodeSyntax(t *testing.T) {
+	tests := []struct {
+		variant core.Variant
+		code    []byte
+		want    string
+		length  int
+	}{
+		{core.VariantNMOS, []byte{0xEA}, "NOP", 1},
+		{core.VariantNMOS, []byte{0x0A}, "ASL A", 1},
+		{core.VariantNMOS, []byte{0xA9, 0x42}, "LDA #$42", 2},
+		{core.VariantNMOS, []byte{0xA5, 0x10}, "LDA $10", 2},
+		{core.VariantNMOS, []byte{0xB5, 0x10}, "LDA $10,X", 2},
+		{core.VariantNMOS, []byte{0xB6, 0x10}, "LDX $10,Y", 2},
+		{core.VariantNMOS, []byte{0xAD, 0x34, 0x12}, "LDA $1234", 3},
+		{core.VariantNMOS, []byte{0xBD, 0x34, 0x12}, "LDA $1234,X", 3},
+		{core.VariantNMOS, []byte{0xB9, 0x34, 0x12}, "LDA $1234,Y", 3},
+		{core.VariantNMOS, []byte{0x6C, 0xFF, 0x10}, "JMP ($10FF)", 3},
+		{core.VariantNMOS, []byte{0xA1, 0x20}, "LDA ($20,X)", 2},
+		{core.VariantNMOS, []byte{0xB1, 0x20}, "LDA ($20),Y", 2},
+		{core.VariantNMOS, []byte{0xD0, 0xFE}, "BNE $0200", 2},
+		{core.VariantNMOS, []byte{0x10, 0x10}, "BPL $0212", 2},
+		{core.VariantNMOS, []byte{0xA7, 0x10}, "LAX $10", 2},
+		{core.VariantNMOS, []byte{0x02}, "JAM", 1},
+		{core.VariantWDC65C02, []byte{0xB2, 0x20}, "LDA ($20)", 2},
+		{core.VariantWDC65C02, []byte{0x7C, 0x00, 0x30}, "JMP ($3000,X)", 3},
+		{core.VariantWDC65C02, []byte{0x0F, 0x20, 0x05}, "BBR0 $20,$0208", 3},
+		{core.VariantWDC65C02, []byte{0xFF, 0x20, 0xFD}, "BBS7 $20,$0200", 3},
+		{core.VariantWDC65C02, []byte{0x87, 0x20}, "SMB0 $20", 2},
+		{core.VariantWDC65C02, []byte{0x80, 0x02}, "BRA $0204", 2},
+		{core.VariantWDC65C02, []byte{0x1A}, "INC A", 1},
+		{core.VariantWDC65C02, []byte{0x5C, 0x34, 0x12}, "NOP $1234", 3},
+	}
+
+	for _, tt := range tests {
+		d, _ := newDisassembler(t, tt.variant, tt.code...)
+		ins := d.Decode(0x0200)
+
+		if got := ins.String(); got != tt.want {
+			t.Errorf("%v % X: got %q, want %q", tt.variant, tt.code, got, tt.want)
+		}
+		if ins.Len() != tt.length {
+			t.Errorf("%v % X: expected %d bytes, got %d", tt.variant, tt.code, tt.length, ins.Len())
+		}
+	}
+}
+
+func TestDecodeVariantDifferences(t *testing.T) {
+	code := []byte{0x1A, 0x5C, 0x34, 0x12}
+
+	d, _ := newDisassembler(t, core.VariantNMOS, code...)
+	nmos := d.Decode(0x0200)
+	d, _ = newDisassembler(t, core.VariantWDC65C02, code...)
+	wdc := d.Decode(0x0200)
+
+	if nmos.String() != "NOP" || !nmos.Illegal {
+		t.Errorf("NMOS $1A: got %q illegal=%v", nmos.String(), nmos.Illegal)
+	}
+	if wdc.String() != "INC A" || wdc.Illegal {
+		t.Errorf("65C02 $1A: got %q illegal=%v", wdc.String(), wdc.Illegal)
+	}
+
+	if _, err := New(&SimpleRAM{}, core.Variant(99)); err == nil {
+		t.Error("expected an error for an unknown variant")
+	}
+}
+
+func TestDecodeTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant core.Variant
+		code    []byte
+		regs    *core.Registers
+		target  uint16
+		known   bool
+	}{
+		{"zero page", core.VariantNMOS, []byte{0xA5, 0x10}, nil, 0x0010, true},
+		{"absolute", core.VariantNMOS, []byte{0x20, 0x00, 0x30}, nil, 0x3000, true},
+		{"branch", core.VariantNMOS, []byte{0xF0, 0x80}, nil, 0x0182, true},
+		{"immediate", core.VariantNMOS, []byte{0xA9, 0x10}, nil, 0, false},
+		{"indexed without registers", core.VariantNMOS, []byte{0xBD, 0x00, 0x30}, nil, 0, false},
+		{"absolute,X", core.VariantNMOS, []byte{0xBD, 0xFF, 0x30}, &core.Registers{X: 2}, 0x3101, true},
+		{"zero page,X wraps", core.VariantNMOS, []byte{0xB5, 0xFF}, &core.Registers{X: 2}, 0x0001, true},
+		{"(zp),Y", core.VariantNMOS, []byte{0xB1, 0x40}, &core.Registers{Y: 4}, 0x5004, true},
+		{"(zp,X)", core.VariantNMOS, []byte{0xA1, 0x3E}, &core.Registers{X: 2}, 0x5000, true},
+		{"NMOS JMP indirect bug", core.VariantNMOS, []byte{0x6C, 0xFF, 0x10}, &core.Registers{}, 0x2040, true},
+		{"65C02 JMP indirect", core.VariantWDC65C02, []byte{0x6C, 0xFF, 0x10}, &core.Registers{}, 0x3040, true},
+		{"65C02 (zp)", core.VariantWDC65C02, []byte{0xB2, 0x40}, &core.Registers{}, 0x5000, true},
+		{"65C02 (abs,X)", core.VariantWDC65C02, []byte{0x7C, 0xFE, 0x10}, &core.Registers{X: 1}, 0x3040, true},
+		{"65C02 BBR", core.VariantWDC65C02, []byte{0x2F, 0x40, 0x10}, nil, 0x0213, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ram := newDisassembler(t, tt.variant, tt.code...)
+			ram.memory[0x0040] = 0x00 // ($40) -> $5000
+			ram.memory[0x0041] = 0x50
+			ram.memory[0x10FF] = 0x40 // ($10FF) -> $3040, or $2040 with the NMOS bug
+			ram.memory[0x1000] = 0x20
+			ram.memory[0x1100] = 0x30
+			d.Registers = tt.regs
+
+			ins := d.Decode(0x0200)
+			if ins.HasTarget != tt.known || ins.Target != tt.target {
+				t.Errorf("got target $%04X (known=%v), want $%04X (known=%v)", ins.Target, ins.HasTarget, tt.target, tt.known)
+			}
+		})
+	}
+}
+
+func TestDecodeLabels(t *testing.T) {
+	d, _ := newDisassembler(t, core.VariantWDC65C02,
+		0x20, 0xD2, 0xFF, // JSR CHROUT
+		0xB1, 0xFB, // LDA (ptr),Y
+		0x8F, 0x02, 0xF8, // BBS0 flags,start
+		0xD0, 0xF6, // BNE start
+	)
+	d.Symbols = SymbolTable{0xFFD2: "CHROUT", 0x00FB: "ptr", 0x0002: "flags", 0x0200: "start"}
+
+	want := []string{"JSR CHROUT", "LDA (ptr),Y", "BBS0 flags,start", "BNE start"}
+	for n, ins := range d.DecodeRange(0x0200, len(want)) {
+		if got := ins.String(); got != want[n] {
+			t.Errorf("instruction %d: got %q, want %q", n, got, want[n])
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	d, _ := newDisassembler(t, core.VariantNMOS, 0xBD, 0x34, 0x12)
+
+	want := "0200  BD 34 12  LDA $1234,X"
+	if got := d.Decode(0x0200).Format(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
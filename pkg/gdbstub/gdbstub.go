@@ -0,0 +1,631 @@
+// Package gdbstub serves a CPU over the GDB remote serial protocol (RSP), so
+// it can be driven by gdb or any other RSP client over TCP.
+//
+// The stub runs in all-stop mode with a single thread and supports:
+//   - g/G and p/P register access
+//   - m/M memory access through the bus, without triggering watchpoints
+//   - s step and c continue, interrupted by Ctrl-C (0x03)
+//   - Z0/Z1 software and hardware breakpoints, both implemented as
+//     execution breakpoints and reported as swbreak and hwbreak stops, and
+//     Z2/Z3/Z4 write, read and access watchpoints
+//   - qSupported, QStartNoAckMode and a target description through
+//     qXfer:features:read
+//
+// Registers are numbered 0 A, 1 X, 2 Y, 3 SP, 4 PC and 5 P. PC is 16 bits,
+// little endian, the others 8 bits.
+//
+// Stop replies use SIGTRAP (05) for breakpoints, watchpoints and steps,
+// SIGINT (02) after Ctrl-C and SIGILL (04) when the CPU jams, executes STP
+// or meets an unknown opcode.
+package gdbstub
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/debugger"
+)
+
+// Register numbers.
+const (
+	RegA = iota
+	RegX
+	RegY
+	RegSP
+	RegPC
+	RegP
+	numRegs
+)
+
+// targetXML describes the registers to clients that ask for it.
+const targetXML = `<?xml version="1.0"?>
+<!DOCTYPE target SYSTEM "gdb-target.dtd">
+<target version="1.0">
+  <feature name="org.6502.core">
+    <reg name="a" bitsize="8" type="uint8" regnum="0"/>
+    <reg name="x" bitsize="8" type="uint8"/>
+    <reg name="y" bitsize="8" type="uint8"/>
+    <reg name="sp" bitsize="8" type="uint8"/>
+    <reg name="pc" bitsize="16" type="code_ptr"/>
+    <reg name="p" bitsize="8" type="uint8"/>
+  </feature>
+</target>
+`
+
+// Server serves one debugger to RSP clients, one connection at a time.
+type Server struct {
+	Debugger *debugger.Debugger
+
+	// Logf, if set, receives every packet sent and received
+	Logf func(format string, args ...any)
+}
+
+// NewServer returns a server for d.
+func NewServer(d *debugger.Debugger) *Server {
+	return &Server{Debugger: d}
+}
+
+// ListenAndServe listens on the TCP address addr and serves clients until
+// the listener fails.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l and serves them one at a time.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		if err := s.ServeConn(conn); err != nil && s.Logf != nil {
+			s.Logf("gdbstub: %v", err)
+		}
+	}
+}
+
+// errDetach ends a session without an error.
+var errDetach = errors.New("detach")
+
+// input is a packet or Ctrl-C from the client.
+type input struct {
+	packet    string
+	interrupt bool
+	err       error
+}
+
+// session is one client connection.
+type session struct {
+	s      *Server
+	d      *debugger.Debugger
+	conn   io.ReadWriteCloser
+	in     chan input
+	done   chan struct{} // Closed when the session ends
+	queued []input       // Packets received while the CPU was running
+	noAck  atomic.Bool
+	swbrk  bool // Client accepts swbreak stop reasons
+	hwbrk  bool // Client accepts hwbreak stop reasons
+	points map[string]*debugger.Breakpoint
+	last   string // Reply to the last packet, for "?"
+}
+
+// ServeConn serves a single client until it detaches, kills the session or
+// disconnects. Breakpoints the client set are removed when it returns.
+func (s *Server) ServeConn(conn io.ReadWriteCloser) error {
+	defer conn.Close()
+
+	ss := &session{
+		s:      s,
+		d:      s.Debugger,
+		conn:   conn,
+		in:     make(chan input, 16),
+		done:   make(chan struct{}),
+		points: make(map[string]*debugger.Breakpoint),
+	}
+	defer close(ss.done)
+	defer func() {
+		for _, bp := range ss.points {
+			ss.d.Delete(bp.ID)
+		}
+	}()
+
+	go ss.read()
+	for {
+		in, ok := ss.next()
+		if !ok {
+			return nil
+		}
+		if in.err != nil {
+			if in.err == io.EOF {
+				return nil
+			}
+			return in.err
+		}
+		if in.interrupt {
+			continue // Nothing is running
+		}
+
+		reply, err := ss.handle(in.packet)
+		if err == errDetach {
+			ss.send(reply)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := ss.send(reply); err != nil {
+			return err
+		}
+	}
+}
+
+// next returns the next input, taking queued packets first.
+func (ss *session) next() (input, bool) {
+	if len(ss.queued) > 0 {
+		in := ss.queued[0]
+		ss.queued = ss.queued[1:]
+		return in, true
+	}
+	in, ok := <-ss.in
+	return in, ok
+}
+
+func (ss *session) logf(format string, args ...any) {
+	if ss.s.Logf != nil {
+		ss.s.Logf(format, args...)
+	}
+}
+
+// deliver passes input to the session, unless it has ended.
+func (ss *session) deliver(in input) bool {
+	select {
+	case ss.in <- in:
+		return true
+	case <-ss.done:
+		return false
+	}
+}
+
+// read parses packets from the connection into ss.in.
+func (ss *session) read() {
+	defer close(ss.in)
+	r := bufio.NewReader(ss.conn)
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			ss.deliver(input{err: err})
+			return
+		}
+
+		switch c {
+		case 0x03:
+			if !ss.deliver(input{interrupt: true}) {
+				return
+			}
+			continue
+		case '$':
+		default:
+			continue // Acks and line noise
+		}
+
+		data, err := r.ReadString('#')
+		if err != nil {
+			ss.deliver(input{err: err})
+			return
+		}
+		data = data[:len(data)-1]
+		var sum [2]byte
+		if _, err := io.ReadFull(r, sum[:]); err != nil {
+			ss.deliver(input{err: err})
+			return
+		}
+
+		if !ss.noAck.Load() {
+			want, err := strconv.ParseUint(string(sum[:]), 16, 8)
+			if err != nil || byte(want) != checksum(data) {
+				ss.conn.Write([]byte{'-'})
+				continue
+			}
+			ss.conn.Write([]byte{'+'})
+		}
+		ss.logf("gdbstub: <- %s", data)
+		if !ss.deliver(input{packet: data}) {
+			return
+		}
+	}
+}
+
+func checksum(data string) byte {
+	var sum byte
+	for i := 0; i < len(data); i++ {
+		sum += data[i]
+	}
+	return sum
+}
+
+// send writes a packet. Acknowledgements from the client are not waited
+// for; a '-' is not resent, as there is no retransmission over TCP.
+func (ss *session) send(data string) error {
+	ss.logf("gdbstub: -> %s", data)
+	_, err := fmt.Fprintf(ss.conn, "$%s#%02x", escape(data), checksum(escape(data)))
+	return err
+}
+
+// escape escapes the bytes RSP reserves in packet data.
+func escape(data string) string {
+	if !strings.ContainsAny(data, "$#}*") {
+		return data
+	}
+	var b strings.Builder
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '$', '#', '}', '*':
+			b.WriteByte('}')
+			b.WriteByte(c ^ 0x20)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// handle executes one packet and returns the reply. An empty reply tells
+// the client the packet is not supported.
+func (ss *session) handle(p string) (string, error) {
+	if p == "" {
+		return "", nil
+	}
+
+	switch p[0] {
+	case '?':
+		if ss.last == "" {
+			ss.last = "S05"
+		}
+		return ss.last, nil
+	case 'g':
+		return ss.readRegisters(), nil
+	case 'G':
+		return ss.writeRegisters(p[1:]), nil
+	case 'p':
+		return ss.readRegister(p[1:]), nil
+	case 'P':
+		return ss.writeRegister(p[1:]), nil
+	case 'm':
+		return ss.readMemory(p[1:]), nil
+	case 'M':
+		return ss.writeMemory(p[1:]), nil
+	case 's', 'c':
+		if len(p) > 1 {
+			addr, err := strconv.ParseUint(p[1:], 16, 16)
+			if err != nil {
+				return "E01", nil
+			}
+			r := ss.d.CPU.Registers()
+			r.PC = uint16(addr)
+			ss.d.CPU.SetRegisters(r)
+		}
+		return ss.resume(p[0] == 's')
+	case 'Z', 'z':
+		return ss.breakpoint(p), nil
+	case 'H', 'T':
+		return "OK", nil // There is only one thread
+	case 'D':
+		return "OK", errDetach
+	case 'k':
+		return "", errDetach
+	case 'q', 'Q':
+		return ss.query(p), nil
+	}
+	return "", nil
+}
+
+func (ss *session) query(p string) string {
+	switch {
+	case strings.HasPrefix(p, "qSupported"):
+		features := p[len("qSupported"):]
+		ss.swbrk = strings.Contains(features, "swbreak+")
+		ss.hwbrk = strings.Contains(features, "hwbreak+")
+		return "PacketSize=4000;QStartNoAckMode+;swbreak+;hwbreak+;qXfer:features:read+"
+	case p == "QStartNoAckMode":
+		ss.noAck.Store(true)
+		return "OK"
+	case p == "qAttached":
+		return "1"
+	case p == "qC":
+		return "QC1"
+	case p == "qfThreadInfo":
+		return "m1"
+	case p == "qsThreadInfo":
+		return "l"
+	case strings.HasPrefix(p, "qXfer:features:read:target.xml:"):
+		return xfer(targetXML, p[len("qXfer:features:read:target.xml:"):])
+	}
+	return ""
+}
+
+// xfer returns the part of doc selected by an "offset,length" annex.
+func xfer(doc, annex string) string {
+	offStr, lenStr, ok := strings.Cut(annex, ",")
+	off, err1 := strconv.ParseUint(offStr, 16, 32)
+	n, err2 := strconv.ParseUint(lenStr, 16, 32)
+	if !ok || err1 != nil || err2 != nil {
+		return "E01"
+	}
+	if off >= uint64(len(doc)) {
+		return "l"
+	}
+	end := min(off+n, uint64(len(doc)))
+	prefix := "m"
+	if end == uint64(len(doc)) {
+		prefix = "l"
+	}
+	return prefix + doc[off:end]
+}
+
+// registerBytes returns the encoding of register n.
+func registerBytes(r core.Registers, n int) []byte {
+	switch n {
+	case RegA:
+		return []byte{r.A}
+	case RegX:
+		return []byte{r.X}
+	case RegY:
+		return []byte{r.Y}
+	case RegSP:
+		return []byte{r.SP}
+	case RegPC:
+		return []byte{byte(r.PC), byte(r.PC >> 8)}
+	default:
+		return []byte{r.Status}
+	}
+}
+
+// setRegister decodes data into register n.
+func setRegister(r *core.Registers, n int, data []byte) bool {
+	want := 1
+	if n == RegPC {
+		want = 2
+	}
+	if len(data) != want {
+		return false
+	}
+
+	switch n {
+	case RegA:
+		r.A = data[0]
+	case RegX:
+		r.X = data[0]
+	case RegY:
+		r.Y = data[0]
+	case RegSP:
+		r.SP = data[0]
+	case RegPC:
+		r.PC = uint16(data[0]) | uint16(data[1])<<8
+	default:
+		r.Status = data[0] | core.FlagUnused
+	}
+	return true
+}
+
+func (ss *session) readRegisters() string {
+	r := ss.d.CPU.Registers()
+	var b []byte
+	for n := range numRegs {
+		b = append(b, registerBytes(r, n)...)
+	}
+	return hex.EncodeToString(b)
+}
+
+func (ss *session) writeRegisters(data string) string {
+	b, err := hex.DecodeString(data)
+	if err != nil || len(b) != numRegs+1 {
+		return "E01"
+	}
+	r := ss.d.CPU.Registers()
+	for n := range numRegs {
+		size := len(registerBytes(r, n))
+		setRegister(&r, n, b[:size])
+		b = b[size:]
+	}
+	ss.d.CPU.SetRegisters(r)
+	return "OK"
+}
+
+func (ss *session) readRegister(arg string) string {
+	n, err := strconv.ParseUint(arg, 16, 8)
+	if err != nil || n >= numRegs {
+		return "E01"
+	}
+	return hex.EncodeToString(registerBytes(ss.d.CPU.Registers(), int(n)))
+}
+
+func (ss *session) writeRegister(arg string) string {
+	num, value, ok := strings.Cut(arg, "=")
+	n, err := strconv.ParseUint(num, 16, 8)
+	b, err2 := hex.DecodeString(value)
+	if !ok || err != nil || err2 != nil || n >= numRegs {
+		return "E01"
+	}
+	r := ss.d.CPU.Registers()
+	if !setRegister(&r, int(n), b) {
+		return "E01"
+	}
+	ss.d.CPU.SetRegisters(r)
+	return "OK"
+}
+
+// parseRange parses "addr,length" and checks it fits in 64K.
+func parseRange(arg string) (uint16, int, bool) {
+	addrStr, lenStr, ok := strings.Cut(arg, ",")
+	addr, err1 := strconv.ParseUint(addrStr, 16, 32)
+	n, err2 := strconv.ParseUint(lenStr, 16, 32)
+	if !ok || err1 != nil || err2 != nil || addr+n > 0x10000 {
+		return 0, 0, false
+	}
+	return uint16(addr), int(n), true
+}
+
+func (ss *session) readMemory(arg string) string {
+	addr, n, ok := parseRange(arg)
+	if !ok {
+		return "E01"
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = ss.d.Peek(addr + uint16(i))
+	}
+	return hex.EncodeToString(b)
+}
+
+func (ss *session) writeMemory(arg string) string {
+	where, data, found := strings.Cut(arg, ":")
+	addr, n, ok := parseRange(where)
+	b, err := hex.DecodeString(data)
+	if !found || !ok || err != nil || len(b) != n {
+		return "E01"
+	}
+	for i, v := range b {
+		if ss.d.Poke(addr+uint16(i), v) != nil {
+			return "E01"
+		}
+	}
+	return "OK"
+}
+
+// breakpoint handles Z and z packets: "Ztype,addr,kind".
+func (ss *session) breakpoint(p string) string {
+	fields := strings.Split(p[1:], ",")
+	if len(fields) < 3 {
+		return "E01"
+	}
+	addr, err1 := strconv.ParseUint(fields[1], 16, 16)
+	size, err2 := strconv.ParseUint(fields[2], 16, 16)
+	if err1 != nil || err2 != nil {
+		return "E01"
+	}
+
+	var bp debugger.Breakpoint
+	switch fields[0] {
+	case "0", "1":
+		bp = debugger.Breakpoint{Kind: debugger.Exec, Addr: uint16(addr)}
+	case "2", "3", "4":
+		kind := map[string]debugger.Kind{"2": debugger.Write, "3": debugger.Read, "4": debugger.Access}[fields[0]]
+		end := addr + max(size, 1) - 1
+		if end > 0xFFFF {
+			return "E01"
+		}
+		bp = debugger.Breakpoint{Kind: kind, Addr: uint16(addr), End: uint16(end)}
+	default:
+		return "" // Unsupported type
+	}
+
+	key := strings.Join(fields[:3], ",")
+	old := ss.points[key]
+	if p[0] == 'z' {
+		if old != nil {
+			ss.d.Delete(old.ID)
+			delete(ss.points, key)
+		}
+		return "OK"
+	}
+	if old != nil {
+		return "OK" // Inserting twice is allowed
+	}
+	added, err := ss.d.Add(bp)
+	if err != nil {
+		return "E01"
+	}
+	ss.points[key] = added
+	return "OK"
+}
+
+// resume steps or continues, handling Ctrl-C while the CPU runs, and
+// returns the stop reply.
+func (ss *session) resume(step bool) (string, error) {
+	type result struct {
+		stop *debugger.Stop
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		if step {
+			r.stop, r.err = ss.d.Step()
+		} else {
+			r.stop, r.err = ss.d.Continue()
+		}
+		done <- r
+	}()
+
+	for {
+		select {
+		case r := <-done:
+			ss.last = ss.stopReply(r.stop, r.err)
+			return ss.last, nil
+		case in, ok := <-ss.in:
+			switch {
+			case !ok || in.err != nil:
+				ss.d.Interrupt()
+				<-done
+				return "", errDetach
+			case in.interrupt:
+				ss.d.Interrupt()
+			default:
+				ss.queued = append(ss.queued, in)
+			}
+		}
+	}
+}
+
+func (ss *session) stopReply(stop *debugger.Stop, err error) string {
+	switch {
+	case err != nil:
+		return "S04"
+	case stop == nil:
+		return "S05" // A step that hit nothing
+	case stop.Breakpoint == nil:
+		return "S02"
+	}
+
+	bp := stop.Breakpoint
+	switch bp.Kind {
+	case debugger.Write:
+		return fmt.Sprintf("T05watch:%04x;", stop.Access.Addr)
+	case debugger.Read:
+		return fmt.Sprintf("T05rwatch:%04x;", stop.Access.Addr)
+	case debugger.Access:
+		return fmt.Sprintf("T05awatch:%04x;", stop.Access.Addr)
+	}
+	// Each reason may only be sent to a client that asked for it
+	if ss.hardware(bp) {
+		if ss.hwbrk {
+			return "T05hwbreak:;"
+		}
+	} else if ss.swbrk {
+		return "T05swbreak:;"
+	}
+	return "S05"
+}
+
+// hardware reports whether the client set bp with a Z1 packet.
+func (ss *session) hardware(bp *debugger.Breakpoint) bool {
+	for key, p := range ss.points {
+		if p == bp {
+			return key[0] == '1'
+		}
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+package gdbstub
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/asm"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/debugger"
+)
+
+// SimpleRAM is a simple RAM implementation for testing
+type SimpleRAM struct {
+	memory [0x10000]byte
+}
+
+func (r *SimpleRAM) Read(addr uint16) byte {
+	return r.memory[addr]
+}
+
+func (r *SimpleRAM) Write(addr uint16, data byte) {
+	r.memory[addr] = data
+}
+
+// client is a minimal RSP client.
+type client struct {
+	t     *testing.T
+	conn  net.Conn
+	r     *bufio.Reader
+	noAck bool
+}
+
+// startServer serves a debugger running src on a loopback port.
+func startServer(t *testing.T, variant core.Variant, src string) *client {
+	t.Helper()
+	ram := &SimpleRAM{}
+	prog := asm.MustAssemble(variant, src)
+	prog.Load(ram)
+
+	d, err := debugger.New(variant, ram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := d.CPU.Registers()
+	r.PC = prog.Symbols["start"]
+	d.CPU.SetRegisters(r)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go NewServer(d).Serve(l)
+	t.Cleanup(func() { l.Close() })
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	t.Cleanup(func() { conn.Close() })
+	return &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+// request sends a packet and returns the reply.
+func (c *client) request(packet string) string {
+	c.t.Helper()
+	fmt.Fprintf(c.conn, "$%s#%02x", packet, checksum(packet))
+	if !c.noAck {
+		if ack, err := c.r.ReadByte(); err != nil || ack != '+' {
+			c.t.Fatalf("%s: expected ack, got %q, %v", packet, ack, err)
+		}
+	}
+	return c.reply()
+}
+
+func (c *client) reply() string {
+	c.t.Helper()
+	if _, err := c.r.ReadString('$'); err != nil {
+		c.t.Fatal(err)
+	}
+	data, err := c.r.ReadString('#')
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	data = data[:len(data)-1]
+	var sum [2]byte
+	if _, err := c.r.Read(sum[:1]); err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := c.r.Read(sum[1:]); err != nil {
+		c.t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%02x", checksum(data)); got != string(sum[:]) {
+		c.t.Fatalf("bad checksum %s for %q", sum, data)
+	}
+	return data
+}
+
+func (c *client) expect(packet, want string) {
+	c.t.Helper()
+	if got := c.request(packet); got != want {
+		c.t.Errorf("%s: got %q, want %q", packet, got, want)
+	}
+}
+
+const counter = `
+        .org $0200
+start:  ldx #0
+loop:   inx
+        stx $10
+        lda $20
+        jmp loop
+`
+
+func TestRegistersAndMemory(t *testing.T) {
+	c := startServer(t, core.VariantNMOS, counter)
+
+	if got := c.request("qSupported:swbreak+;hwbreak+"); !strings.Contains(got, "QStartNoAckMode+") {
+		t.Errorf("unexpected qSupported reply %q", got)
+	}
+	c.expect("QStartNoAckMode", "OK")
+	c.noAck = true
+
+	c.expect("?", "S05")
+	c.expect("g", "000000fd000234")
+	c.expect("G"+"112233fc3412a5", "OK")
+	c.expect("g", "112233fc3412a5")
+	c.expect("p4", "3412")
+	c.expect("P4=0002", "OK")
+	c.expect("P5=00", "OK")
+	c.expect("p5", "20") // The unused bit reads as 1
+	c.expect("p6", "E01")
+
+	c.expect("m0200,3", "a200e8")
+	c.expect("M0300,2:beef", "OK")
+	c.expect("m0300,2", "beef")
+	c.expect("mFFFF,2", "E01")
+	c.expect("vMustReplyEmpty", "")
+
+	if got := c.request("qXfer:features:read:target.xml:0,20"); !strings.HasPrefix(got, "m<?xml") {
+		t.Errorf("unexpected target.xml reply %q", got)
+	}
+	if got := c.request("qXfer:features:read:target.xml:0,1000"); !strings.HasPrefix(got, "l<?xml") || !strings.HasSuffix(got, "</target>\n") {
+		t.Errorf("unexpected target.xml reply %q", got)
+	}
+}
+
+func TestStepAndBreakpoints(t *testing.T) {
+	c := startServer(t, core.VariantWDC65C02, counter)
+	c.request("qSupported:swbreak+")
+
+	c.expect("s", "S05")
+	c.expect("p4", "0202")
+
+	// Software and hardware breakpoints stop before the instruction
+	c.expect("Z0,0203,1", "OK")
+	c.expect("c", "T05swbreak:;")
+	c.expect("p4", "0302") // Little endian
+	c.expect("p1", "01")
+	c.expect("c", "T05swbreak:;")
+	c.expect("p1", "02")
+	c.expect("z0,0203,1", "OK")
+
+	c.expect("Z1,0205,1", "OK")
+	c.expect("c", "S05") // The client did not ask for hwbreak
+	c.expect("p4", "0502")
+	c.expect("z1,0205,1", "OK")
+
+	// Watchpoints stop after the access
+	c.expect("Z2,0010,1", "OK")
+	c.expect("c", "T05watch:0010;")
+	c.expect("p4", "0502")
+	c.expect("z2,0010,1", "OK")
+	c.expect("Z3,0020,1", "OK")
+	c.expect("c", "T05rwatch:0020;")
+	c.expect("p4", "0702")
+	c.expect("Z4,0010,1", "OK")
+	c.expect("c", "T05awatch:0010;")
+	c.expect("z3,0020,1", "OK")
+	c.expect("z4,0010,1", "OK")
+	c.expect("Z9,0000,1", "")
+
+	// With everything removed only Ctrl-C stops the loop
+	interrupt(t, c)
+}
+
+// TestBreakpointStopReasons checks that a stop names the kind of
+// breakpoint the client set, when the client accepts that reason.
+func TestBreakpointStopReasons(t *testing.T) {
+	c := startServer(t, core.VariantNMOS, counter)
+	c.request("qSupported:swbreak+;hwbreak+")
+
+	c.expect("Z1,0203,1", "OK")
+	c.expect("c", "T05hwbreak:;")
+	c.expect("z1,0203,1", "OK")
+	c.expect("Z0,0203,1", "OK")
+	c.expect("c", "T05swbreak:;")
+}
+
+// interrupt continues, then stops the CPU with Ctrl-C.
+func interrupt(t *testing.T, c *client) {
+	t.Helper()
+	fmt.Fprintf(c.conn, "$c#%02x", checksum("c"))
+	if ack, _ := c.r.ReadByte(); ack != '+' {
+		t.Fatalf("expected ack, got %q", ack)
+	}
+	time.Sleep(10 * time.Millisecond)
+	c.conn.Write([]byte{0x03})
+	if got := c.reply(); got != "S02" {
+		t.Errorf("expected S02 after Ctrl-C, got %q", got)
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	c := startServer(t, core.VariantNMOS, counter)
+	interrupt(t, c)
+	c.expect("?", "S02")
+}
+
+func TestJam(t *testing.T) {
+	c := startServer(t, core.VariantNMOS, "        .org $0200\nstart:  jam")
+	c.expect("c", "S04")
+}
+
+func TestDetachRemovesBreakpoints(t *testing.T) {
+	c := startServer(t, core.VariantNMOS, counter)
+	c.expect("Z0,0203,1", "OK")
+	c.expect("Z0,0203,1", "OK") // Inserting twice is not an error
+	c.expect("D", "OK")
+
+	// The next client runs without stopping at $0203
+	conn, err := net.Dial("tcp", c.conn.RemoteAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	interrupt(t, &client{t: t, conn: conn, r: bufio.NewReader(conn)})
+}
+
+func TestBadChecksum(t *testing.T) {
+	c := startServer(t, core.VariantNMOS, counter)
+	fmt.Fprint(c.conn, "$g#00")
+	if nak, _ := c.r.ReadByte(); nak != '-' {
+		t.Errorf("expected '-', got %q", nak)
+	}
+	c.expect("p0", "00")
+}
@@ -0,0 +1,80 @@
+package mos6502
+
+import (
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// benchProgram is a representative inner loop: an indexed copy with
+// arithmetic, a subroutine call using zero page and (zp),Y operands, and a
+// taken branch on every iteration but the last.
+var benchProgram = map[uint16][]byte{
+	0x0200: {
+		0xA2, 0x00, // LDX #$00
+		0xBD, 0x00, 0x03, // loop: LDA $0300,X
+		0x69, 0x01, // ADC #$01
+		0x9D, 0x00, 0x04, // STA $0400,X
+		0x20, 0x80, 0x02, // JSR $0280
+		0xE8,       // INX
+		0xD0, 0xF2, // BNE loop
+		0x4C, 0x00, 0x02, // JMP $0200
+	},
+	0x0280: {
+		0xA5, 0x10, // LDA $10
+		0x51, 0x20, // EOR ($20),Y
+		0x85, 0x10, // STA $10
+		0x26, 0x11, // ROL $11
+		0x60, // RTS
+	},
+	0x0020: {0x00, 0x05},
+}
+
+func newBenchCPU() *CPU {
+	bus := NewSimpleRAM()
+	for addr, code := range benchProgram {
+		bus.LoadProgram(addr, code)
+	}
+	cpu := NewCPU(bus)
+	cpu.PC = 0x0200
+	return cpu
+}
+
+// BenchmarkStep measures the cost of one clock cycle through Step.
+func BenchmarkStep(b *testing.B) {
+	cpu := newBenchCPU()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cpu.Step()
+	}
+}
+
+// BenchmarkStepInstruction measures the cost of one instruction through
+// StepInstruction.
+func BenchmarkStepInstruction(b *testing.B) {
+	cpu := newBenchCPU()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cpu.StepInstruction()
+	}
+}
+
+// BenchmarkStepInstructionMapDispatch runs the same workload one
+// instruction at a time, decoding through a map as the CPUs did before the
+// opcode tables, for comparison with BenchmarkStepInstruction.
+func BenchmarkStepInstructionMapDispatch(b *testing.B) {
+	cpu := newBenchCPU()
+	table := make(map[byte]*core.Opcode)
+	for i := range opcodes {
+		table[byte(i)] = &opcodes[i]
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		opcode := cpu.Read(cpu.PC)
+		cpu.PC++
+		cpu.Execute(table[opcode])
+	}
+}
@@ -0,0 +1,245 @@
+package mos6502
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// busCycles runs one instruction or interrupt sequence and returns its bus
+// cycles as "R 0200 EE", the direction, address and data.
+func busCycles(cpu *CPU) []string {
+	var cycles []string
+	cpu.SetBusTrace(func(c core.BusCycle) {
+		rw := "R"
+		if c.Write {
+			rw = "W"
+		}
+		cycles = append(cycles, fmt.Sprintf("%s %04X %02X", rw, c.Addr, c.Data))
+	})
+	cpu.StepInstruction()
+	cpu.SetBusTrace(nil)
+	return cycles
+}
+
+// TestEveryCycleIsABusAccess runs every opcode with operands that do and do
+// not cross pages, and with every flag clear and set, and checks that it
+// makes one bus access per cycle.
+func TestEveryCycleIsABusAccess(t *testing.T) {
+	for op := 0; op < 0x100; op++ {
+		for _, operand := range [][2]byte{{0x10, 0x20}, {0xF8, 0x20}} {
+			for _, status := range []byte{0x20, 0xFF} {
+				bus := NewSimpleRAM()
+				for i := range bus.memory {
+					bus.memory[i] = 0x20 // Pointers everywhere lead to $2020
+				}
+				bus.LoadProgram(0x0200, []byte{byte(op), operand[0], operand[1]})
+				cpu := NewCPU(bus)
+				cpu.PC = 0x0200
+				cpu.X, cpu.Y = 0x10, 0x10
+				cpu.Status = status
+
+				var accesses int
+				cpu.SetBusTrace(func(core.BusCycle) { accesses++ })
+				result, _ := cpu.StepInstruction()
+				if accesses != result.Cycles {
+					t.Errorf("opcode $%02X operand %02X%02X P=%02X: %d accesses in %d cycles",
+						op, operand[1], operand[0], status, accesses, result.Cycles)
+				}
+			}
+		}
+	}
+}
+
+// TestStepMatchesStepInstruction runs every opcode one Step at a time and
+// checks that each Step makes exactly one bus access and that the cycles and
+// final registers match running the whole instruction with StepInstruction.
+func TestStepMatchesStepInstruction(t *testing.T) {
+	for op := 0; op < 0x100; op++ {
+		for _, operand := range [][2]byte{{0x10, 0x20}, {0xF8, 0x20}} {
+			for _, status := range []byte{0x20, 0xFF} {
+				newCPU := func() *CPU {
+					bus := NewSimpleRAM()
+					for i := range bus.memory {
+						bus.memory[i] = 0x20
+					}
+					bus.LoadProgram(0x0200, []byte{byte(op), operand[0], operand[1]})
+					cpu := NewCPU(bus)
+					cpu.PC = 0x0200
+					cpu.X, cpu.Y = 0x10, 0x10
+					cpu.Status = status
+					return cpu
+				}
+				name := fmt.Sprintf("opcode $%02X operand %02X%02X P=%02X", op, operand[1], operand[0], status)
+
+				whole := newCPU()
+				want := busCycles(whole)
+
+				stepped := newCPU()
+				var got []string
+				stepped.SetBusTrace(func(c core.BusCycle) {
+					rw := "R"
+					if c.Write {
+						rw = "W"
+					}
+					got = append(got, fmt.Sprintf("%s %04X %02X", rw, c.Addr, c.Data))
+				})
+				for steps := 1; steps <= core.MaxSequenceCycles; steps++ {
+					stepped.Step()
+					if len(got) != steps {
+						t.Fatalf("%s: %d accesses after %d steps", name, len(got), steps)
+					}
+					if !stepped.InSequence() {
+						break
+					}
+				}
+
+				if strings.Join(got, ", ") != strings.Join(want, ", ") {
+					t.Errorf("%s:\n got  %v\n want %v", name, got, want)
+				}
+				if stepped.Registers() != whole.Registers() {
+					t.Errorf("%s: registers %+v, want %+v", name, stepped.Registers(), whole.Registers())
+				}
+			}
+		}
+	}
+}
+
+func TestBusSequences(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []byte
+		setup   func(cpu *CPU, bus *SimpleRAM)
+		want    []string
+	}{
+		{
+			name:    "INC writes the old value back first",
+			program: []byte{0xEE, 0x10, 0x20}, // INC $2010
+			setup:   func(cpu *CPU, bus *SimpleRAM) { bus.memory[0x2010] = 0x05 },
+			want:    []string{"R 0200 EE", "R 0201 10", "R 0202 20", "R 2010 05", "W 2010 05", "W 2010 06"},
+		},
+		{
+			name:    "LDA abs,X reads the wrong page when crossing",
+			program: []byte{0xBD, 0xF0, 0x20}, // LDA $20F0,X
+			setup:   func(cpu *CPU, bus *SimpleRAM) { cpu.X = 0x20 },
+			want:    []string{"R 0200 BD", "R 0201 F0", "R 0202 20", "R 2010 00", "R 2110 00"},
+		},
+		{
+			name:    "LDA abs,X on the same page",
+			program: []byte{0xBD, 0x10, 0x20}, // LDA $2010,X
+			setup:   func(cpu *CPU, bus *SimpleRAM) { cpu.X = 0x01 },
+			want:    []string{"R 0200 BD", "R 0201 10", "R 0202 20", "R 2011 00"},
+		},
+		{
+			name:    "STA abs,X always reads before writing",
+			program: []byte{0x9D, 0x10, 0x20}, // STA $2010,X
+			setup:   func(cpu *CPU, bus *SimpleRAM) { cpu.X, cpu.A = 0x01, 0x42 },
+			want:    []string{"R 0200 9D", "R 0201 10", "R 0202 20", "R 2011 00", "W 2011 42"},
+		},
+		{
+			name:    "LDA zp,X reads the base address",
+			program: []byte{0xB5, 0x80}, // LDA $80,X
+			setup:   func(cpu *CPU, bus *SimpleRAM) { cpu.X = 0x90 },
+			want:    []string{"R 0200 B5", "R 0201 80", "R 0080 00", "R 0010 00"},
+		},
+		{
+			name:    "STA (zp),Y",
+			program: []byte{0x91, 0x40}, // STA ($40),Y
+			setup: func(cpu *CPU, bus *SimpleRAM) {
+				bus.LoadProgram(0x0040, []byte{0xF0, 0x20})
+				cpu.Y, cpu.A = 0x20, 0x42
+			},
+			want: []string{"R 0200 91", "R 0201 40", "R 0040 F0", "R 0041 20", "R 2010 00", "W 2110 42"},
+		},
+		{
+			name:    "ASL A reads the next byte",
+			program: []byte{0x0A, 0xEA}, // ASL A
+			want:    []string{"R 0200 0A", "R 0201 EA"},
+		},
+		{
+			name:    "JSR fetches its high byte last",
+			program: []byte{0x20, 0x00, 0x03}, // JSR $0300
+			want:    []string{"R 0200 20", "R 0201 00", "R 01FD 00", "W 01FD 02", "W 01FC 02", "R 0202 03"},
+		},
+		{
+			name:    "RTS",
+			program: []byte{0x60}, // RTS
+			setup: func(cpu *CPU, bus *SimpleRAM) {
+				bus.LoadProgram(0x01FC, []byte{0x02, 0x03})
+				cpu.SP = 0xFB
+			},
+			want: []string{"R 0200 60", "R 0201 00", "R 01FB 00", "R 01FC 02", "R 01FD 03", "R 0302 00"},
+		},
+		{
+			name:    "PLA",
+			program: []byte{0x68}, // PLA
+			setup:   func(cpu *CPU, bus *SimpleRAM) { bus.memory[0x01FE] = 0x42 },
+			want:    []string{"R 0200 68", "R 0201 00", "R 01FD 00", "R 01FE 42"},
+		},
+		{
+			name:    "Taken branch",
+			program: []byte{0xD0, 0x7F}, // BNE *+$81
+			want:    []string{"R 0200 D0", "R 0201 7F", "R 0202 00"},
+		},
+		{
+			name:    "Taken branch back across a page",
+			program: []byte{0xD0, 0xF0}, // BNE *-$0E
+			want:    []string{"R 0200 D0", "R 0201 F0", "R 0202 00", "R 02F2 00"},
+		},
+		{
+			name:    "IRQ",
+			program: []byte{0xEA},
+			setup: func(cpu *CPU, bus *SimpleRAM) {
+				bus.LoadProgram(0xFFFE, []byte{0x00, 0x03})
+				cpu.Status = 0x20
+				cpu.TriggerIRQ()
+			},
+			want: []string{"R 0200 EA", "R 0200 EA", "W 01FD 02", "W 01FC 00", "W 01FB 20", "R FFFE 00", "R FFFF 03"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewSimpleRAM()
+			bus.LoadProgram(0x0200, tt.program)
+			cpu := NewCPU(bus)
+			cpu.PC = 0x0200
+			if tt.setup != nil {
+				tt.setup(cpu, bus)
+			}
+
+			got := busCycles(cpu)
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("got  %s\nwant %s", strings.Join(got, ", "), strings.Join(tt.want, ", "))
+			}
+		})
+	}
+}
+
+func TestBusCycleNumbers(t *testing.T) {
+	bus := NewSimpleRAM()
+	bus.LoadProgram(0x0200, []byte{0xEE, 0x10, 0x20}) // INC $2010
+	cpu := NewCPU(bus)
+	cpu.PC = 0x0200
+
+	// Cycles are counted the same whether the instruction runs through Step
+	// or StepInstruction
+	for _, step := range []func(){
+		func() { cpu.Step() },
+		func() { cpu.StepInstruction() },
+	} {
+		cpu.PC = 0x0200
+		start := cpu.TotalCycles
+		var cycles []uint64
+		cpu.SetBusTrace(func(c core.BusCycle) { cycles = append(cycles, c.Cycle) })
+		step()
+		for cpu.Cycles > 0 {
+			cpu.Step()
+		}
+		if len(cycles) != 6 || cycles[0] != start || cycles[5] != start+5 || cpu.TotalCycles != start+6 {
+			t.Errorf("expected cycles %d to %d, got %v with TotalCycles %d", start, start+5, cycles, cpu.TotalCycles)
+		}
+	}
+}
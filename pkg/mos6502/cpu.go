@@ -6,9 +6,11 @@
 //
 // This implementation includes:
 //   - All 56 legal 6502 instructions with proper flag handling
+//   - The stable undocumented opcodes (LAX, SAX, DCP, ISC, SLO, RLA, ...)
 //   - 13 addressing modes including the JMP indirect page boundary bug
 //   - Cycle-accurate execution with page-crossing penalties
 //   - Interrupt support (NMI, IRQ, RESET)
+//   - JAM opcodes that lock the CPU until reset (see core.StateJammed)
 //   - Configurable bus interface for flexible memory implementations
 //
 // The CPU communicates with memory and peripherals through the Bus interface,
@@ -32,8 +34,6 @@
 package mos6502
 
 import (
-	"fmt"
-
 	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
 )
 
@@ -49,58 +49,126 @@ func NewCPU(bus core.Bus) *CPU {
 	}
 }
 
+var _ core.CPU = (*CPU)(nil)
+
+func init() {
+	core.Register(core.VariantNMOS, func(bus core.Bus) core.CPU {
+		return NewCPU(bus)
+	})
+}
+
 // Reset is inherited from BaseCPU and uses VariantNMOS (6 cycles)
 
-func (c *CPU) Run() {
-	for !c.Halted {
-		c.Step()
+// Run executes until the CPU cannot continue and returns the reason, a
+// *core.StepError wrapping core.ErrJammed, core.ErrBreakpoint or
+// core.ErrUnknownOpcode.
+func (c *CPU) Run() error {
+	for {
+		if _, err := c.StepInstruction(); err != nil {
+			return err
+		}
 	}
 }
 
-func (c *CPU) Step() {
-	if c.Cycles == 0 {
-		if c.ResetPending {
-			c.HandleReset()
-			return
+// Step executes a single CPU cycle, which makes exactly one bus access. An
+// instruction or interrupt sequence takes as many calls as it has cycles,
+// and each register changes in the cycle that changes it.
+//
+// At an instruction boundary Step returns a *core.StepError if the CPU is
+// jammed, stops at a breakpoint or fetches an opcode it cannot execute.
+func (c *CPU) Step() error {
+	if !c.InSequence() {
+		if event := c.dispatch(); !c.InSequence() {
+			return c.idle(event)
 		}
+	}
+	if event, done := c.StepSequence(&opcodes); done {
+		return c.StepError(event)
+	}
+	return nil
+}
+
+// idle runs the cycle of an event that starts no sequence: a jammed CPU
+// spends it doing nothing, and a breakpoint stops before it.
+func (c *CPU) idle(event core.Event) error {
+	if event == core.EventJammed {
+		c.TotalCycles++ // The clock keeps running while jammed
+	}
+	return c.StepError(event)
+}
 
-		if c.NMIPending {
-			c.HandleNMI()
-			return
+// StepInstruction executes exactly one instruction or interrupt sequence and
+// reports what happened. If Step has left one partway through, it runs the
+// rest of that one, and Cycles counts only the cycles run by this call.
+// A jammed CPU consumes one cycle per call. The error is the same as Step's.
+func (c *CPU) StepInstruction() (core.StepResult, error) {
+	start := c.TotalCycles
+	result := core.StepResult{PC: c.SequencePC()}
+
+	if !c.InSequence() {
+		if result.Event = c.dispatch(); !c.InSequence() {
+			err := c.idle(result.Event)
+			result.Cycles = int(c.TotalCycles - start)
+			return result, err
 		}
+	}
+	result.Event = c.RunSequence(&opcodes)
+	if result.Event == core.EventInstruction || result.Event == core.EventUnknownOpcode {
+		result.Opcode = c.Opcode
+	}
+	result.Cycles = int(c.TotalCycles - start)
+	return result, c.StepError(result.Event)
+}
 
-		if c.IRQPending && !c.GetFlag(core.FlagInterruptDisable) {
-			c.HandleIRQ()
-			return
+// RunCycles executes n clock cycles, stopping early on an error, and
+// returns the number of cycles executed. Instructions that fit in what is
+// left of the budget are run whole; the budget may end partway through
+// one, and the next Step or StepInstruction finishes it.
+func (c *CPU) RunCycles(n int) (int, error) {
+	start := c.TotalCycles
+	for ran := 0; ran < n; ran = int(c.TotalCycles - start) {
+		var err error
+		if n-ran >= core.MaxSequenceCycles && !c.InSequence() {
+			_, err = c.StepInstruction()
+		} else {
+			err = c.Step()
 		}
+		if err != nil {
+			return int(c.TotalCycles - start), err
+		}
+	}
+	return int(c.TotalCycles - start), nil
+}
 
-		opcode := c.Bus.Read(c.PC)
-		c.PC++
+// dispatch decides what starts at an instruction boundary. A reset,
+// interrupt or instruction is begun as a sequence for StepSequence or
+// RunSequence to run; other events start no sequence.
+func (c *CPU) dispatch() core.Event {
+	if c.ResetPending {
+		return c.BeginSequence(core.EventReset)
+	}
 
-		instruction, ok := instructionMap[opcode]
-		if !ok {
-			// TODO: Handle illegal opcodes
-			fmt.Printf("Unknown opcode: 0x%02X\n", opcode)
-			c.Halted = true // Halt for now
-			return
-		}
+	if c.State == core.StateJammed {
+		return core.EventJammed // Only a reset recovers a jammed CPU
+	}
 
-		var addr uint16
-		var pageCrossed bool
+	if c.NMIPending {
+		return c.BeginSequence(core.EventNMI)
+	}
 
-		if instruction.AddrMode != nil {
-			addr, pageCrossed = instruction.AddrMode(c)
-		}
+	if c.IRQPending && !c.GetFlag(core.FlagInterruptDisable) {
+		return c.BeginSequence(core.EventIRQ)
+	}
 
-		instruction.Operation(c, addr, pageCrossed)
-		c.Cycles += instruction.Cycles
+	if c.BreakFunc != nil && c.CheckBreakpoint() {
+		return core.EventBreakpoint
 	}
 
-	c.Cycles--
+	return c.BeginSequence(core.EventInstruction)
 }
 
 // Helper methods are inherited from BaseCPU:
 // - GetFlag/SetFlag for status register manipulation
 // - Push/Pull for stack operations
-// - HandleReset/HandleNMI/HandleIRQ for interrupt handling
+// - HandleNMI/HandleIRQ for interrupt sequences
 // - GetCycles for testing/debugging
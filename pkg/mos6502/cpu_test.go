@@ -1,7 +1,10 @@
 package mos6502
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
 )
 
 // SimpleRAM implements a basic 64KB RAM for testing.
@@ -102,8 +105,8 @@ func TestCPUReset(t *testing.T) {
 	}
 
 	// Check that reset takes 6 cycles (per NMOS 6502 datasheet page 8)
-	if cpu.Cycles != 6 {
-		t.Errorf("Expected 6 cycles after reset, got %d", cpu.Cycles)
+	if cpu.TotalCycles != 6 {
+		t.Errorf("Expected reset to take 6 cycles, got %d", cpu.TotalCycles)
 	}
 }
 
@@ -153,32 +156,22 @@ func TestClockCycling(t *testing.T) {
 
 	cpu.Reset()
 
-	// After reset, cycles should be 6
-	initialCycles := cpu.Cycles
-	if initialCycles != 6 {
-		t.Errorf("Expected 6 cycles after reset (per NMOS 6502 datasheet page 8), got %d", initialCycles)
+	// The reset sequence runs at once, taking 6 cycles
+	if cpu.TotalCycles != 6 || cpu.Cycles != 0 {
+		t.Errorf("Expected 6 cycles run by reset (per NMOS 6502 datasheet page 8), got %d with %d pending",
+			cpu.TotalCycles, cpu.Cycles)
 	}
 
-	// Execute cycles until the reset cycles are consumed (6 cycles)
-	for i := 0; i < 6; i++ {
-		cpu.Step()
-	}
-
-	// After 6 steps, the reset cycles should be consumed
-	// Now the CPU should fetch the next instruction
-	if cpu.Cycles != 0 {
-		t.Errorf("Expected 0 cycles after consuming reset cycles, got %d", cpu.Cycles)
+	// The first Step fetches the NOP
+	cpu.Step()
+	if cpu.Cycles != 1 || cpu.PC != 0x8001 {
+		t.Errorf("Expected NOP in progress after 1 cycle, got %d cycles run, PC=0x%04X", cpu.Cycles, cpu.PC)
 	}
 
-	// Step once to fetch and execute NOP
-	// Note: Step() fetches the instruction, sets cycles to instruction.Cycles,
-	// then decrements by 1, so after one Step() with a 2-cycle instruction,
-	// cycles will be 1
+	// NOP takes 2 cycles, so the second Step completes it
 	cpu.Step()
-
-	// NOP takes 2 cycles, so after one Step() that fetches it, cycles should be 1
-	if cpu.Cycles != 1 {
-		t.Errorf("Expected 1 cycle remaining after fetching NOP, got %d", cpu.Cycles)
+	if cpu.Cycles != 0 || cpu.TotalCycles != 8 {
+		t.Errorf("Expected NOP complete after 2 cycles, got %d cycles run, %d total", cpu.Cycles, cpu.TotalCycles)
 	}
 
 	// PC should have advanced by 1 (NOP is 1 byte)
@@ -188,34 +181,32 @@ func TestClockCycling(t *testing.T) {
 	}
 }
 
-// TestStepExecution tests that Step() correctly decrements cycles.
+// TestStepExecution tests that each Step() makes one bus access and that
+// the registers change in the cycle that changes them.
 func TestStepExecution(t *testing.T) {
 	bus := NewSimpleRAM()
 	cpu := NewCPU(bus)
 
 	bus.SetResetVector(0x8000)
+	bus.LoadProgram(0x8000, []byte{0xAD, 0x00, 0x30}) // LDA $3000
+	bus.memory[0x3000] = 0x42
 	cpu.Reset()
 
-	// cycles should be 6 after reset (per NMOS 6502 datasheet page 8)
-	if cpu.Cycles != 6 {
-		t.Fatalf("Expected 6 cycles after reset, got %d", cpu.Cycles)
-	}
+	var accesses int
+	cpu.SetBusTrace(func(core.BusCycle) { accesses++ })
 
-	// Step through the reset cycles
-	for cpu.Cycles > 0 {
-		before := cpu.Cycles
+	for i := 1; i <= 4; i++ {
 		cpu.Step()
-		after := cpu.Cycles
-
-		// Each Step() should decrement cycles by 1
-		if before-after != 1 {
-			t.Errorf("Expected cycles to decrement by 1, went from %d to %d", before, after)
+		if accesses != i {
+			t.Errorf("cycle %d: expected %d bus accesses, got %d", i, i, accesses)
+		}
+		if i < 4 && (cpu.Cycles != byte(i) || cpu.A != 0x00 || cpu.PC != 0x8000+uint16(i)) {
+			t.Errorf("cycle %d: expected LDA in progress, got %d cycles run, A=0x%02X PC=0x%04X", i, cpu.Cycles, cpu.A, cpu.PC)
 		}
 	}
 
-	// After all cycles are consumed, cycles should be 0
-	if cpu.Cycles != 0 {
-		t.Errorf("Expected 0 cycles after stepping through reset, got %d", cpu.Cycles)
+	if cpu.Cycles != 0 || cpu.A != 0x42 || cpu.PC != 0x8003 {
+		t.Errorf("Expected LDA complete, got %d cycles run, A=0x%02X PC=0x%04X", cpu.Cycles, cpu.A, cpu.PC)
 	}
 }
 
@@ -522,6 +513,12 @@ func TestCycleAccuracy(t *testing.T) {
 		{"TAX", []byte{0xAA}, 2},
 		{"INX", []byte{0xE8}, 2},
 		{"DEX", []byte{0xCA}, 2},
+		{"LDA Zero Page,X", []byte{0xB5, 0x10}, 4},
+		{"STY Zero Page,X", []byte{0x94, 0x10}, 4},
+		{"LDA Absolute,X", []byte{0xBD, 0x00, 0x02}, 4},
+		{"ROL Absolute", []byte{0x2E, 0x00, 0x02}, 6},
+		{"INC Zero Page,X", []byte{0xF6, 0x10}, 6},
+		{"STA Absolute,X", []byte{0x9D, 0x00, 0x02}, 5},
 	}
 
 	for _, tt := range tests {
@@ -534,31 +531,347 @@ func TestCycleAccuracy(t *testing.T) {
 
 			cpu.Reset()
 
-			// Consume reset cycles
-			for cpu.Cycles > 0 {
-				cpu.Step()
+			// Step until the instruction completes
+			steps := byte(1)
+			for cpu.Step(); cpu.Cycles > 0; cpu.Step() {
+				steps++
 			}
 
-			// Execute instruction - Step() fetches it and decrements cycles by 1
-			// So after one Step(), cycles will be instruction.Cycles - 1
-			cpu.Step()
-
-			expectedRemaining := tt.expectedCycles - 1
-			if cpu.Cycles != expectedRemaining {
-				t.Errorf("Expected %d cycles remaining (instruction takes %d cycles, Step decrements by 1), got %d",
-					expectedRemaining, tt.expectedCycles, cpu.Cycles)
+			if steps != tt.expectedCycles {
+				t.Errorf("Expected instruction to take %d cycles, took %d", tt.expectedCycles, steps)
 			}
+		})
+	}
+}
 
-			// Now step through the remaining cycles to verify the total
-			stepsNeeded := int(cpu.Cycles)
-			for i := 0; i < stepsNeeded; i++ {
-				cpu.Step()
-			}
+// TestOpcodeTable checks the public opcode table for internal consistency.
+func TestOpcodeTable(t *testing.T) {
+	table := Opcodes()
+
+	for i, op := range table {
+		if op.Handler == nil {
+			t.Errorf("opcode 0x%02X: no handler", i)
+			continue
+		}
+		if op.Code != byte(i) {
+			t.Errorf("opcode 0x%02X: Code is 0x%02X", i, op.Code)
+		}
+		if op.Length != op.Mode.Length() {
+			t.Errorf("opcode 0x%02X: Length %d does not match mode", i, op.Length)
+		}
+		if op.BranchCycle != (op.Mode == core.Relative) {
+			t.Errorf("opcode 0x%02X: BranchCycle=%v for mode %d", i, op.BranchCycle, op.Mode)
+		}
+		if op.Cycles < 2 || op.Cycles > 8 {
+			t.Errorf("opcode 0x%02X: unexpected base cycles %d", i, op.Cycles)
+		}
+	}
 
-			// After all cycles are consumed, the CPU should be ready to fetch the next instruction
-			if cpu.Cycles != 0 {
-				t.Errorf("Expected 0 cycles after consuming all instruction cycles, got %d", cpu.Cycles)
+	// Only indexed reads pay for crossing a page
+	for _, opcode := range []byte{0xBD, 0xB9, 0xB1, 0x7D, 0xDD, 0xBE, 0xBC, 0xBF, 0xBB, 0x1C} {
+		if !table[opcode].PageCrossCycle {
+			t.Errorf("opcode 0x%02X: expected page-cross penalty", opcode)
+		}
+	}
+	for _, opcode := range []byte{0x9D, 0x99, 0x91, 0x1E, 0xFE, 0xDB, 0x9F, 0x9B} {
+		if table[opcode].PageCrossCycle {
+			t.Errorf("opcode 0x%02X: unexpected page-cross penalty", opcode)
+		}
+	}
+
+	// The table is a copy
+	table[0xA9].Mnemonic = "XXX"
+	if op, _ := LookupOpcode(0xA9); op.Mnemonic != "LDA" {
+		t.Errorf("Opcodes() returned a shared table")
+	}
+}
+
+// TestPageCrossPenalty checks that the executor adds the page-crossing
+// cycle for indexed reads but not for indexed writes.
+func TestPageCrossPenalty(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []byte
+		cycles  int
+	}{
+		{"LDA abs,X same page", []byte{0xBD, 0x00, 0x30}, 4},
+		{"LDA abs,X page cross", []byte{0xBD, 0xFF, 0x30}, 5},
+		{"STA abs,X page cross", []byte{0x9D, 0xFF, 0x30}, 5},
+		{"CMP abs,X page cross", []byte{0xDD, 0xFF, 0x30}, 5},
+		{"ASL abs,X page cross", []byte{0x1E, 0xFF, 0x30}, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewSimpleRAM()
+			cpu := NewCPU(bus)
+			cpu.X = 0x01
+
+			if got := executeOne(cpu, bus, tt.program); got != tt.cycles {
+				t.Errorf("expected %d cycles, got %d", tt.cycles, got)
 			}
 		})
 	}
 }
+
+// TestStepInstruction checks that StepInstruction runs whole instructions
+// and reports their address, opcode and cycle count.
+func TestStepInstruction(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+	bus.LoadProgram(0x0200, []byte{
+		0xA9, 0x42, // LDA #$42
+		0xBD, 0xFF, 0x30, // LDA $30FF,X (page cross)
+		0xEA, // NOP
+		0x02, // JAM
+	})
+	cpu.PC = 0x0200
+	cpu.X = 0x01
+
+	tests := []core.StepResult{
+		{Event: core.EventInstruction, PC: 0x0200, Opcode: 0xA9, Cycles: 2},
+		{Event: core.EventInstruction, PC: 0x0202, Opcode: 0xBD, Cycles: 5},
+		{Event: core.EventInstruction, PC: 0x0205, Opcode: 0xEA, Cycles: 2},
+		{Event: core.EventInstruction, PC: 0x0206, Opcode: 0x02, Cycles: 2},
+		{Event: core.EventJammed, PC: 0x0207, Cycles: 1},
+	}
+
+	for i, want := range tests {
+		got, err := cpu.StepInstruction()
+		if got != want {
+			t.Errorf("step %d: got %+v, want %+v", i, got, want)
+		}
+		if jammed := errors.Is(err, core.ErrJammed); jammed != (want.Event == core.EventJammed) {
+			t.Errorf("step %d: unexpected error %v", i, err)
+		}
+		if cpu.Cycles != 0 {
+			t.Errorf("step %d: %d cycles left over", i, cpu.Cycles)
+		}
+	}
+}
+
+// TestStepInstructionFinishesStep checks that StepInstruction finishes an
+// instruction left partway through by Step, counting the cycles it runs.
+func TestStepInstructionFinishesStep(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+	bus.LoadProgram(0x0200, []byte{0xAD, 0x00, 0x30, 0xEA}) // LDA $3000; NOP
+	cpu.PC = 0x0200
+
+	cpu.Step()
+	got, _ := cpu.StepInstruction()
+
+	want := core.StepResult{Event: core.EventInstruction, PC: 0x0200, Opcode: 0xAD, Cycles: 3}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if cpu.PC != 0x0203 || cpu.TotalCycles != 4 {
+		t.Errorf("expected LDA complete after 4 cycles, got PC=0x%04X after %d", cpu.PC, cpu.TotalCycles)
+	}
+}
+
+// TestStepInstructionInterrupts checks that interrupt and reset sequences
+// are reported as a single step.
+func TestStepInstructionInterrupts(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+	bus.SetResetVector(0x8000)
+	bus.LoadProgram(0xFFFA, []byte{0x00, 0x90}) // NMI vector
+	bus.LoadProgram(0xFFFE, []byte{0x00, 0xA0}) // IRQ vector
+	cpu.PC = 0x0200
+	cpu.SetFlag(core.FlagInterruptDisable, false)
+
+	cpu.IRQPending = true
+	cpu.NMIPending = true
+	if got, _ := cpu.StepInstruction(); got.Event != core.EventNMI || got.PC != 0x0200 || got.Cycles != 7 || cpu.PC != 0x9000 {
+		t.Errorf("NMI: got %+v, PC=0x%04X", got, cpu.PC)
+	}
+
+	// The NMI set I, so the IRQ waits for the handler's first instruction
+	cpu.SetFlag(core.FlagInterruptDisable, false)
+	if got, _ := cpu.StepInstruction(); got.Event != core.EventIRQ || got.PC != 0x9000 || got.Cycles != 7 || cpu.PC != 0xA000 {
+		t.Errorf("IRQ: got %+v, PC=0x%04X", got, cpu.PC)
+	}
+
+	cpu.ResetPending = true
+	if got, _ := cpu.StepInstruction(); got.Event != core.EventReset || got.Cycles != 6 || cpu.PC != 0x8000 {
+		t.Errorf("reset: got %+v, PC=0x%04X", got, cpu.PC)
+	}
+}
+
+// TestRunCycles checks that RunCycles ticks exactly the requested number of
+// cycles, even when that ends partway through an instruction.
+func TestRunCycles(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+	bus.LoadProgram(0x0200, []byte{0xE8, 0xE8, 0xE8, 0xE8, 0xE8}) // INX x5
+	cpu.PC = 0x0200
+
+	if n, err := cpu.RunCycles(6); n != 6 || err != nil {
+		t.Errorf("expected 6 cycles, got %d (%v)", n, err)
+	}
+	if cpu.X != 3 || cpu.Cycles != 0 {
+		t.Errorf("expected 3 INX completed, got X=%d with %d cycles pending", cpu.X, cpu.Cycles)
+	}
+
+	cpu.RunCycles(1)
+	if cpu.X != 3 || cpu.Cycles != 1 {
+		t.Errorf("expected to stop inside the fourth INX, got X=%d with %d cycles pending", cpu.X, cpu.Cycles)
+	}
+}
+
+// TestNewCPUFromVariant checks that the NMOS CPU is registered with the core
+// factory and can be driven through core.CPU.
+func TestNewCPUFromVariant(t *testing.T) {
+	bus := NewSimpleRAM()
+	bus.LoadProgram(0x0200, []byte{0xE8}) // INX
+	bus.LoadProgram(0xFFFA, []byte{0x00, 0x90})
+
+	cpu, err := core.NewCPU(core.VariantNMOS, bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cpu.(*CPU); !ok || cpu.GetVariant() != core.VariantNMOS {
+		t.Fatalf("expected an NMOS *CPU, got %T", cpu)
+	}
+
+	cpu.SetRegisters(core.Registers{PC: 0x0200, SP: 0xFF, X: 0x41, Status: 0x24})
+	cpu.StepInstruction()
+	if r := cpu.Registers(); r.X != 0x42 || r.PC != 0x0201 {
+		t.Errorf("expected INX to run, got %+v", r)
+	}
+
+	cpu.TriggerNMI()
+	if res, _ := cpu.StepInstruction(); res.Event != core.EventNMI || cpu.Registers().PC != 0x9000 {
+		t.Errorf("expected NMI, got %+v", res)
+	}
+
+	if op, ok := cpu.LookupOpcode(0xA7); !ok || op.Mnemonic != "LAX" {
+		t.Errorf("expected LAX for $A7, got %q", op.Mnemonic)
+	}
+}
+
+// TestSnapshotRestore checks that a snapshot restores the full CPU state.
+func TestSnapshotRestore(t *testing.T) {
+	bus := NewSimpleRAM()
+	bus.LoadProgram(0x0200, []byte{0xAD, 0x00, 0x30}) // LDA $3000
+	cpu := NewCPU(bus)
+	cpu.PC = 0x0200
+	cpu.A = 0x11
+	cpu.IRQPending = true
+	cpu.Step()
+
+	snap := cpu.Snapshot()
+	cpu.RunCycles(10)
+	cpu.State = core.StateJammed
+
+	if err := cpu.Restore(snap); err != nil {
+		t.Fatal(err)
+	}
+	if got := cpu.Snapshot(); got != snap {
+		t.Errorf("got %+v, want %+v", got, snap)
+	}
+	if cpu.Cycles != 1 || cpu.PC != 0x0201 || !cpu.IRQPending {
+		t.Errorf("expected to resume mid-instruction, got PC=0x%04X cycles=%d", cpu.PC, cpu.Cycles)
+	}
+	bus.memory[0x3000] = 0x42
+	if res, _ := cpu.StepInstruction(); res.Cycles != 3 || cpu.A != 0x42 || cpu.PC != 0x0203 {
+		t.Errorf("expected LDA to finish in 3 cycles, got %+v A=0x%02X PC=0x%04X", res, cpu.A, cpu.PC)
+	}
+
+	snap.Variant = core.VariantWDC65C02
+	if err := cpu.Restore(snap); err == nil {
+		t.Error("expected an error restoring a 65C02 snapshot")
+	}
+}
+
+// TestSnapshotMidJSR checks that a snapshot taken while JSR still has its
+// pushes to make survives encoding and finishes them on another CPU.
+func TestSnapshotMidJSR(t *testing.T) {
+	bus := NewSimpleRAM()
+	bus.LoadProgram(0x0200, []byte{0x20, 0x34, 0x12}) // JSR $1234
+	cpu := NewCPU(bus)
+	cpu.PC = 0x0200
+	for i := 0; i < 3; i++ { // Opcode, low byte, stack cycle
+		cpu.Step()
+	}
+	if bus.memory[0x01FD] != 0 {
+		t.Fatal("expected the return address not to be pushed yet")
+	}
+
+	data, err := cpu.Snapshot().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap core.Snapshot
+	if err := snap.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	other := NewSimpleRAM()
+	*other = *bus
+	resumed := NewCPU(other)
+	if err := resumed.Restore(snap); err != nil {
+		t.Fatal(err)
+	}
+
+	if res, _ := resumed.StepInstruction(); res.Cycles != 3 || res.PC != 0x0200 {
+		t.Errorf("expected JSR to finish in 3 cycles, got %+v", res)
+	}
+	if resumed.PC != 0x1234 || resumed.SP != 0xFB {
+		t.Errorf("expected PC=$1234 SP=$FB, got PC=$%04X SP=$%02X", resumed.PC, resumed.SP)
+	}
+	if hi, lo := other.memory[0x01FD], other.memory[0x01FC]; hi != 0x02 || lo != 0x02 {
+		t.Errorf("expected return address $0202 on the stack, got $%02X%02X", hi, lo)
+	}
+}
+
+// TestBreakpoint checks that BreakFunc stops execution before the
+// instruction and that running again executes it.
+func TestBreakpoint(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+	bus.LoadProgram(0x0200, []byte{0xE8, 0xE8, 0x4C, 0x00, 0x02}) // INX; INX; JMP $0200
+	cpu.PC = 0x0200
+	cpu.BreakFunc = func(pc uint16) bool { return pc == 0x0201 }
+
+	for pass := 1; pass <= 2; pass++ {
+		err := cpu.Run()
+
+		var stepErr *core.StepError
+		if !errors.As(err, &stepErr) || !errors.Is(err, core.ErrBreakpoint) {
+			t.Fatalf("pass %d: expected a breakpoint, got %v", pass, err)
+		}
+		if stepErr.PC != 0x0201 || cpu.PC != 0x0201 {
+			t.Errorf("pass %d: expected to stop at $0201, got %v with PC=$%04X", pass, err, cpu.PC)
+		}
+		if want := byte(2*pass - 1); cpu.X != want {
+			t.Errorf("pass %d: expected X=%d, got %d", pass, want, cpu.X)
+		}
+	}
+}
+
+// TestUnknownOpcodeError checks that an opcode without a handler is
+// reported as an error with PC left on the opcode.
+func TestUnknownOpcodeError(t *testing.T) {
+	saved := opcodes[0xEA]
+	opcodes[0xEA].Handler = nil
+	defer func() { opcodes[0xEA] = saved }()
+
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+	bus.LoadProgram(0x0200, []byte{0xE8, 0xEA}) // INX; (no handler)
+	cpu.PC = 0x0200
+
+	n, err := cpu.RunCycles(100)
+
+	var stepErr *core.StepError
+	if !errors.As(err, &stepErr) || !errors.Is(err, core.ErrUnknownOpcode) {
+		t.Fatalf("expected an unknown opcode error, got %v", err)
+	}
+	if stepErr.PC != 0x0201 || stepErr.Opcode != 0xEA || stepErr.Cycles != 3 || n != 3 {
+		t.Errorf("unexpected error %v after %d cycles", err, n)
+	}
+	if cpu.PC != 0x0201 || cpu.Halted {
+		t.Errorf("expected PC left on the opcode, got PC=$%04X Halted=%v", cpu.PC, cpu.Halted)
+	}
+}
@@ -0,0 +1,226 @@
+package mos6502
+
+import (
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// ========== Decimal Mode Tests ==========
+
+// decimalResult holds the accumulator and flags after a decimal ADC/SBC.
+type decimalResult struct {
+	a          byte
+	c, z, n, v bool
+}
+
+// executeDecimal runs a single immediate-mode ADC or SBC with the decimal
+// flag set and returns the resulting accumulator and flags.
+func executeDecimal(cpu *CPU, bus *SimpleRAM, opcode, a, operand byte, carry bool) decimalResult {
+	bus.memory[0x0200] = opcode
+	bus.memory[0x0201] = operand
+
+	cpu.PC = 0x0200
+	cpu.A = a
+	cpu.Cycles = 0
+	cpu.SetFlag(core.FlagDecimal, true)
+	cpu.SetFlag(core.FlagCarry, carry)
+
+	cpu.Step()
+	for cpu.Cycles > 0 {
+		cpu.Step()
+	}
+
+	return decimalResult{
+		a: cpu.A,
+		c: cpu.GetFlag(core.FlagCarry),
+		z: cpu.GetFlag(core.FlagZero),
+		n: cpu.GetFlag(core.FlagNegative),
+		v: cpu.GetFlag(core.FlagOverflow),
+	}
+}
+
+// predictNMOSADC mirrors the prediction routine of the exhaustive test in
+// Bruce Clark's 6502.org "Decimal Mode" tutorial: the digits are added one at
+// a time with an explicit half-carry, and N/V are taken before the high digit
+// is adjusted.
+func predictNMOSADC(a, b byte, carry bool) decimalResult {
+	c := 0
+	if carry {
+		c = 1
+	}
+
+	lo := int(a&0x0F) + int(b&0x0F) + c
+	halfCarry := 0
+	if lo > 9 {
+		lo = (lo + 6) & 0x0F
+		halfCarry = 1
+	}
+
+	hi := int(a>>4) + int(b>>4) + halfCarry
+	unadjusted := byte(hi<<4) | byte(lo)
+	signedHi := int(int8(a&0xF0)>>4) + int(int8(b&0xF0)>>4) + halfCarry
+
+	r := decimalResult{
+		n: unadjusted&0x80 != 0,
+		v: signedHi < -8 || signedHi > 7,
+		z: a+b+byte(c) == 0,
+	}
+	if hi > 9 {
+		hi += 6
+	}
+	r.c = hi > 15
+	r.a = byte(hi<<4) | byte(lo)
+	return r
+}
+
+// predictNMOSSBC mirrors the SBC prediction of Bruce Clark's decimal test.
+// Only the accumulator is decimal; every flag matches binary subtraction.
+func predictNMOSSBC(a, b byte, carry bool) decimalResult {
+	borrow := 1
+	if carry {
+		borrow = 0
+	}
+
+	lo := int(a&0x0F) - int(b&0x0F) - borrow
+	halfBorrow := 0
+	if lo < 0 {
+		lo = (lo - 6) & 0x0F
+		halfBorrow = 1
+	}
+
+	hi := int(a>>4) - int(b>>4) - halfBorrow
+	if hi < 0 {
+		hi -= 6
+	}
+
+	binary := int(a) - int(b) - borrow
+	signed := int(int8(a)) - int(int8(b)) - borrow
+	return decimalResult{
+		a: byte(hi<<4) | byte(lo),
+		c: binary >= 0,
+		z: byte(binary) == 0,
+		n: byte(binary)&0x80 != 0,
+		v: signed < -128 || signed > 127,
+	}
+}
+
+// TestDecimalModeExhaustive checks every operand pair and carry input for
+// decimal ADC and SBC, including invalid BCD values, against the NMOS
+// behavior described in Bruce Clark's decimal mode tutorial.
+func TestDecimalModeExhaustive(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+
+	failures := 0
+	for a := 0; a < 0x100; a++ {
+		for b := 0; b < 0x100; b++ {
+			for _, carry := range []bool{false, true} {
+				got := executeDecimal(cpu, bus, 0x69, byte(a), byte(b), carry)
+				if want := predictNMOSADC(byte(a), byte(b), carry); got != want {
+					t.Errorf("ADC $%02X+$%02X C=%v: got %+v, want %+v", a, b, carry, got, want)
+					failures++
+				}
+
+				got = executeDecimal(cpu, bus, 0xE9, byte(a), byte(b), carry)
+				if want := predictNMOSSBC(byte(a), byte(b), carry); got != want {
+					t.Errorf("SBC $%02X-$%02X C=%v: got %+v, want %+v", a, b, carry, got, want)
+					failures++
+				}
+
+				if failures > 10 {
+					t.Fatal("too many failures")
+				}
+			}
+		}
+	}
+}
+
+// TestDecimalModeValidBCD checks decimal arithmetic on valid BCD operands
+// against ordinary base-10 arithmetic.
+func TestDecimalModeValidBCD(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+
+	toBCD := func(n int) byte { return byte((n/10)<<4 | n%10) }
+
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 100; y++ {
+			for c := 0; c < 2; c++ {
+				sum := x + y + c
+				got := executeDecimal(cpu, bus, 0x69, toBCD(x), toBCD(y), c == 1)
+				if got.a != toBCD(sum%100) || got.c != (sum >= 100) {
+					t.Fatalf("ADC %d+%d+%d: got A=$%02X C=%v", x, y, c, got.a, got.c)
+				}
+
+				diff := x - y - (1 - c)
+				got = executeDecimal(cpu, bus, 0xE9, toBCD(x), toBCD(y), c == 1)
+				if got.a != toBCD((diff+100)%100) || got.c != (diff >= 0) {
+					t.Fatalf("SBC %d-%d-%d: got A=$%02X C=%v", x, y, 1-c, got.a, got.c)
+				}
+			}
+		}
+	}
+}
+
+// TestDecimalModeFlagQuirks spot-checks the documented NMOS flag oddities.
+func TestDecimalModeFlagQuirks(t *testing.T) {
+	tests := []struct {
+		name    string
+		opcode  byte
+		a       byte
+		operand byte
+		carry   bool
+		want    decimalResult
+	}{
+		// Z reflects the binary sum $9A, N the unadjusted $A0
+		{"ADC 99+01 zero result", 0x69, 0x99, 0x01, false, decimalResult{a: 0x00, c: true, z: false, n: true, v: false}},
+		// Binary sum $00 sets Z even though the decimal result is $A0+$60
+		{"ADC 50+B0 binary zero", 0x69, 0x50, 0xB0, false, decimalResult{a: 0x60, c: true, z: true, n: false, v: false}},
+		// 79+00+1 = 80: V set because the unadjusted sum overflows signed range
+		{"ADC 79+00+1 overflow", 0x69, 0x79, 0x00, true, decimalResult{a: 0x80, c: false, z: false, n: true, v: true}},
+		{"ADC 24+56 valid", 0x69, 0x24, 0x56, false, decimalResult{a: 0x80, c: false, z: false, n: true, v: true}},
+		// 58+46+1 = 105: the unadjusted $A5 sets both N and V
+		{"ADC 58+46+1 carry", 0x69, 0x58, 0x46, true, decimalResult{a: 0x05, c: true, z: false, n: true, v: true}},
+		// SBC flags come from the binary difference $FF
+		{"SBC 00-01 borrow", 0xE9, 0x00, 0x01, true, decimalResult{a: 0x99, c: false, z: false, n: true, v: false}},
+		{"SBC 46-12", 0xE9, 0x46, 0x12, true, decimalResult{a: 0x34, c: true, z: false, n: false, v: false}},
+		{"SBC 40-13", 0xE9, 0x40, 0x13, true, decimalResult{a: 0x27, c: true, z: false, n: false, v: false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewSimpleRAM()
+			cpu := NewCPU(bus)
+
+			got := executeDecimal(cpu, bus, tt.opcode, tt.a, tt.operand, tt.carry)
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDecimalModeCycles verifies decimal mode costs no extra cycles on NMOS.
+func TestDecimalModeCycles(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+
+	for _, opcode := range []byte{0x69, 0xE9} {
+		bus.memory[0x0200] = opcode
+		cpu.PC = 0x0200
+		cpu.Cycles = 0
+		cpu.SetFlag(core.FlagDecimal, true)
+
+		cpu.Step()
+		steps := 1
+		for cpu.Cycles > 0 {
+			cpu.Step()
+			steps++
+		}
+
+		if steps != 2 {
+			t.Errorf("opcode $%02X: expected 2 cycles in decimal mode, got %d", opcode, steps)
+		}
+	}
+}
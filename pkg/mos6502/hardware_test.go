@@ -684,3 +684,28 @@ func TestPHPBreakFlag(t *testing.T) {
 		t.Error("PHP should push status with unused flag set")
 	}
 }
+
+// TestBRKStackedStatus tests that BRK pushes status with B and the unused
+// flag set but I as it was, setting I only after the push.
+func TestBRKStackedStatus(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+
+	bus.LoadProgram(0x0200, []byte{0x00, 0xEA}) // BRK; padding byte
+	bus.LoadProgram(0xFFFE, []byte{0x00, 0x03})
+	cpu.PC = 0x0200
+	cpu.Status = 0x00
+
+	if res, err := cpu.StepInstruction(); err != nil || res.Cycles != 7 {
+		t.Fatalf("expected BRK to take 7 cycles, got %+v, %v", res, err)
+	}
+	if got := bus.memory[0x01FB]; got != 0x30 {
+		t.Errorf("expected stacked status $30, got $%02X", got)
+	}
+	if cpu.Status&0x04 == 0 || cpu.Status&0x10 != 0 {
+		t.Errorf("expected I set and B clear after BRK, got status $%02X", cpu.Status)
+	}
+	if cpu.PC != 0x0300 {
+		t.Errorf("expected PC=$0300, got $%04X", cpu.PC)
+	}
+}
@@ -0,0 +1,446 @@
+package mos6502
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// ========== Undocumented Opcode Tests ==========
+
+// executeOne runs the instruction at $0200 to completion and returns the
+// number of cycles it took.
+func executeOne(cpu *CPU, bus *SimpleRAM, program []byte) int {
+	bus.LoadProgram(0x0200, program)
+	cpu.PC = 0x0200
+	cpu.Cycles = 0
+
+	cpu.Step()
+	cycles := 1
+	for cpu.Cycles > 0 {
+		cpu.Step()
+		cycles++
+	}
+	return cycles
+}
+
+// TestIllegalReadModifyWrite checks the combined RMW+ALU opcodes in zero
+// page mode: memory is modified and the result fed to the accumulator.
+func TestIllegalReadModifyWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		opcode  byte
+		a       byte
+		mem     byte
+		carry   bool
+		wantA   byte
+		wantMem byte
+		wantC   bool
+		wantZ   bool
+		wantN   bool
+	}{
+		{"SLO", 0x07, 0x01, 0x81, false, 0x03, 0x02, true, false, false},
+		{"RLA", 0x27, 0xFF, 0x40, true, 0x81, 0x81, false, false, true},
+		{"SRE", 0x47, 0x01, 0x03, false, 0x00, 0x01, true, true, false},
+		{"RRA", 0x67, 0x10, 0x02, true, 0x91, 0x81, false, false, true},
+		{"DCP equal", 0xC7, 0x41, 0x42, false, 0x41, 0x41, true, true, false},
+		{"DCP less", 0xC7, 0x10, 0x00, false, 0x10, 0xFF, false, false, false},
+		{"ISC", 0xE7, 0x10, 0x04, true, 0x0B, 0x05, true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewSimpleRAM()
+			cpu := NewCPU(bus)
+			bus.memory[0x0010] = tt.mem
+			cpu.A = tt.a
+			cpu.SetFlag(core.FlagCarry, tt.carry)
+
+			cycles := executeOne(cpu, bus, []byte{tt.opcode, 0x10})
+
+			if cpu.A != tt.wantA {
+				t.Errorf("A: expected 0x%02X, got 0x%02X", tt.wantA, cpu.A)
+			}
+			if bus.memory[0x0010] != tt.wantMem {
+				t.Errorf("memory: expected 0x%02X, got 0x%02X", tt.wantMem, bus.memory[0x0010])
+			}
+			if cpu.GetFlag(core.FlagCarry) != tt.wantC {
+				t.Errorf("C: expected %v", tt.wantC)
+			}
+			if cpu.GetFlag(core.FlagZero) != tt.wantZ {
+				t.Errorf("Z: expected %v", tt.wantZ)
+			}
+			if cpu.GetFlag(core.FlagNegative) != tt.wantN {
+				t.Errorf("N: expected %v", tt.wantN)
+			}
+			if cycles != 5 {
+				t.Errorf("expected 5 cycles, got %d", cycles)
+			}
+		})
+	}
+}
+
+// TestIllegalLAXSAX checks the combined load and store of A and X.
+func TestIllegalLAXSAX(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+
+	bus.memory[0x0010] = 0x8F
+	executeOne(cpu, bus, []byte{0xA7, 0x10}) // LAX $10
+	if cpu.A != 0x8F || cpu.X != 0x8F {
+		t.Errorf("LAX: expected A=X=0x8F, got A=0x%02X X=0x%02X", cpu.A, cpu.X)
+	}
+	if !cpu.GetFlag(core.FlagNegative) {
+		t.Error("LAX: expected N set")
+	}
+
+	cpu.A = 0xF0
+	cpu.X = 0x3C
+	cpu.Status = 0x24
+	executeOne(cpu, bus, []byte{0x87, 0x20}) // SAX $20
+	if bus.memory[0x0020] != 0x30 {
+		t.Errorf("SAX: expected 0x30, got 0x%02X", bus.memory[0x0020])
+	}
+	if cpu.Status != 0x24 {
+		t.Errorf("SAX: expected flags unchanged, got 0x%02X", cpu.Status)
+	}
+}
+
+// TestIllegalImmediate checks the immediate-mode combined ALU opcodes.
+func TestIllegalImmediate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opcode  byte
+		a, x    byte
+		operand byte
+		carry   bool
+		decimal bool
+		wantA   byte
+		wantX   byte
+		wantC   bool
+		wantV   bool
+	}{
+		{"ANC negative", 0x0B, 0xF0, 0x00, 0x80, false, false, 0x80, 0x00, true, false},
+		{"ANC alias", 0x2B, 0x7F, 0x00, 0x3F, true, false, 0x3F, 0x00, false, false},
+		{"ALR", 0x4B, 0xFF, 0x00, 0x03, false, false, 0x01, 0x00, true, false},
+		{"ARR C from bit 6", 0x6B, 0xFF, 0x00, 0xFF, true, false, 0xFF, 0x00, true, false},
+		{"ARR V from bit 6 xor 5", 0x6B, 0xFF, 0x00, 0x80, false, false, 0x40, 0x00, true, true},
+		{"ARR decimal", 0x6B, 0xFF, 0x00, 0xFF, false, true, 0xD5, 0x00, true, false},
+		{"SBX", 0xCB, 0xF3, 0x3F, 0x02, false, false, 0xF3, 0x31, true, false},
+		{"SBX borrow", 0xCB, 0x0F, 0x01, 0x02, false, false, 0x0F, 0xFF, false, false},
+		{"USBC", 0xEB, 0x10, 0x00, 0x01, true, false, 0x0F, 0x00, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewSimpleRAM()
+			cpu := NewCPU(bus)
+			cpu.A = tt.a
+			cpu.X = tt.x
+			cpu.SetFlag(core.FlagCarry, tt.carry)
+			cpu.SetFlag(core.FlagDecimal, tt.decimal)
+
+			cycles := executeOne(cpu, bus, []byte{tt.opcode, tt.operand})
+
+			if cpu.A != tt.wantA {
+				t.Errorf("A: expected 0x%02X, got 0x%02X", tt.wantA, cpu.A)
+			}
+			if cpu.X != tt.wantX {
+				t.Errorf("X: expected 0x%02X, got 0x%02X", tt.wantX, cpu.X)
+			}
+			if cpu.GetFlag(core.FlagCarry) != tt.wantC {
+				t.Errorf("C: expected %v", tt.wantC)
+			}
+			if cpu.GetFlag(core.FlagOverflow) != tt.wantV {
+				t.Errorf("V: expected %v", tt.wantV)
+			}
+			if cycles != 2 {
+				t.Errorf("expected 2 cycles, got %d", cycles)
+			}
+		})
+	}
+}
+
+// TestIllegalTiming checks lengths, cycle counts and page-crossing penalties
+// across the addressing modes of the undocumented opcodes.
+func TestIllegalTiming(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []byte
+		cycles  int
+	}{
+		{"SLO (zp,X)", []byte{0x03, 0x10}, 8},
+		{"RLA abs", []byte{0x2F, 0x00, 0x30}, 6},
+		{"SRE (zp),Y", []byte{0x53, 0x10}, 8},
+		{"RRA zp,X", []byte{0x77, 0x10}, 6},
+		{"DCP abs,Y page cross", []byte{0xDB, 0xFF, 0x30}, 7},
+		{"ISC abs,X page cross", []byte{0xFF, 0xFF, 0x30}, 7},
+		{"LAX abs,Y", []byte{0xBF, 0x00, 0x30}, 4},
+		{"LAX abs,Y page cross", []byte{0xBF, 0xFF, 0x30}, 5},
+		{"LAX (zp),Y page cross", []byte{0xB3, 0x10}, 6},
+		{"LAX zp,Y", []byte{0xB7, 0x10}, 4},
+		{"SAX (zp,X)", []byte{0x83, 0x10}, 6},
+		{"NOP implied", []byte{0x1A}, 2},
+		{"NOP immediate", []byte{0x80, 0x00}, 2},
+		{"NOP zp", []byte{0x04, 0x10}, 3},
+		{"NOP zp,X", []byte{0x14, 0x10}, 4},
+		{"NOP abs", []byte{0x0C, 0x00, 0x30}, 4},
+		{"NOP abs,X", []byte{0x1C, 0x00, 0x30}, 4},
+		{"NOP abs,X page cross", []byte{0x1C, 0xFF, 0x30}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewSimpleRAM()
+			cpu := NewCPU(bus)
+			cpu.X = 0x01
+			cpu.Y = 0x01
+			bus.memory[0x0010] = 0xFF // (zp),Y pointer $00FF crosses into $0100
+			bus.memory[0x0011] = 0x00
+
+			cycles := executeOne(cpu, bus, tt.program)
+
+			if cpu.Halted {
+				t.Fatal("CPU halted on undocumented opcode")
+			}
+			if cycles != tt.cycles {
+				t.Errorf("expected %d cycles, got %d", tt.cycles, cycles)
+			}
+			if want := uint16(0x0200 + len(tt.program)); cpu.PC != want {
+				t.Errorf("expected PC 0x%04X, got 0x%04X", want, cpu.PC)
+			}
+		})
+	}
+}
+
+// TestLookupOpcodeIllegal checks that tools can tell undocumented opcodes
+// from documented ones.
+func TestLookupOpcodeIllegal(t *testing.T) {
+	tests := []struct {
+		opcode   byte
+		mnemonic string
+		illegal  bool
+	}{
+		{0xA9, "LDA", false},
+		{0xE9, "SBC", false},
+		{0xEA, "NOP", false},
+		{0xA7, "LAX", true},
+		{0xEB, "SBC", true},
+		{0x1A, "NOP", true},
+		{0xDF, "DCP", true},
+		{0x02, "JAM", true},
+	}
+
+	for _, tt := range tests {
+		op, ok := LookupOpcode(tt.opcode)
+		if !ok {
+			t.Errorf("opcode 0x%02X: not found", tt.opcode)
+			continue
+		}
+		if op.Mnemonic != tt.mnemonic || op.Illegal != tt.illegal || op.Code != tt.opcode {
+			t.Errorf("opcode 0x%02X: got %s illegal=%v", tt.opcode, op.Mnemonic, op.Illegal)
+		}
+	}
+
+	for opcode := 0; opcode < 0x100; opcode++ {
+		if _, ok := LookupOpcode(byte(opcode)); !ok {
+			t.Errorf("opcode 0x%02X: not found", opcode)
+		}
+	}
+}
+
+// ========== Unstable Opcode Tests ==========
+
+// TestUnstableMagicConstant checks that ANE and LXA use the configured
+// magic constant.
+func TestUnstableMagicConstant(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode byte
+		magic  byte
+		wantA  byte
+		wantX  byte
+	}{
+		{"ANE default magic", 0x8B, 0xEE, 0x0F, 0x0F},
+		{"ANE magic FF", 0x8B, 0xFF, 0x0F, 0x0F},
+		{"ANE magic 00", 0x8B, 0x00, 0x01, 0x0F},
+		{"LXA default magic", 0xAB, 0xEE, 0x2F, 0x2F},
+		{"LXA magic 00", 0xAB, 0x00, 0x01, 0x01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewSimpleRAM()
+			cpu := NewCPU(bus)
+			cpu.Unstable.Magic = tt.magic
+			cpu.A = 0x01
+			cpu.X = 0x0F
+
+			cycles := executeOne(cpu, bus, []byte{tt.opcode, 0x3F})
+
+			if cpu.A != tt.wantA || cpu.X != tt.wantX {
+				t.Errorf("expected A=0x%02X X=0x%02X, got A=0x%02X X=0x%02X", tt.wantA, tt.wantX, cpu.A, cpu.X)
+			}
+			if cycles != 2 {
+				t.Errorf("expected 2 cycles, got %d", cycles)
+			}
+		})
+	}
+}
+
+// TestUnstableSHxStores checks the AND with the high byte plus one, the
+// corrupted target address on page crossings, and the RDY variant that
+// drops the AND.
+func TestUnstableSHxStores(t *testing.T) {
+	tests := []struct {
+		name      string
+		program   []byte
+		x, y      byte
+		dropAND   bool
+		wantAddr  uint16
+		wantValue byte
+		cycles    int
+	}{
+		{"SHX abs,Y", []byte{0x9E, 0x00, 0x12}, 0xF7, 0x01, false, 0x1201, 0x13 & 0xF7, 5},
+		{"SHY abs,X", []byte{0x9C, 0x00, 0x12}, 0x02, 0x7E, false, 0x1202, 0x13 & 0x7E, 5},
+		{"SHA abs,Y", []byte{0x9F, 0x00, 0x12}, 0xF7, 0x01, false, 0x1201, 0x13 & 0xF7 & 0xFE, 5},
+		{"SHA (zp),Y", []byte{0x93, 0x10}, 0xF7, 0x01, false, 0x1201, 0x13 & 0xF7 & 0xFE, 6},
+		{"TAS abs,Y", []byte{0x9B, 0x00, 0x12}, 0xF7, 0x01, false, 0x1201, 0x13 & 0xF7 & 0xFE, 5},
+		{"SHX abs,Y page cross", []byte{0x9E, 0xFF, 0x12}, 0x07, 0x01, false, 0x0300, 0x13 & 0x07, 5},
+		{"SHX drop AND", []byte{0x9E, 0x00, 0x12}, 0xF7, 0x01, true, 0x1201, 0xF7, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewSimpleRAM()
+			cpu := NewCPU(bus)
+			cpu.Unstable.SHxDropAND = tt.dropAND
+			cpu.A = 0xFE
+			cpu.X = tt.x
+			cpu.Y = tt.y
+			bus.memory[0x0010] = 0x00 // (zp),Y pointer $1200
+			bus.memory[0x0011] = 0x12
+
+			cycles := executeOne(cpu, bus, tt.program)
+
+			if got := bus.memory[tt.wantAddr]; got != tt.wantValue {
+				t.Errorf("expected 0x%02X at 0x%04X, got 0x%02X", tt.wantValue, tt.wantAddr, got)
+			}
+			if cycles != tt.cycles {
+				t.Errorf("expected %d cycles, got %d", tt.cycles, cycles)
+			}
+		})
+	}
+}
+
+// TestUnstableTASLAS checks the stack pointer side effects of TAS and LAS.
+func TestUnstableTASLAS(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+
+	cpu.A = 0xF3
+	cpu.X = 0x3F
+	cpu.Y = 0x00
+	executeOne(cpu, bus, []byte{0x9B, 0x00, 0x30}) // TAS $3000,Y
+	if cpu.SP != 0x33 {
+		t.Errorf("TAS: expected SP=0x33, got 0x%02X", cpu.SP)
+	}
+
+	bus.memory[0x3100] = 0x96
+	cpu.Y = 0x01
+	cycles := executeOne(cpu, bus, []byte{0xBB, 0xFF, 0x30}) // LAS $30FF,Y
+	if cpu.A != 0x12 || cpu.X != 0x12 || cpu.SP != 0x12 {
+		t.Errorf("LAS: expected A=X=SP=0x12, got A=0x%02X X=0x%02X SP=0x%02X", cpu.A, cpu.X, cpu.SP)
+	}
+	if cycles != 5 {
+		t.Errorf("LAS: expected 5 cycles with page cross, got %d", cycles)
+	}
+}
+
+// ========== JAM Tests ==========
+
+// TestJAMLocksCPU checks that every JAM opcode enters the jammed state and
+// stops fetching instructions.
+func TestJAMLocksCPU(t *testing.T) {
+	for _, opcode := range []byte{0x02, 0x12, 0x22, 0x32, 0x42, 0x52, 0x62, 0x72, 0x92, 0xB2, 0xD2, 0xF2} {
+		bus := NewSimpleRAM()
+		cpu := NewCPU(bus)
+
+		executeOne(cpu, bus, []byte{opcode, 0xE8}) // JAM; INX
+		for i := 0; i < 10; i++ {
+			cpu.Step()
+		}
+
+		if cpu.State != core.StateJammed {
+			t.Errorf("opcode 0x%02X: expected state %v, got %v", opcode, core.StateJammed, cpu.State)
+		}
+		if cpu.X != 0 || cpu.PC != 0x0201 {
+			t.Errorf("opcode 0x%02X: CPU kept executing (X=0x%02X PC=0x%04X)", opcode, cpu.X, cpu.PC)
+		}
+		if cpu.Halted {
+			t.Errorf("opcode 0x%02X: JAM should not use the generic Halted flag", opcode)
+		}
+	}
+}
+
+// TestJAMIgnoresInterrupts checks that IRQ and NMI are not serviced while
+// jammed and that only a reset recovers the CPU.
+func TestJAMIgnoresInterrupts(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+	bus.SetResetVector(0x8000)
+	bus.memory[0xFFFA] = 0x00 // NMI vector $9000
+	bus.memory[0xFFFB] = 0x90
+	bus.memory[0xFFFE] = 0x00 // IRQ vector $9100
+	bus.memory[0xFFFF] = 0x91
+
+	executeOne(cpu, bus, []byte{0x02})
+	cpu.SetFlag(core.FlagInterruptDisable, false)
+	cpu.NMIPending = true
+	cpu.IRQPending = true
+	sp := cpu.SP
+	for i := 0; i < 20; i++ {
+		cpu.Step()
+	}
+
+	if cpu.State != core.StateJammed || cpu.PC != 0x0201 || cpu.SP != sp {
+		t.Fatalf("interrupt serviced while jammed: state=%v PC=0x%04X SP=0x%02X", cpu.State, cpu.PC, cpu.SP)
+	}
+
+	cpu.ResetPending = true
+	cpu.StepInstruction()
+
+	if cpu.State != core.StateRunning {
+		t.Errorf("expected state %v after reset, got %v", core.StateRunning, cpu.State)
+	}
+	if cpu.PC != 0x8000 {
+		t.Errorf("expected PC=0x8000 after reset, got 0x%04X", cpu.PC)
+	}
+}
+
+// TestJAMStopsRun checks that Run returns ErrJammed once the CPU jams.
+func TestJAMStopsRun(t *testing.T) {
+	bus := NewSimpleRAM()
+	cpu := NewCPU(bus)
+	bus.SetResetVector(0x8000)
+	bus.LoadProgram(0x8000, []byte{0xE8, 0xE8, 0x02}) // INX; INX; JAM
+
+	cpu.Reset()
+	err := cpu.Run()
+
+	if cpu.State != core.StateJammed || cpu.X != 2 {
+		t.Errorf("expected jammed after two INX, got state=%v X=%d", cpu.State, cpu.X)
+	}
+
+	var stepErr *core.StepError
+	if !errors.As(err, &stepErr) || !errors.Is(err, core.ErrJammed) {
+		t.Fatalf("expected a jammed StepError, got %v", err)
+	}
+	if stepErr.PC != 0x8002 || stepErr.Opcode != 0x02 {
+		t.Errorf("expected JAM at $8002, got %v", err)
+	}
+	if want := uint64(6 + 2 + 2 + 2 + 1); stepErr.Cycles != want {
+		t.Errorf("expected the error at cycle %d, got %d", want, stepErr.Cycles)
+	}
+}
@@ -6,26 +6,39 @@ import "github.com/andrewthecodertx/go-6502-emulator/pkg/core"
 
 // ADC adds with carry.
 func ADC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
+	addWithCarry(c, data)
+}
+
+// SBC subtracts with carry.
+func SBC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	data := c.Read(addr)
+	subtractWithCarry(c, data)
+}
+
+// addWithCarry adds data and the carry flag to the accumulator.
+// Shared by ADC and the undocumented RRA instruction.
+func addWithCarry(c *core.BaseCPU, data byte) {
 	carry := byte(0)
 	if c.GetFlag(core.FlagCarry) {
 		carry = 1
 	}
 
+	if c.GetFlag(core.FlagDecimal) {
+		adcDecimal(c, data, carry)
+		return
+	}
+
 	result := uint16(c.A) + uint16(data) + uint16(carry)
 	c.SetFlag(core.FlagCarry, result > 0xFF) // Carry
 	c.SetFlag(core.FlagOverflow, ((uint16(c.A)^result)&(uint16(data)^result))&0x80 != 0) // Overflow
 	c.A = byte(result)
 	c.SetZN(c.A)
-
-	if pageCrossed {
-		c.Cycles++
-	}
 }
 
-// SBC subtracts with carry.
-func SBC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+// subtractWithCarry subtracts data and the inverted carry flag from the
+// accumulator. Shared by SBC and the undocumented ISC instruction.
+func subtractWithCarry(c *core.BaseCPU, data byte) {
 	carry := byte(0)
 	if c.GetFlag(core.FlagCarry) {
 		carry = 1
@@ -34,12 +47,59 @@ func SBC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 	result := uint16(c.A) - uint16(data) - (1 - uint16(carry))
 	c.SetFlag(core.FlagCarry, result < 0x100) // Carry (borrow)
 	c.SetFlag(core.FlagOverflow, ((uint16(c.A)^result)&(^uint16(data)^result))&0x80 != 0) // Overflow
-	c.A = byte(result)
-	c.SetZN(c.A)
+	c.SetZN(byte(result)) // N, V, Z and C always come from the binary result
+	if c.GetFlag(core.FlagDecimal) {
+		c.A = sbcDecimal(c.A, data, carry)
+	} else {
+		c.A = byte(result)
+	}
+}
+
+// adcDecimal performs a BCD addition the way the NMOS 6502 does.
+//
+// The accumulator and carry follow sequence 1 of Bruce Clark's "Decimal Mode"
+// tutorial, so they are correct for valid BCD operands and reproduce the
+// silicon for invalid ones. The flags are the NMOS quirks:
+//   - N and V are computed from the intermediate result before the high
+//     nibble is decimal adjusted (sequence 2)
+//   - Z is computed from the plain binary sum, so $99+$01 leaves Z clear
+func adcDecimal(c *core.BaseCPU, data, carry byte) {
+	binary := c.A + data + carry
+
+	low := int(c.A&0x0F) + int(data&0x0F) + int(carry)
+	if low >= 0x0A {
+		low = ((low + 0x06) & 0x0F) + 0x10
+	}
+
+	sum := int(c.A&0xF0) + int(data&0xF0) + low
+	signed := int(int8(c.A&0xF0)) + int(int8(data&0xF0)) + low
+
+	c.SetFlag(core.FlagNegative, sum&0x80 != 0)
+	c.SetFlag(core.FlagOverflow, signed < -128 || signed > 127)
+	c.SetFlag(core.FlagZero, binary == 0)
 
-	if pageCrossed {
-		c.Cycles++
+	if sum >= 0xA0 {
+		sum += 0x60
 	}
+	c.SetFlag(core.FlagCarry, sum >= 0x100)
+	c.A = byte(sum)
+}
+
+// sbcDecimal returns the accumulator produced by an NMOS 6502 BCD
+// subtraction (sequence 3 of Bruce Clark's "Decimal Mode" tutorial).
+// The flags are not affected by decimal mode on the NMOS part; SBC sets
+// them from the binary difference before calling this.
+func sbcDecimal(a, data, carry byte) byte {
+	low := int(a&0x0F) - int(data&0x0F) + int(carry) - 1
+	if low < 0 {
+		low = ((low - 0x06) & 0x0F) - 0x10
+	}
+
+	result := int(a&0xF0) - int(data&0xF0) + low
+	if result < 0 {
+		result -= 0x60
+	}
+	return byte(result)
 }
 
 // compare is a helper function for comparison operations.
@@ -52,22 +112,18 @@ func compare(c *core.BaseCPU, a, b byte) {
 
 // CMP compares the accumulator.
 func CMP(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	compare(c, c.A, data)
-
-	if pageCrossed {
-		c.Cycles++
-	}
 }
 
 // CPX compares the X register.
 func CPX(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	compare(c, c.X, data)
 }
 
 // CPY compares the Y register.
 func CPY(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	compare(c, c.Y, data)
 }
@@ -0,0 +1,155 @@
+package instructions
+
+import "github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+
+// Undocumented (illegal) instructions for NMOS 6502
+//
+// These are the stable opcodes that behave identically across NMOS parts.
+// Most combine a read-modify-write instruction with an ALU operation on the
+// accumulator, because the decoder enables both at once. Behavior follows
+// "No More Secrets - NMOS 6510 Unintended Opcodes".
+//
+// The indexed read-modify-write forms always take their fixed cycle count,
+// so only the pure reads (LAX and the NOPs) add a page-crossing penalty.
+
+// LAX loads a byte from memory into both the accumulator and X.
+func LAX(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	data := c.Read(addr)
+	c.A = data
+	c.X = data
+	c.SetZN(data)
+}
+
+// SAX stores the accumulator ANDed with X. No flags are affected.
+func SAX(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	c.Write(addr, c.A&c.X)
+}
+
+// DCP decrements memory, then compares the accumulator with the result.
+func DCP(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	old := c.Read(addr)
+	data := old - 1
+	c.WriteModified(addr, old, data)
+	compare(c, c.A, data)
+}
+
+// ISC increments memory, then subtracts the result from the accumulator.
+func ISC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	old := c.Read(addr)
+	data := old + 1
+	c.WriteModified(addr, old, data)
+	subtractWithCarry(c, data)
+}
+
+// SLO shifts memory left, then ORs the result into the accumulator.
+func SLO(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	data := c.Read(addr)
+	c.SetFlag(core.FlagCarry, data&0x80 != 0) // Carry
+	result := data << 1
+	c.WriteModified(addr, data, result)
+	c.A |= result
+	c.SetZN(c.A)
+}
+
+// RLA rotates memory left, then ANDs the result into the accumulator.
+func RLA(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	data := c.Read(addr)
+	carry := byte(0)
+	if c.GetFlag(core.FlagCarry) {
+		carry = 1
+	}
+	c.SetFlag(core.FlagCarry, data&0x80 != 0) // Carry
+	result := (data << 1) | carry
+	c.WriteModified(addr, data, result)
+	c.A &= result
+	c.SetZN(c.A)
+}
+
+// SRE shifts memory right, then EORs the result into the accumulator.
+func SRE(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	data := c.Read(addr)
+	c.SetFlag(core.FlagCarry, data&0x01 != 0) // Carry
+	result := data >> 1
+	c.WriteModified(addr, data, result)
+	c.A ^= result
+	c.SetZN(c.A)
+}
+
+// RRA rotates memory right, then adds the result to the accumulator using
+// the carry shifted out of memory.
+func RRA(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	data := c.Read(addr)
+	carry := byte(0)
+	if c.GetFlag(core.FlagCarry) {
+		carry = 1
+	}
+	c.SetFlag(core.FlagCarry, data&0x01 != 0) // Carry
+	result := (data >> 1) | (carry << 7)
+	c.WriteModified(addr, data, result)
+	addWithCarry(c, result)
+}
+
+// ANC ANDs the accumulator with an immediate value and copies N into C.
+func ANC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	c.A &= c.Read(addr)
+	c.SetZN(c.A)
+	c.SetFlag(core.FlagCarry, c.A&0x80 != 0) // Carry from bit 7
+}
+
+// ALR ANDs the accumulator with an immediate value, then shifts it right.
+func ALR(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	c.A &= c.Read(addr)
+	c.SetFlag(core.FlagCarry, c.A&0x01 != 0) // Carry
+	c.A >>= 1
+	c.SetZN(c.A)
+}
+
+// ARR ANDs the accumulator with an immediate value, then rotates it right.
+// C and V come from bits 6 and 5 of the result, as the adder sees them. In
+// decimal mode the result is further adjusted like a BCD addition.
+func ARR(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	data := c.A & c.Read(addr)
+	carry := byte(0)
+	if c.GetFlag(core.FlagCarry) {
+		carry = 1
+	}
+	c.A = (data >> 1) | (carry << 7)
+	c.SetZN(c.A)
+
+	if !c.GetFlag(core.FlagDecimal) {
+		c.SetFlag(core.FlagCarry, c.A&0x40 != 0)                // Carry from bit 6
+		c.SetFlag(core.FlagOverflow, (c.A>>6^c.A>>5)&0x01 != 0) // Overflow from bit 6 XOR bit 5
+		return
+	}
+
+	c.SetFlag(core.FlagOverflow, (data^c.A)&0x40 != 0) // Overflow
+	if data&0x0F+data&0x01 > 0x05 {
+		c.A = c.A&0xF0 | (c.A+0x06)&0x0F
+	}
+	highAdjust := uint16(data&0xF0)+uint16(data&0x10) > 0x50
+	if highAdjust {
+		c.A += 0x60
+	}
+	c.SetFlag(core.FlagCarry, highAdjust) // Carry
+}
+
+// SBX subtracts an immediate value from the accumulator ANDed with X and
+// stores the result in X. It sets flags like CMP and ignores decimal mode.
+func SBX(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	data := c.Read(addr)
+	ax := c.A & c.X
+	compare(c, ax, data)
+	c.X = ax - data
+}
+
+// JAM locks up the processor. It stops fetching instructions and ignores
+// IRQ and NMI until it is reset. Also known as KIL or HLT.
+func JAM(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	c.State = core.StateJammed
+}
+
+// NOPRead reads its operand and discards it, as the multi-byte undocumented
+// NOPs do. Reads that cross a page take an extra cycle.
+func NOPRead(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	c.Read(addr)
+}
@@ -6,15 +6,17 @@ import "github.com/andrewthecodertx/go-6502-emulator/pkg/core"
 
 // INC increments a value in memory.
 func INC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr) + 1
-	c.Bus.Write(addr, data)
+	old := c.Read(addr)
+	data := old + 1
+	c.WriteModified(addr, old, data)
 	c.SetZN(data)
 }
 
 // DEC decrements a value in memory.
 func DEC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr) - 1
-	c.Bus.Write(addr, data)
+	old := c.Read(addr)
+	data := old - 1
+	c.WriteModified(addr, old, data)
 	c.SetZN(data)
 }
 
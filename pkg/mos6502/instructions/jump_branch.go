@@ -10,105 +10,127 @@ func JMP(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 }
 
 // JSR jumps to a subroutine.
+// The high byte of the address is fetched after the return address, which
+// points at it, is pushed.
 func JSR(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	c.PC--
-	c.Push(byte(c.PC >> 8))
-	c.Push(byte(c.PC))
-	c.PC = addr
+	if c.Stage() == 0 {
+		low := uint16(c.ReadAs(core.AccessOperand, c.PC))
+		c.PC++
+		c.DummyStackRead()
+		c.Push(byte(c.PC >> 8))
+		c.Push(byte(c.PC))
+		c.Continue(low)
+		return
+	}
+	high := uint16(c.ReadAs(core.AccessOperand, c.PC))
+	c.PC = (high << 8) | addr
 }
 
 // RTS returns from a subroutine.
 func RTS(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	low := uint16(c.Pull())
-	high := uint16(c.Pull())
-	c.PC = ((high << 8) | low) + 1
+	switch c.Stage() {
+	case 0:
+		c.DummyStackRead()
+		c.Continue(0)
+	case 1:
+		c.Continue(uint16(c.Pull()))
+	default:
+		high := uint16(c.Pull())
+		c.PC = (high << 8) | addr
+		c.DummyRead(c.PC) // Read while incrementing past the JSR
+		c.PC++
+	}
 }
 
 // RTI returns from an interrupt.
 func RTI(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	c.Status = c.Pull()
-	c.SetFlag(core.FlagBreak, false) // B flag not stored
-	c.SetFlag(core.FlagUnused, true)  // Unused flag always set
-	low := uint16(c.Pull())
-	high := uint16(c.Pull())
-	c.PC = (high << 8) | low
+	switch c.Stage() {
+	case 0:
+		c.DummyStackRead()
+		c.Continue(0)
+	case 1:
+		c.Status = c.Pull()
+		c.SetFlag(core.FlagBreak, false) // B flag not stored
+		c.SetFlag(core.FlagUnused, true)  // Unused flag always set
+		c.Continue(0)
+	case 2:
+		c.Continue(uint16(c.Pull()))
+	default:
+		high := uint16(c.Pull())
+		c.PC = (high << 8) | addr
+	}
 }
 
 // BRK forces a break.
 func BRK(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	c.PC++
-	c.SetFlag(core.FlagInterruptDisable, true)
-	c.Push(byte(c.PC >> 8))
-	c.Push(byte(c.PC))
-	c.SetFlag(core.FlagBreak, true)
-	c.Push(c.Status)
-	c.SetFlag(core.FlagBreak, false)
-	low := uint16(c.Bus.Read(0xFFFE))
-	high := uint16(c.Bus.Read(0xFFFF))
-	c.PC = (high << 8) | low
-}
-
-// branch takes a branch if the condition is met.
-func branch(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	c.Cycles++
-	if pageCrossed {
-		c.Cycles++
+	switch c.Stage() {
+	case 0:
+		c.PC++
+		c.Push(byte(c.PC >> 8))
+		c.Push(byte(c.PC))
+		c.Push(c.Status | core.FlagBreak | core.FlagUnused)
+		c.SetFlag(core.FlagInterruptDisable, true)
+		c.Continue(0)
+	case 1:
+		c.Continue(uint16(c.ReadAs(core.AccessVector, 0xFFFE)))
+	default:
+		high := uint16(c.ReadAs(core.AccessVector, 0xFFFF))
+		c.PC = (high << 8) | addr
 	}
-	c.PC = addr
 }
 
 // BCC branches if carry is clear.
 func BCC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 	if !c.GetFlag(core.FlagCarry) {
-		branch(c, addr, pageCrossed)
+		c.Branch(addr)
 	}
 }
 
 // BCS branches if carry is set.
 func BCS(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 	if c.GetFlag(core.FlagCarry) {
-		branch(c, addr, pageCrossed)
+		c.Branch(addr)
 	}
 }
 
 // BEQ branches if zero is set.
 func BEQ(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 	if c.GetFlag(core.FlagZero) {
-		branch(c, addr, pageCrossed)
+		c.Branch(addr)
 	}
 }
 
 // BMI branches if negative is set.
 func BMI(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 	if c.GetFlag(core.FlagNegative) {
-		branch(c, addr, pageCrossed)
+		c.Branch(addr)
 	}
 }
 
 // BNE branches if zero is clear.
 func BNE(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 	if !c.GetFlag(core.FlagZero) {
-		branch(c, addr, pageCrossed)
+		c.Branch(addr)
 	}
 }
 
 // BPL branches if negative is clear.
 func BPL(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 	if !c.GetFlag(core.FlagNegative) {
-		branch(c, addr, pageCrossed)
+		c.Branch(addr)
 	}
 }
 
 // BVC branches if overflow is clear.
 func BVC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 	if !c.GetFlag(core.FlagOverflow) {
-		branch(c, addr, pageCrossed)
+		c.Branch(addr)
 	}
 }
 
 // BVS branches if overflow is set.
 func BVS(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 	if c.GetFlag(core.FlagOverflow) {
-		branch(c, addr, pageCrossed)
+		c.Branch(addr)
 	}
 }
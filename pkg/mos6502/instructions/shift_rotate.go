@@ -6,11 +6,11 @@ import "github.com/andrewthecodertx/go-6502-emulator/pkg/core"
 
 // ASL shifts left.
 func ASL(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	c.SetFlag(core.FlagCarry, data&0x80 != 0) // Carry
-	data <<= 1
-	c.Bus.Write(addr, data)
-	c.SetZN(data)
+	result := data << 1
+	c.WriteModified(addr, data, result)
+	c.SetZN(result)
 }
 
 // ASLAccumulator shifts the accumulator left.
@@ -22,12 +22,12 @@ func ASLAccumulator(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 
 // LSR shifts right.
 func LSR(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	c.SetFlag(core.FlagCarry, data&0x01 != 0) // Carry
-	data >>= 1
-	c.Bus.Write(addr, data)
+	result := data >> 1
+	c.WriteModified(addr, data, result)
 	c.SetFlag(core.FlagNegative, false)     // Negative always clear
-	c.SetFlag(core.FlagZero, data == 0) // Zero
+	c.SetFlag(core.FlagZero, result == 0) // Zero
 }
 
 // LSRAccumulator shifts the accumulator right.
@@ -40,15 +40,15 @@ func LSRAccumulator(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 
 // ROL rotates left.
 func ROL(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	carry := byte(0)
 	if c.GetFlag(core.FlagCarry) {
 		carry = 1
 	}
 	c.SetFlag(core.FlagCarry, data&0x80 != 0) // Carry
-	data = (data << 1) | carry
-	c.Bus.Write(addr, data)
-	c.SetZN(data)
+	result := (data << 1) | carry
+	c.WriteModified(addr, data, result)
+	c.SetZN(result)
 }
 
 // ROLAccumulator rotates the accumulator left.
@@ -64,15 +64,15 @@ func ROLAccumulator(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 
 // ROR rotates right.
 func ROR(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	carry := byte(0)
 	if c.GetFlag(core.FlagCarry) {
 		carry = 1
 	}
 	c.SetFlag(core.FlagCarry, data&0x01 != 0) // Carry
-	data = (data >> 1) | (carry << 7)
-	c.Bus.Write(addr, data)
-	c.SetZN(data)
+	result := (data >> 1) | (carry << 7)
+	c.WriteModified(addr, data, result)
+	c.SetZN(result)
 }
 
 // RORAccumulator rotates the accumulator right.
@@ -11,6 +11,11 @@ func PHA(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 
 // PLA pulls the accumulator from the stack.
 func PLA(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	if c.Stage() == 0 {
+		c.DummyStackRead()
+		c.Continue(0)
+		return
+	}
 	c.A = c.Pull()
 	c.SetZN(c.A)
 }
@@ -23,6 +28,11 @@ func PHP(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 
 // PLP pulls the status register from the stack.
 func PLP(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	if c.Stage() == 0 {
+		c.DummyStackRead()
+		c.Continue(0)
+		return
+	}
 	c.Status = c.Pull()
 	c.SetFlag(core.FlagUnused, true) // Unused flag always set
 	c.SetFlag(core.FlagBreak, false) // B flag not actually stored
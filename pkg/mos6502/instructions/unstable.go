@@ -0,0 +1,71 @@
+package instructions
+
+import "github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+
+// Unstable undocumented instructions for NMOS 6502
+//
+// The results of these opcodes vary between chips, temperature and bus
+// activity. The chip-specific parts are taken from c.Unstable.
+
+// ANE ANDs X and an immediate value into the accumulator after ORing the
+// accumulator with the magic constant. Also known as XAA.
+func ANE(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	c.A = (c.A | c.Unstable.Magic) & c.X & c.Read(addr)
+	c.SetZN(c.A)
+}
+
+// LXA loads an immediate value ANDed with the accumulator, after ORing the
+// accumulator with the magic constant, into both A and X.
+func LXA(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	c.A = (c.A | c.Unstable.Magic) & c.Read(addr)
+	c.X = c.A
+	c.SetZN(c.A)
+}
+
+// SHA stores the accumulator ANDed with X and the high byte of the address
+// plus one. Also known as AHX.
+func SHA(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	storeHighByteAND(c, addr, c.Y, pageCrossed, c.A&c.X)
+}
+
+// SHX stores X ANDed with the high byte of the address plus one.
+func SHX(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	storeHighByteAND(c, addr, c.Y, pageCrossed, c.X)
+}
+
+// SHY stores Y ANDed with the high byte of the address plus one.
+func SHY(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	storeHighByteAND(c, addr, c.X, pageCrossed, c.Y)
+}
+
+// TAS transfers the accumulator ANDed with X to the stack pointer, then
+// stores it like SHA. Also known as SHS.
+func TAS(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	c.SP = c.A & c.X
+	storeHighByteAND(c, addr, c.Y, pageCrossed, c.SP)
+}
+
+// LAS ANDs memory with the stack pointer and loads the result into A, X
+// and SP.
+func LAS(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	value := c.Read(addr) & c.SP
+	c.A = value
+	c.X = value
+	c.SP = value
+	c.SetZN(value)
+}
+
+// storeHighByteAND performs the store shared by the SHx instructions. The
+// value is ANDed with the high byte of the unindexed base address plus one.
+// When indexing crosses a page, the stored value also replaces the high
+// byte of the target address.
+func storeHighByteAND(c *core.BaseCPU, addr uint16, index byte, pageCrossed bool, value byte) {
+	if !c.Unstable.SHxDropAND {
+		base := addr - uint16(index)
+		value &= byte(base>>8) + 1
+	}
+	if pageCrossed {
+		addr = uint16(value)<<8 | addr&0x00FF
+	}
+	c.Write(addr, value)
+}
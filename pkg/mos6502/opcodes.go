@@ -0,0 +1,300 @@
+package mos6502
+
+import (
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/mos6502/instructions"
+)
+
+// This file contains the opcode table for NMOS 6502.
+// Instruction implementations are in pkg/mos6502/instructions/.
+
+// opcodes describes every NMOS 6502 opcode, indexed by opcode byte.
+// Undocumented opcodes are marked Illegal. Their indexed read-modify-write
+// and SHx store forms have fixed timings with no page-crossing penalty, the
+// behavior of ANE, LXA and the SHx group is selected by BaseCPU.Unstable,
+// and JAM enters core.StateJammed.
+//
+// Code and Length are filled in by init.
+var opcodes = [256]core.Opcode{
+	0x00: {Mnemonic: "BRK", Mode: core.Implied, Cycles: 7, Handler: instructions.BRK},
+	0x01: {Mnemonic: "ORA", Mode: core.IndirectX, Cycles: 6, Handler: instructions.ORA},
+	0x02: {Mnemonic: "JAM", Mode: core.Implied, Cycles: 2, Handler: instructions.JAM, Illegal: true},
+	0x03: {Mnemonic: "SLO", Mode: core.IndirectX, Cycles: 8, Handler: instructions.SLO, Illegal: true},
+	0x04: {Mnemonic: "NOP", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.NOPRead, Illegal: true},
+	0x05: {Mnemonic: "ORA", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.ORA},
+	0x06: {Mnemonic: "ASL", Mode: core.ZeroPage, Cycles: 5, Handler: instructions.ASL},
+	0x07: {Mnemonic: "SLO", Mode: core.ZeroPage, Cycles: 5, Handler: instructions.SLO, Illegal: true},
+	0x08: {Mnemonic: "PHP", Mode: core.Implied, Cycles: 3, Handler: instructions.PHP},
+	0x09: {Mnemonic: "ORA", Mode: core.Immediate, Cycles: 2, Handler: instructions.ORA},
+	0x0A: {Mnemonic: "ASL", Mode: core.Accumulator, Cycles: 2, Handler: instructions.ASLAccumulator},
+	0x0B: {Mnemonic: "ANC", Mode: core.Immediate, Cycles: 2, Handler: instructions.ANC, Illegal: true},
+	0x0C: {Mnemonic: "NOP", Mode: core.Absolute, Cycles: 4, Handler: instructions.NOPRead, Illegal: true},
+	0x0D: {Mnemonic: "ORA", Mode: core.Absolute, Cycles: 4, Handler: instructions.ORA},
+	0x0E: {Mnemonic: "ASL", Mode: core.Absolute, Cycles: 6, Handler: instructions.ASL},
+	0x0F: {Mnemonic: "SLO", Mode: core.Absolute, Cycles: 6, Handler: instructions.SLO, Illegal: true},
+	0x10: {Mnemonic: "BPL", Mode: core.Relative, Cycles: 2, BranchCycle: true, Handler: instructions.BPL},
+	0x11: {Mnemonic: "ORA", Mode: core.IndirectY, Cycles: 5, PageCrossCycle: true, Handler: instructions.ORA},
+	0x12: {Mnemonic: "JAM", Mode: core.Implied, Cycles: 2, Handler: instructions.JAM, Illegal: true},
+	0x13: {Mnemonic: "SLO", Mode: core.IndirectY, Cycles: 8, Handler: instructions.SLO, Illegal: true},
+	0x14: {Mnemonic: "NOP", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.NOPRead, Illegal: true},
+	0x15: {Mnemonic: "ORA", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.ORA},
+	0x16: {Mnemonic: "ASL", Mode: core.ZeroPageX, Cycles: 6, Handler: instructions.ASL},
+	0x17: {Mnemonic: "SLO", Mode: core.ZeroPageX, Cycles: 6, Handler: instructions.SLO, Illegal: true},
+	0x18: {Mnemonic: "CLC", Mode: core.Implied, Cycles: 2, Handler: instructions.CLC},
+	0x19: {Mnemonic: "ORA", Mode: core.AbsoluteY, Cycles: 4, PageCrossCycle: true, Handler: instructions.ORA},
+	0x1A: {Mnemonic: "NOP", Mode: core.Implied, Cycles: 2, Handler: instructions.NOP, Illegal: true},
+	0x1B: {Mnemonic: "SLO", Mode: core.AbsoluteY, Cycles: 7, Handler: instructions.SLO, Illegal: true},
+	0x1C: {Mnemonic: "NOP", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.NOPRead, Illegal: true},
+	0x1D: {Mnemonic: "ORA", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.ORA},
+	0x1E: {Mnemonic: "ASL", Mode: core.AbsoluteX, Cycles: 7, Handler: instructions.ASL},
+	0x1F: {Mnemonic: "SLO", Mode: core.AbsoluteX, Cycles: 7, Handler: instructions.SLO, Illegal: true},
+	0x20: {Mnemonic: "JSR", Mode: core.Absolute, Cycles: 6, Handler: instructions.JSR, FetchesOperand: true},
+	0x21: {Mnemonic: "AND", Mode: core.IndirectX, Cycles: 6, Handler: instructions.AND},
+	0x22: {Mnemonic: "JAM", Mode: core.Implied, Cycles: 2, Handler: instructions.JAM, Illegal: true},
+	0x23: {Mnemonic: "RLA", Mode: core.IndirectX, Cycles: 8, Handler: instructions.RLA, Illegal: true},
+	0x24: {Mnemonic: "BIT", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.BIT},
+	0x25: {Mnemonic: "AND", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.AND},
+	0x26: {Mnemonic: "ROL", Mode: core.ZeroPage, Cycles: 5, Handler: instructions.ROL},
+	0x27: {Mnemonic: "RLA", Mode: core.ZeroPage, Cycles: 5, Handler: instructions.RLA, Illegal: true},
+	0x28: {Mnemonic: "PLP", Mode: core.Implied, Cycles: 4, Handler: instructions.PLP},
+	0x29: {Mnemonic: "AND", Mode: core.Immediate, Cycles: 2, Handler: instructions.AND},
+	0x2A: {Mnemonic: "ROL", Mode: core.Accumulator, Cycles: 2, Handler: instructions.ROLAccumulator},
+	0x2B: {Mnemonic: "ANC", Mode: core.Immediate, Cycles: 2, Handler: instructions.ANC, Illegal: true},
+	0x2C: {Mnemonic: "BIT", Mode: core.Absolute, Cycles: 4, Handler: instructions.BIT},
+	0x2D: {Mnemonic: "AND", Mode: core.Absolute, Cycles: 4, Handler: instructions.AND},
+	0x2E: {Mnemonic: "ROL", Mode: core.Absolute, Cycles: 6, Handler: instructions.ROL},
+	0x2F: {Mnemonic: "RLA", Mode: core.Absolute, Cycles: 6, Handler: instructions.RLA, Illegal: true},
+	0x30: {Mnemonic: "BMI", Mode: core.Relative, Cycles: 2, BranchCycle: true, Handler: instructions.BMI},
+	0x31: {Mnemonic: "AND", Mode: core.IndirectY, Cycles: 5, PageCrossCycle: true, Handler: instructions.AND},
+	0x32: {Mnemonic: "JAM", Mode: core.Implied, Cycles: 2, Handler: instructions.JAM, Illegal: true},
+	0x33: {Mnemonic: "RLA", Mode: core.IndirectY, Cycles: 8, Handler: instructions.RLA, Illegal: true},
+	0x34: {Mnemonic: "NOP", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.NOPRead, Illegal: true},
+	0x35: {Mnemonic: "AND", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.AND},
+	0x36: {Mnemonic: "ROL", Mode: core.ZeroPageX, Cycles: 6, Handler: instructions.ROL},
+	0x37: {Mnemonic: "RLA", Mode: core.ZeroPageX, Cycles: 6, Handler: instructions.RLA, Illegal: true},
+	0x38: {Mnemonic: "SEC", Mode: core.Implied, Cycles: 2, Handler: instructions.SEC},
+	0x39: {Mnemonic: "AND", Mode: core.AbsoluteY, Cycles: 4, PageCrossCycle: true, Handler: instructions.AND},
+	0x3A: {Mnemonic: "NOP", Mode: core.Implied, Cycles: 2, Handler: instructions.NOP, Illegal: true},
+	0x3B: {Mnemonic: "RLA", Mode: core.AbsoluteY, Cycles: 7, Handler: instructions.RLA, Illegal: true},
+	0x3C: {Mnemonic: "NOP", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.NOPRead, Illegal: true},
+	0x3D: {Mnemonic: "AND", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.AND},
+	0x3E: {Mnemonic: "ROL", Mode: core.AbsoluteX, Cycles: 7, Handler: instructions.ROL},
+	0x3F: {Mnemonic: "RLA", Mode: core.AbsoluteX, Cycles: 7, Handler: instructions.RLA, Illegal: true},
+	0x40: {Mnemonic: "RTI", Mode: core.Implied, Cycles: 6, Handler: instructions.RTI},
+	0x41: {Mnemonic: "EOR", Mode: core.IndirectX, Cycles: 6, Handler: instructions.EOR},
+	0x42: {Mnemonic: "JAM", Mode: core.Implied, Cycles: 2, Handler: instructions.JAM, Illegal: true},
+	0x43: {Mnemonic: "SRE", Mode: core.IndirectX, Cycles: 8, Handler: instructions.SRE, Illegal: true},
+	0x44: {Mnemonic: "NOP", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.NOPRead, Illegal: true},
+	0x45: {Mnemonic: "EOR", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.EOR},
+	0x46: {Mnemonic: "LSR", Mode: core.ZeroPage, Cycles: 5, Handler: instructions.LSR},
+	0x47: {Mnemonic: "SRE", Mode: core.ZeroPage, Cycles: 5, Handler: instructions.SRE, Illegal: true},
+	0x48: {Mnemonic: "PHA", Mode: core.Implied, Cycles: 3, Handler: instructions.PHA},
+	0x49: {Mnemonic: "EOR", Mode: core.Immediate, Cycles: 2, Handler: instructions.EOR},
+	0x4A: {Mnemonic: "LSR", Mode: core.Accumulator, Cycles: 2, Handler: instructions.LSRAccumulator},
+	0x4B: {Mnemonic: "ALR", Mode: core.Immediate, Cycles: 2, Handler: instructions.ALR, Illegal: true},
+	0x4C: {Mnemonic: "JMP", Mode: core.Absolute, Cycles: 3, Handler: instructions.JMP},
+	0x4D: {Mnemonic: "EOR", Mode: core.Absolute, Cycles: 4, Handler: instructions.EOR},
+	0x4E: {Mnemonic: "LSR", Mode: core.Absolute, Cycles: 6, Handler: instructions.LSR},
+	0x4F: {Mnemonic: "SRE", Mode: core.Absolute, Cycles: 6, Handler: instructions.SRE, Illegal: true},
+	0x50: {Mnemonic: "BVC", Mode: core.Relative, Cycles: 2, BranchCycle: true, Handler: instructions.BVC},
+	0x51: {Mnemonic: "EOR", Mode: core.IndirectY, Cycles: 5, PageCrossCycle: true, Handler: instructions.EOR},
+	0x52: {Mnemonic: "JAM", Mode: core.Implied, Cycles: 2, Handler: instructions.JAM, Illegal: true},
+	0x53: {Mnemonic: "SRE", Mode: core.IndirectY, Cycles: 8, Handler: instructions.SRE, Illegal: true},
+	0x54: {Mnemonic: "NOP", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.NOPRead, Illegal: true},
+	0x55: {Mnemonic: "EOR", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.EOR},
+	0x56: {Mnemonic: "LSR", Mode: core.ZeroPageX, Cycles: 6, Handler: instructions.LSR},
+	0x57: {Mnemonic: "SRE", Mode: core.ZeroPageX, Cycles: 6, Handler: instructions.SRE, Illegal: true},
+	0x58: {Mnemonic: "CLI", Mode: core.Implied, Cycles: 2, Handler: instructions.CLI},
+	0x59: {Mnemonic: "EOR", Mode: core.AbsoluteY, Cycles: 4, PageCrossCycle: true, Handler: instructions.EOR},
+	0x5A: {Mnemonic: "NOP", Mode: core.Implied, Cycles: 2, Handler: instructions.NOP, Illegal: true},
+	0x5B: {Mnemonic: "SRE", Mode: core.AbsoluteY, Cycles: 7, Handler: instructions.SRE, Illegal: true},
+	0x5C: {Mnemonic: "NOP", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.NOPRead, Illegal: true},
+	0x5D: {Mnemonic: "EOR", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.EOR},
+	0x5E: {Mnemonic: "LSR", Mode: core.AbsoluteX, Cycles: 7, Handler: instructions.LSR},
+	0x5F: {Mnemonic: "SRE", Mode: core.AbsoluteX, Cycles: 7, Handler: instructions.SRE, Illegal: true},
+	0x60: {Mnemonic: "RTS", Mode: core.Implied, Cycles: 6, Handler: instructions.RTS},
+	0x61: {Mnemonic: "ADC", Mode: core.IndirectX, Cycles: 6, Handler: instructions.ADC},
+	0x62: {Mnemonic: "JAM", Mode: core.Implied, Cycles: 2, Handler: instructions.JAM, Illegal: true},
+	0x63: {Mnemonic: "RRA", Mode: core.IndirectX, Cycles: 8, Handler: instructions.RRA, Illegal: true},
+	0x64: {Mnemonic: "NOP", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.NOPRead, Illegal: true},
+	0x65: {Mnemonic: "ADC", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.ADC},
+	0x66: {Mnemonic: "ROR", Mode: core.ZeroPage, Cycles: 5, Handler: instructions.ROR},
+	0x67: {Mnemonic: "RRA", Mode: core.ZeroPage, Cycles: 5, Handler: instructions.RRA, Illegal: true},
+	0x68: {Mnemonic: "PLA", Mode: core.Implied, Cycles: 4, Handler: instructions.PLA},
+	0x69: {Mnemonic: "ADC", Mode: core.Immediate, Cycles: 2, Handler: instructions.ADC},
+	0x6A: {Mnemonic: "ROR", Mode: core.Accumulator, Cycles: 2, Handler: instructions.RORAccumulator},
+	0x6B: {Mnemonic: "ARR", Mode: core.Immediate, Cycles: 2, Handler: instructions.ARR, Illegal: true},
+	0x6C: {Mnemonic: "JMP", Mode: core.Indirect, Cycles: 5, Handler: instructions.JMP},
+	0x6D: {Mnemonic: "ADC", Mode: core.Absolute, Cycles: 4, Handler: instructions.ADC},
+	0x6E: {Mnemonic: "ROR", Mode: core.Absolute, Cycles: 6, Handler: instructions.ROR},
+	0x6F: {Mnemonic: "RRA", Mode: core.Absolute, Cycles: 6, Handler: instructions.RRA, Illegal: true},
+	0x70: {Mnemonic: "BVS", Mode: core.Relative, Cycles: 2, BranchCycle: true, Handler: instructions.BVS},
+	0x71: {Mnemonic: "ADC", Mode: core.IndirectY, Cycles: 5, PageCrossCycle: true, Handler: instructions.ADC},
+	0x72: {Mnemonic: "JAM", Mode: core.Implied, Cycles: 2, Handler: instructions.JAM, Illegal: true},
+	0x73: {Mnemonic: "RRA", Mode: core.IndirectY, Cycles: 8, Handler: instructions.RRA, Illegal: true},
+	0x74: {Mnemonic: "NOP", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.NOPRead, Illegal: true},
+	0x75: {Mnemonic: "ADC", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.ADC},
+	0x76: {Mnemonic: "ROR", Mode: core.ZeroPageX, Cycles: 6, Handler: instructions.ROR},
+	0x77: {Mnemonic: "RRA", Mode: core.ZeroPageX, Cycles: 6, Handler: instructions.RRA, Illegal: true},
+	0x78: {Mnemonic: "SEI", Mode: core.Implied, Cycles: 2, Handler: instructions.SEI},
+	0x79: {Mnemonic: "ADC", Mode: core.AbsoluteY, Cycles: 4, PageCrossCycle: true, Handler: instructions.ADC},
+	0x7A: {Mnemonic: "NOP", Mode: core.Implied, Cycles: 2, Handler: instructions.NOP, Illegal: true},
+	0x7B: {Mnemonic: "RRA", Mode: core.AbsoluteY, Cycles: 7, Handler: instructions.RRA, Illegal: true},
+	0x7C: {Mnemonic: "NOP", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.NOPRead, Illegal: true},
+	0x7D: {Mnemonic: "ADC", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.ADC},
+	0x7E: {Mnemonic: "ROR", Mode: core.AbsoluteX, Cycles: 7, Handler: instructions.ROR},
+	0x7F: {Mnemonic: "RRA", Mode: core.AbsoluteX, Cycles: 7, Handler: instructions.RRA, Illegal: true},
+	0x80: {Mnemonic: "NOP", Mode: core.Immediate, Cycles: 2, Handler: instructions.NOPRead, Illegal: true},
+	0x81: {Mnemonic: "STA", Mode: core.IndirectX, Cycles: 6, Handler: instructions.STA},
+	0x82: {Mnemonic: "NOP", Mode: core.Immediate, Cycles: 2, Handler: instructions.NOPRead, Illegal: true},
+	0x83: {Mnemonic: "SAX", Mode: core.IndirectX, Cycles: 6, Handler: instructions.SAX, Illegal: true},
+	0x84: {Mnemonic: "STY", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.STY},
+	0x85: {Mnemonic: "STA", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.STA},
+	0x86: {Mnemonic: "STX", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.STX},
+	0x87: {Mnemonic: "SAX", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.SAX, Illegal: true},
+	0x88: {Mnemonic: "DEY", Mode: core.Implied, Cycles: 2, Handler: instructions.DEY},
+	0x89: {Mnemonic: "NOP", Mode: core.Immediate, Cycles: 2, Handler: instructions.NOPRead, Illegal: true},
+	0x8A: {Mnemonic: "TXA", Mode: core.Implied, Cycles: 2, Handler: instructions.TXA},
+	0x8B: {Mnemonic: "ANE", Mode: core.Immediate, Cycles: 2, Handler: instructions.ANE, Illegal: true},
+	0x8C: {Mnemonic: "STY", Mode: core.Absolute, Cycles: 4, Handler: instructions.STY},
+	0x8D: {Mnemonic: "STA", Mode: core.Absolute, Cycles: 4, Handler: instructions.STA},
+	0x8E: {Mnemonic: "STX", Mode: core.Absolute, Cycles: 4, Handler: instructions.STX},
+	0x8F: {Mnemonic: "SAX", Mode: core.Absolute, Cycles: 4, Handler: instructions.SAX, Illegal: true},
+	0x90: {Mnemonic: "BCC", Mode: core.Relative, Cycles: 2, BranchCycle: true, Handler: instructions.BCC},
+	0x91: {Mnemonic: "STA", Mode: core.IndirectY, Cycles: 6, Handler: instructions.STA},
+	0x92: {Mnemonic: "JAM", Mode: core.Implied, Cycles: 2, Handler: instructions.JAM, Illegal: true},
+	0x93: {Mnemonic: "SHA", Mode: core.IndirectY, Cycles: 6, Handler: instructions.SHA, Illegal: true},
+	0x94: {Mnemonic: "STY", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.STY},
+	0x95: {Mnemonic: "STA", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.STA},
+	0x96: {Mnemonic: "STX", Mode: core.ZeroPageY, Cycles: 4, Handler: instructions.STX},
+	0x97: {Mnemonic: "SAX", Mode: core.ZeroPageY, Cycles: 4, Handler: instructions.SAX, Illegal: true},
+	0x98: {Mnemonic: "TYA", Mode: core.Implied, Cycles: 2, Handler: instructions.TYA},
+	0x99: {Mnemonic: "STA", Mode: core.AbsoluteY, Cycles: 5, Handler: instructions.STA},
+	0x9A: {Mnemonic: "TXS", Mode: core.Implied, Cycles: 2, Handler: instructions.TXS},
+	0x9B: {Mnemonic: "TAS", Mode: core.AbsoluteY, Cycles: 5, Handler: instructions.TAS, Illegal: true},
+	0x9C: {Mnemonic: "SHY", Mode: core.AbsoluteX, Cycles: 5, Handler: instructions.SHY, Illegal: true},
+	0x9D: {Mnemonic: "STA", Mode: core.AbsoluteX, Cycles: 5, Handler: instructions.STA},
+	0x9E: {Mnemonic: "SHX", Mode: core.AbsoluteY, Cycles: 5, Handler: instructions.SHX, Illegal: true},
+	0x9F: {Mnemonic: "SHA", Mode: core.AbsoluteY, Cycles: 5, Handler: instructions.SHA, Illegal: true},
+	0xA0: {Mnemonic: "LDY", Mode: core.Immediate, Cycles: 2, Handler: instructions.LDY},
+	0xA1: {Mnemonic: "LDA", Mode: core.IndirectX, Cycles: 6, Handler: instructions.LDA},
+	0xA2: {Mnemonic: "LDX", Mode: core.Immediate, Cycles: 2, Handler: instructions.LDX},
+	0xA3: {Mnemonic: "LAX", Mode: core.IndirectX, Cycles: 6, Handler: instructions.LAX, Illegal: true},
+	0xA4: {Mnemonic: "LDY", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.LDY},
+	0xA5: {Mnemonic: "LDA", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.LDA},
+	0xA6: {Mnemonic: "LDX", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.LDX},
+	0xA7: {Mnemonic: "LAX", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.LAX, Illegal: true},
+	0xA8: {Mnemonic: "TAY", Mode: core.Implied, Cycles: 2, Handler: instructions.TAY},
+	0xA9: {Mnemonic: "LDA", Mode: core.Immediate, Cycles: 2, Handler: instructions.LDA},
+	0xAA: {Mnemonic: "TAX", Mode: core.Implied, Cycles: 2, Handler: instructions.TAX},
+	0xAB: {Mnemonic: "LXA", Mode: core.Immediate, Cycles: 2, Handler: instructions.LXA, Illegal: true},
+	0xAC: {Mnemonic: "LDY", Mode: core.Absolute, Cycles: 4, Handler: instructions.LDY},
+	0xAD: {Mnemonic: "LDA", Mode: core.Absolute, Cycles: 4, Handler: instructions.LDA},
+	0xAE: {Mnemonic: "LDX", Mode: core.Absolute, Cycles: 4, Handler: instructions.LDX},
+	0xAF: {Mnemonic: "LAX", Mode: core.Absolute, Cycles: 4, Handler: instructions.LAX, Illegal: true},
+	0xB0: {Mnemonic: "BCS", Mode: core.Relative, Cycles: 2, BranchCycle: true, Handler: instructions.BCS},
+	0xB1: {Mnemonic: "LDA", Mode: core.IndirectY, Cycles: 5, PageCrossCycle: true, Handler: instructions.LDA},
+	0xB2: {Mnemonic: "JAM", Mode: core.Implied, Cycles: 2, Handler: instructions.JAM, Illegal: true},
+	0xB3: {Mnemonic: "LAX", Mode: core.IndirectY, Cycles: 5, PageCrossCycle: true, Handler: instructions.LAX, Illegal: true},
+	0xB4: {Mnemonic: "LDY", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.LDY},
+	0xB5: {Mnemonic: "LDA", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.LDA},
+	0xB6: {Mnemonic: "LDX", Mode: core.ZeroPageY, Cycles: 4, Handler: instructions.LDX},
+	0xB7: {Mnemonic: "LAX", Mode: core.ZeroPageY, Cycles: 4, Handler: instructions.LAX, Illegal: true},
+	0xB8: {Mnemonic: "CLV", Mode: core.Implied, Cycles: 2, Handler: instructions.CLV},
+	0xB9: {Mnemonic: "LDA", Mode: core.AbsoluteY, Cycles: 4, PageCrossCycle: true, Handler: instructions.LDA},
+	0xBA: {Mnemonic: "TSX", Mode: core.Implied, Cycles: 2, Handler: instructions.TSX},
+	0xBB: {Mnemonic: "LAS", Mode: core.AbsoluteY, Cycles: 4, PageCrossCycle: true, Handler: instructions.LAS, Illegal: true},
+	0xBC: {Mnemonic: "LDY", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.LDY},
+	0xBD: {Mnemonic: "LDA", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.LDA},
+	0xBE: {Mnemonic: "LDX", Mode: core.AbsoluteY, Cycles: 4, PageCrossCycle: true, Handler: instructions.LDX},
+	0xBF: {Mnemonic: "LAX", Mode: core.AbsoluteY, Cycles: 4, PageCrossCycle: true, Handler: instructions.LAX, Illegal: true},
+	0xC0: {Mnemonic: "CPY", Mode: core.Immediate, Cycles: 2, Handler: instructions.CPY},
+	0xC1: {Mnemonic: "CMP", Mode: core.IndirectX, Cycles: 6, Handler: instructions.CMP},
+	0xC2: {Mnemonic: "NOP", Mode: core.Immediate, Cycles: 2, Handler: instructions.NOPRead, Illegal: true},
+	0xC3: {Mnemonic: "DCP", Mode: core.IndirectX, Cycles: 8, Handler: instructions.DCP, Illegal: true},
+	0xC4: {Mnemonic: "CPY", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.CPY},
+	0xC5: {Mnemonic: "CMP", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.CMP},
+	0xC6: {Mnemonic: "DEC", Mode: core.ZeroPage, Cycles: 5, Handler: instructions.DEC},
+	0xC7: {Mnemonic: "DCP", Mode: core.ZeroPage, Cycles: 5, Handler: instructions.DCP, Illegal: true},
+	0xC8: {Mnemonic: "INY", Mode: core.Implied, Cycles: 2, Handler: instructions.INY},
+	0xC9: {Mnemonic: "CMP", Mode: core.Immediate, Cycles: 2, Handler: instructions.CMP},
+	0xCA: {Mnemonic: "DEX", Mode: core.Implied, Cycles: 2, Handler: instructions.DEX},
+	0xCB: {Mnemonic: "SBX", Mode: core.Immediate, Cycles: 2, Handler: instructions.SBX, Illegal: true},
+	0xCC: {Mnemonic: "CPY", Mode: core.Absolute, Cycles: 4, Handler: instructions.CPY},
+	0xCD: {Mnemonic: "CMP", Mode: core.Absolute, Cycles: 4, Handler: instructions.CMP},
+	0xCE: {Mnemonic: "DEC", Mode: core.Absolute, Cycles: 6, Handler: instructions.DEC},
+	0xCF: {Mnemonic: "DCP", Mode: core.Absolute, Cycles: 6, Handler: instructions.DCP, Illegal: true},
+	0xD0: {Mnemonic: "BNE", Mode: core.Relative, Cycles: 2, BranchCycle: true, Handler: instructions.BNE},
+	0xD1: {Mnemonic: "CMP", Mode: core.IndirectY, Cycles: 5, PageCrossCycle: true, Handler: instructions.CMP},
+	0xD2: {Mnemonic: "JAM", Mode: core.Implied, Cycles: 2, Handler: instructions.JAM, Illegal: true},
+	0xD3: {Mnemonic: "DCP", Mode: core.IndirectY, Cycles: 8, Handler: instructions.DCP, Illegal: true},
+	0xD4: {Mnemonic: "NOP", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.NOPRead, Illegal: true},
+	0xD5: {Mnemonic: "CMP", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.CMP},
+	0xD6: {Mnemonic: "DEC", Mode: core.ZeroPageX, Cycles: 6, Handler: instructions.DEC},
+	0xD7: {Mnemonic: "DCP", Mode: core.ZeroPageX, Cycles: 6, Handler: instructions.DCP, Illegal: true},
+	0xD8: {Mnemonic: "CLD", Mode: core.Implied, Cycles: 2, Handler: instructions.CLD},
+	0xD9: {Mnemonic: "CMP", Mode: core.AbsoluteY, Cycles: 4, PageCrossCycle: true, Handler: instructions.CMP},
+	0xDA: {Mnemonic: "NOP", Mode: core.Implied, Cycles: 2, Handler: instructions.NOP, Illegal: true},
+	0xDB: {Mnemonic: "DCP", Mode: core.AbsoluteY, Cycles: 7, Handler: instructions.DCP, Illegal: true},
+	0xDC: {Mnemonic: "NOP", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.NOPRead, Illegal: true},
+	0xDD: {Mnemonic: "CMP", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.CMP},
+	0xDE: {Mnemonic: "DEC", Mode: core.AbsoluteX, Cycles: 7, Handler: instructions.DEC},
+	0xDF: {Mnemonic: "DCP", Mode: core.AbsoluteX, Cycles: 7, Handler: instructions.DCP, Illegal: true},
+	0xE0: {Mnemonic: "CPX", Mode: core.Immediate, Cycles: 2, Handler: instructions.CPX},
+	0xE1: {Mnemonic: "SBC", Mode: core.IndirectX, Cycles: 6, Handler: instructions.SBC},
+	0xE2: {Mnemonic: "NOP", Mode: core.Immediate, Cycles: 2, Handler: instructions.NOPRead, Illegal: true},
+	0xE3: {Mnemonic: "ISC", Mode: core.IndirectX, Cycles: 8, Handler: instructions.ISC, Illegal: true},
+	0xE4: {Mnemonic: "CPX", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.CPX},
+	0xE5: {Mnemonic: "SBC", Mode: core.ZeroPage, Cycles: 3, Handler: instructions.SBC},
+	0xE6: {Mnemonic: "INC", Mode: core.ZeroPage, Cycles: 5, Handler: instructions.INC},
+	0xE7: {Mnemonic: "ISC", Mode: core.ZeroPage, Cycles: 5, Handler: instructions.ISC, Illegal: true},
+	0xE8: {Mnemonic: "INX", Mode: core.Implied, Cycles: 2, Handler: instructions.INX},
+	0xE9: {Mnemonic: "SBC", Mode: core.Immediate, Cycles: 2, Handler: instructions.SBC},
+	0xEA: {Mnemonic: "NOP", Mode: core.Implied, Cycles: 2, Handler: instructions.NOP},
+	0xEB: {Mnemonic: "SBC", Mode: core.Immediate, Cycles: 2, Handler: instructions.SBC, Illegal: true},
+	0xEC: {Mnemonic: "CPX", Mode: core.Absolute, Cycles: 4, Handler: instructions.CPX},
+	0xED: {Mnemonic: "SBC", Mode: core.Absolute, Cycles: 4, Handler: instructions.SBC},
+	0xEE: {Mnemonic: "INC", Mode: core.Absolute, Cycles: 6, Handler: instructions.INC},
+	0xEF: {Mnemonic: "ISC", Mode: core.Absolute, Cycles: 6, Handler: instructions.ISC, Illegal: true},
+	0xF0: {Mnemonic: "BEQ", Mode: core.Relative, Cycles: 2, BranchCycle: true, Handler: instructions.BEQ},
+	0xF1: {Mnemonic: "SBC", Mode: core.IndirectY, Cycles: 5, PageCrossCycle: true, Handler: instructions.SBC},
+	0xF2: {Mnemonic: "JAM", Mode: core.Implied, Cycles: 2, Handler: instructions.JAM, Illegal: true},
+	0xF3: {Mnemonic: "ISC", Mode: core.IndirectY, Cycles: 8, Handler: instructions.ISC, Illegal: true},
+	0xF4: {Mnemonic: "NOP", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.NOPRead, Illegal: true},
+	0xF5: {Mnemonic: "SBC", Mode: core.ZeroPageX, Cycles: 4, Handler: instructions.SBC},
+	0xF6: {Mnemonic: "INC", Mode: core.ZeroPageX, Cycles: 6, Handler: instructions.INC},
+	0xF7: {Mnemonic: "ISC", Mode: core.ZeroPageX, Cycles: 6, Handler: instructions.ISC, Illegal: true},
+	0xF8: {Mnemonic: "SED", Mode: core.Implied, Cycles: 2, Handler: instructions.SED},
+	0xF9: {Mnemonic: "SBC", Mode: core.AbsoluteY, Cycles: 4, PageCrossCycle: true, Handler: instructions.SBC},
+	0xFA: {Mnemonic: "NOP", Mode: core.Implied, Cycles: 2, Handler: instructions.NOP, Illegal: true},
+	0xFB: {Mnemonic: "ISC", Mode: core.AbsoluteY, Cycles: 7, Handler: instructions.ISC, Illegal: true},
+	0xFC: {Mnemonic: "NOP", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.NOPRead, Illegal: true},
+	0xFD: {Mnemonic: "SBC", Mode: core.AbsoluteX, Cycles: 4, PageCrossCycle: true, Handler: instructions.SBC},
+	0xFE: {Mnemonic: "INC", Mode: core.AbsoluteX, Cycles: 7, Handler: instructions.INC},
+	0xFF: {Mnemonic: "ISC", Mode: core.AbsoluteX, Cycles: 7, Handler: instructions.ISC, Illegal: true},
+}
+
+func init() {
+	for i := range opcodes {
+		opcodes[i].Code = uint8(i)
+		opcodes[i].Length = opcodes[i].Mode.Length()
+	}
+}
+
+// Opcodes returns a copy of the NMOS 6502 opcode table, indexed by opcode
+// byte, for use by disassemblers and other tools.
+func Opcodes() [256]core.Opcode {
+	return opcodes
+}
+
+// LookupOpcode describes the instruction decoded from opcode. It returns
+// false for opcodes the NMOS 6502 does not execute.
+func LookupOpcode(opcode byte) (core.Opcode, bool) {
+	op := opcodes[opcode]
+	return op, op.Handler != nil
+}
+
+// LookupOpcode is the package-level LookupOpcode, exposed through core.CPU.
+func (c *CPU) LookupOpcode(opcode byte) (core.Opcode, bool) {
+	return LookupOpcode(opcode)
+}
@@ -0,0 +1,275 @@
+// Package savestate saves a whole machine, the CPU together with its memory
+// and devices, to a file and loads it back, so that a long simulation can
+// be checkpointed or a bug reproduced from the state that triggered it.
+//
+// The CPU state is always saved. Anything else with state, a bus or a
+// device, registers a named section with the Machine:
+//
+//	m := savestate.New(cpu)
+//	m.Register("ram", savestate.Memory(ram.memory[:]))
+//	m.Register("via", via) // via implements Section
+//
+//	err := m.SaveFile("checkpoint.sav")
+//	...
+//	err = m.LoadFile("checkpoint.sav")
+//
+// A file starts with the magic string "6502SAVE" and a format version,
+// followed by the sections, each a name and a length-prefixed block of data
+// in whatever encoding its owner chose:
+//
+//	magic    [8]byte  "6502SAVE"
+//	version  uint16
+//	count    uint16   number of sections
+//	count times:
+//	  name   uint8 length, then the name
+//	  data   uint32 length, then the data
+//
+// Integers are little-endian. The CPU is the section named "cpu".
+package savestate
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// Version is the version of the file format written by Save.
+const Version = 1
+
+var magic = [8]byte{'6', '5', '0', '2', 'S', 'A', 'V', 'E'}
+
+// cpuSection is the name of the CPU's section.
+const cpuSection = "cpu"
+
+// Section is implemented by buses and devices with state to save. The data
+// is stored as written; a device whose state may change shape should
+// include its own version in it.
+type Section interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// Memory is a Section for a block of RAM. It saves a copy of the slice and
+// loads into it in place, so it should be made from the memory itself:
+//
+//	savestate.Memory(ram.memory[:])
+type Memory []byte
+
+func (m Memory) MarshalBinary() ([]byte, error) {
+	return bytes.Clone(m), nil
+}
+
+func (m Memory) UnmarshalBinary(data []byte) error {
+	if len(data) != len(m) {
+		return fmt.Errorf("memory is %d bytes, saved state has %d", len(m), len(data))
+	}
+	copy(m, data)
+	return nil
+}
+
+// Machine is a CPU and the sections saved with it.
+type Machine struct {
+	CPU core.CPU
+
+	sections []namedSection
+}
+
+type namedSection struct {
+	name    string
+	section Section
+}
+
+// New returns a machine that saves cpu and no other sections.
+func New(cpu core.CPU) *Machine {
+	return &Machine{CPU: cpu}
+}
+
+// Register adds a section to be saved and loaded under name. Sections are
+// written in the order they were registered and loaded in the same order,
+// after the CPU. Registering a name twice, or the reserved name "cpu",
+// panics.
+func (m *Machine) Register(name string, s Section) {
+	if s == nil {
+		panic("savestate: Register section is nil")
+	}
+	if name == "" || len(name) > 255 {
+		panic(fmt.Sprintf("savestate: bad section name %q", name))
+	}
+	if name == cpuSection {
+		panic(`savestate: section name "cpu" is reserved`)
+	}
+	if m.section(name) != nil {
+		panic(fmt.Sprintf("savestate: Register called twice for %q", name))
+	}
+	m.sections = append(m.sections, namedSection{name, s})
+}
+
+func (m *Machine) section(name string) Section {
+	for _, s := range m.sections {
+		if s.name == name {
+			return s.section
+		}
+	}
+	return nil
+}
+
+// Save writes the CPU and every registered section to w.
+func (m *Machine) Save(w io.Writer) error {
+	var b bytes.Buffer
+	b.Write(magic[:])
+	header := [2]uint16{Version, uint16(len(m.sections) + 1)}
+	if err := binary.Write(&b, binary.LittleEndian, header); err != nil {
+		return fmt.Errorf("savestate: writing header: %w", err)
+	}
+
+	cpu, err := m.CPU.Snapshot().MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("savestate: saving %s: %w", cpuSection, err)
+	}
+	if err := writeSection(&b, cpuSection, cpu); err != nil {
+		return err
+	}
+	for _, s := range m.sections {
+		data, err := s.section.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("savestate: saving %s: %w", s.name, err)
+		}
+		if err := writeSection(&b, s.name, data); err != nil {
+			return err
+		}
+	}
+
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+func writeSection(b *bytes.Buffer, name string, data []byte) error {
+	if uint64(len(data)) > math.MaxUint32 {
+		return fmt.Errorf("savestate: section %s is %d bytes, too large to save", name, len(data))
+	}
+	b.WriteByte(byte(len(name)))
+	b.WriteString(name)
+	if err := binary.Write(b, binary.LittleEndian, uint32(len(data))); err != nil {
+		return fmt.Errorf("savestate: saving %s: %w", name, err)
+	}
+	b.Write(data)
+	return nil
+}
+
+// Load reads a state written by Save and restores the CPU and every
+// registered section. The file must hold exactly the sections registered,
+// for a CPU of the same variant; nothing is changed if it does not. A
+// section that rejects its data is reported after the CPU and the sections
+// before it have been loaded.
+func (m *Machine) Load(r io.Reader) error {
+	var header struct {
+		Magic   [8]byte
+		Version uint16
+		Count   uint16
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return fmt.Errorf("savestate: reading header: %w", err)
+	}
+	if header.Magic != magic {
+		return errors.New("savestate: not a saved state")
+	}
+	if header.Version != Version {
+		return fmt.Errorf("savestate: unsupported version %d", header.Version)
+	}
+
+	saved := make(map[string][]byte, header.Count)
+	for range header.Count {
+		name, data, err := readSection(r)
+		if err != nil {
+			return fmt.Errorf("savestate: %w", err)
+		}
+		if _, dup := saved[name]; dup {
+			return fmt.Errorf("savestate: section %q appears twice", name)
+		}
+		saved[name] = data
+	}
+
+	// Check everything that can be checked before changing any state
+	var cpu core.Snapshot
+	data, ok := saved[cpuSection]
+	if !ok {
+		return errors.New("savestate: no CPU section")
+	}
+	if err := cpu.UnmarshalBinary(data); err != nil {
+		return fmt.Errorf("savestate: %w", err)
+	}
+	if cpu.Variant != m.CPU.GetVariant() {
+		return fmt.Errorf("savestate: state is for a %v CPU, not %v", cpu.Variant, m.CPU.GetVariant())
+	}
+	for name := range saved {
+		if name != cpuSection && m.section(name) == nil {
+			return fmt.Errorf("savestate: section %q is not registered", name)
+		}
+	}
+	for _, s := range m.sections {
+		if _, ok := saved[s.name]; !ok {
+			return fmt.Errorf("savestate: no %q section", s.name)
+		}
+	}
+
+	if err := m.CPU.Restore(cpu); err != nil {
+		return fmt.Errorf("savestate: %w", err)
+	}
+	for _, s := range m.sections {
+		if err := s.section.UnmarshalBinary(saved[s.name]); err != nil {
+			return fmt.Errorf("savestate: loading %s: %w", s.name, err)
+		}
+	}
+	return nil
+}
+
+func readSection(r io.Reader) (string, []byte, error) {
+	var n [1]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return "", nil, err
+	}
+	name := make([]byte, n[0])
+	if _, err := io.ReadFull(r, name); err != nil {
+		return "", nil, err
+	}
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return "", nil, err
+	}
+	// Grow the buffer as the data arrives rather than trusting size, so that
+	// a corrupt length cannot make Load allocate gigabytes
+	var data bytes.Buffer
+	if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", nil, fmt.Errorf("section %q: %w", name, err)
+	}
+	return string(name), data.Bytes(), nil
+}
+
+// SaveFile saves the machine to the file at path, replacing it.
+func (m *Machine) SaveFile(path string) error {
+	var b bytes.Buffer
+	if err := m.Save(&b); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// LoadFile loads the machine from the file at path.
+func (m *Machine) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.Load(f)
+}
@@ -0,0 +1,269 @@
+package savestate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/asm"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// counter is a device at $D000 that returns a count that goes up on every
+// read, so a run depends on its state as well as on memory.
+type counter struct {
+	count uint16
+}
+
+func (c *counter) MarshalBinary() ([]byte, error) {
+	return binary.LittleEndian.AppendUint16(nil, c.count), nil
+}
+
+func (c *counter) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return errors.New("bad counter state")
+	}
+	c.count = binary.LittleEndian.Uint16(data)
+	return nil
+}
+
+// testBus is RAM with a counter mapped at $D000.
+type testBus struct {
+	memory  [0x10000]byte
+	counter counter
+}
+
+func (b *testBus) Read(addr uint16) byte {
+	if addr == 0xD000 {
+		b.counter.count++
+		return byte(b.counter.count)
+	}
+	return b.memory[addr]
+}
+
+func (b *testBus) Write(addr uint16, data byte) {
+	b.memory[addr] = data
+}
+
+const program = `
+        .org $0200
+start:  ldx #0
+loop:   lda $D000
+        adc $10,x
+        sta $10,x
+        inx
+        bne loop
+        inc $0300
+        jmp start
+
+        .org $FFFC
+        .word start
+`
+
+// newMachine returns a machine for variant with the test program loaded and
+// the RAM and counter registered.
+func newMachine(t *testing.T, variant core.Variant) (*Machine, *testBus) {
+	t.Helper()
+	bus := &testBus{}
+	asm.MustAssemble(variant, program).Load(bus)
+	cpu, err := core.NewCPU(variant, bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu.Reset()
+
+	m := New(cpu)
+	m.Register("ram", Memory(bus.memory[:]))
+	m.Register("counter", &bus.counter)
+	return m, bus
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	for _, variant := range []core.Variant{core.VariantNMOS, core.VariantWDC65C02} {
+		t.Run(variant.String(), func(t *testing.T) {
+			m, bus := newMachine(t, variant)
+			m.CPU.RunCycles(12345) // Stop partway through an instruction
+			m.CPU.TriggerIRQ()
+
+			var saved bytes.Buffer
+			if err := m.Save(&saved); err != nil {
+				t.Fatal(err)
+			}
+			m.CPU.RunCycles(50000)
+			want, wantBus := m.CPU.Snapshot(), *bus
+
+			// Loading into a fresh machine continues the run exactly
+			m2, bus2 := newMachine(t, variant)
+			if err := m2.Load(bytes.NewReader(saved.Bytes())); err != nil {
+				t.Fatal(err)
+			}
+			m2.CPU.RunCycles(50000)
+			if got := m2.CPU.Snapshot(); got != want {
+				t.Errorf("CPU state after loading:\ngot  %+v\nwant %+v", got, want)
+			}
+			if bus2.memory != wantBus.memory || bus2.counter != wantBus.counter {
+				t.Error("memory or device state differs after loading")
+			}
+		})
+	}
+}
+
+func TestSaveAtBreakpoint(t *testing.T) {
+	atLoop := func(pc uint16) bool { return pc == 0x0202 }
+	m, _ := newMachine(t, core.VariantNMOS)
+	m.CPU.SetBreakFunc(atLoop)
+	for {
+		if _, err := m.CPU.StepInstruction(); errors.Is(err, core.ErrBreakpoint) {
+			break
+		}
+	}
+	var saved bytes.Buffer
+	if err := m.Save(&saved); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stepping after loading runs the instruction instead of stopping again
+	m2, _ := newMachine(t, core.VariantNMOS)
+	m2.CPU.SetBreakFunc(atLoop)
+	if err := m2.Load(bytes.NewReader(saved.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m2.CPU.StepInstruction(); err != nil {
+		t.Fatal(err)
+	}
+	if pc := m2.CPU.Registers().PC; pc != 0x0205 {
+		t.Errorf("PC = $%04X, want $0205", pc)
+	}
+}
+
+// failing is a section that cannot be saved.
+type failing struct{}
+
+func (failing) MarshalBinary() ([]byte, error) { return nil, errors.New("device busy") }
+func (failing) UnmarshalBinary([]byte) error   { return nil }
+
+func TestSaveError(t *testing.T) {
+	m, _ := newMachine(t, core.VariantNMOS)
+	m.Register("via", failing{})
+	var saved bytes.Buffer
+	err := m.Save(&saved)
+	if err == nil || err.Error() != "savestate: saving via: device busy" {
+		t.Fatalf("got error %v", err)
+	}
+	if saved.Len() != 0 {
+		t.Error("Save wrote a partial state")
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	m, bus := newMachine(t, core.VariantNMOS)
+	m.CPU.RunCycles(1000)
+	path := filepath.Join(t.TempDir(), "state.sav")
+	if err := m.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	want := m.CPU.Snapshot()
+
+	m.CPU.RunCycles(1000)
+	bus.memory[0x0300] = 0xFF
+	if err := m.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.CPU.Snapshot(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if bus.memory[0x0300] == 0xFF {
+		t.Error("memory was not restored")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	m, _ := newMachine(t, core.VariantNMOS)
+	var saved bytes.Buffer
+	m.Save(&saved)
+	valid := saved.Bytes()
+
+	other := New(m.CPU)
+	other.Register("ram", Memory(make([]byte, 0x10000)))
+
+	wdc, _ := newMachine(t, core.VariantWDC65C02)
+
+	extra, _ := newMachine(t, core.VariantNMOS)
+	extra.Register("via", Memory(make([]byte, 16)))
+
+	newer := bytes.Clone(valid)
+	newer[8] = Version + 1
+
+	tests := []struct {
+		name string
+		m    *Machine
+		data []byte
+		want string
+	}{
+		{"Empty", m, nil, "reading header"},
+		{"Bad magic", m, append([]byte("NOTSAVED"), valid[8:]...), "not a saved state"},
+		{"Newer version", m, newer, "unsupported version 2"},
+		{"Truncated", m, valid[:len(valid)-10], "unexpected EOF"},
+		{"Other variant", wdc, valid, "state is for a NMOS6502 CPU, not WDC65C02"},
+		{"Unregistered section", other, valid, `section "counter" is not registered`},
+		{"Missing section", extra, valid, `no "via" section`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.m.CPU.Snapshot()
+			err := tt.m.Load(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want %q", err, tt.want)
+			}
+			if tt.m.CPU.Snapshot() != before {
+				t.Error("CPU state changed by a failed load")
+			}
+		})
+	}
+}
+
+// TestLoadHugeSection checks that a length field larger than the file is
+// reported as truncation without allocating the length it claims.
+func TestLoadHugeSection(t *testing.T) {
+	m, _ := newMachine(t, core.VariantNMOS)
+	var saved bytes.Buffer
+	m.Save(&saved)
+
+	data := bytes.Clone(saved.Bytes()[:12]) // Magic, version and count
+	data = append(data, 3, 'c', 'p', 'u')
+	data = binary.LittleEndian.AppendUint32(data, math.MaxUint32)
+	data = append(data, 1, 2, 3, 4)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	err := m.Load(bytes.NewReader(data))
+	runtime.ReadMemStats(&after)
+
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got error %v, want unexpected EOF", err)
+	}
+	if grown := after.TotalAlloc - before.TotalAlloc; grown > 1<<20 {
+		t.Errorf("allocated %d bytes for a 4-byte section", grown)
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	m, _ := newMachine(t, core.VariantNMOS)
+	for _, name := range []string{"cpu", "ram", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) did not panic", name)
+				}
+			}()
+			m.Register(name, Memory(nil))
+		}()
+	}
+}
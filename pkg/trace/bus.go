@@ -0,0 +1,84 @@
+package trace
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// BusLog records bus cycles for export, one row per access. Install it on
+// a CPU with
+//
+//	cpu.SetBusTrace(log.Record)
+type BusLog struct {
+	Cycles []core.BusCycle
+
+	// Limit, if positive, keeps only the most recent Limit cycles.
+	Limit int
+}
+
+// Record appends a bus cycle.
+func (l *BusLog) Record(c core.BusCycle) {
+	l.Cycles = append(l.Cycles, c)
+	if l.Limit > 0 && len(l.Cycles) > l.Limit {
+		l.Cycles = l.Cycles[len(l.Cycles)-l.Limit:]
+	}
+}
+
+// Reset discards the recorded cycles.
+func (l *BusLog) Reset() {
+	l.Cycles = nil
+}
+
+// WriteCSV writes the cycles as CSV with a header row:
+//
+//	cycle,address,data,rw,kind
+//	7,C000,4C,R,opcode
+//
+// Addresses and data are in hex, without a prefix.
+func (l *BusLog) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"cycle", "address", "data", "rw", "kind"})
+	for _, c := range l.Cycles {
+		cw.Write([]string{
+			strconv.FormatUint(c.Cycle, 10),
+			fmt.Sprintf("%04X", c.Addr),
+			fmt.Sprintf("%02X", c.Data),
+			rw(c.Write),
+			c.Kind.String(),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// busCycleJSON is the JSON form of a bus cycle.
+type busCycleJSON struct {
+	Cycle   uint64 `json:"cycle"`
+	Address uint16 `json:"address"`
+	Data    byte   `json:"data"`
+	RW      string `json:"rw"`
+	Kind    string `json:"kind"`
+}
+
+// WriteJSON writes the cycles as a JSON array of objects:
+//
+//	{"cycle":7,"address":49152,"data":76,"rw":"R","kind":"opcode"}
+func (l *BusLog) WriteJSON(w io.Writer) error {
+	rows := make([]busCycleJSON, len(l.Cycles))
+	for i, c := range l.Cycles {
+		rows[i] = busCycleJSON{c.Cycle, c.Addr, c.Data, rw(c.Write), c.Kind.String()}
+	}
+	return json.NewEncoder(w).Encode(rows)
+}
+
+func rw(write bool) string {
+	if write {
+		return "W"
+	}
+	return "R"
+}
@@ -0,0 +1,199 @@
+package trace
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Entry is a parsed trace line.
+type Entry struct {
+	PC             uint16
+	Bytes          []byte
+	Disasm         string // With the '*' mark for undocumented opcodes
+	A, X, Y, P, SP byte
+	Cycles         uint64
+	HasCycles      bool // The line has a CYC field
+}
+
+// Parse parses a line in nestest.log format. Column positions are not
+// relied on, so logs from other emulators with the same fields also parse,
+// and fields other than these, such as PPU, are ignored.
+func Parse(line string) (Entry, error) {
+	var e Entry
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return e, fmt.Errorf("empty line")
+	}
+	pc, err := strconv.ParseUint(fields[0], 16, 16)
+	if err != nil || len(fields[0]) != 4 {
+		return e, fmt.Errorf("bad PC %q", fields[0])
+	}
+	e.PC = uint16(pc)
+
+	i := 1
+	for ; i < len(fields) && i <= 3 && len(fields[i]) == 2; i++ {
+		b, err := strconv.ParseUint(fields[i], 16, 8)
+		if err != nil {
+			break
+		}
+		e.Bytes = append(e.Bytes, byte(b))
+	}
+
+	// The disassembly runs up to the A: field
+	var dis []string
+	for ; i < len(fields) && !strings.HasPrefix(fields[i], "A:"); i++ {
+		dis = append(dis, fields[i])
+	}
+	e.Disasm = strings.Join(dis, " ")
+
+	regs := map[string]*byte{"A": &e.A, "X": &e.X, "Y": &e.Y, "P": &e.P, "SP": &e.SP}
+	seen := 0
+	for ; i < len(fields); i++ {
+		name, value, ok := strings.Cut(fields[i], ":")
+		if !ok {
+			continue
+		}
+		if reg, ok := regs[name]; ok {
+			v, err := strconv.ParseUint(value, 16, 8)
+			if err != nil {
+				return e, fmt.Errorf("bad %s value %q", name, value)
+			}
+			*reg = byte(v)
+			seen++
+		} else if name == "CYC" {
+			e.Cycles, err = strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return e, fmt.Errorf("bad CYC value %q", value)
+			}
+			e.HasCycles = true
+		}
+	}
+	if seen != len(regs) {
+		return e, fmt.Errorf("missing registers")
+	}
+	return e, nil
+}
+
+// DiffOptions controls what Diff compares.
+type DiffOptions struct {
+	Context      int  // Matching lines to show before a divergence
+	IgnoreDisasm bool // Compare only PC, bytes, registers and cycles
+	IgnoreCycles bool
+}
+
+// Divergence is the first difference between two traces.
+type Divergence struct {
+	Line      int      // 1-based line number
+	Got, Want string   // The differing lines; empty if that trace ended
+	Fields    []string // Names of the differing fields, e.g. "A", "P", "CYC"
+	Context   []string // Matching lines before the divergence
+}
+
+func (d *Divergence) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "line %d: %s differs\n", d.Line, strings.Join(d.Fields, ", "))
+	for i, line := range d.Context {
+		fmt.Fprintf(&b, "  %6d  %s\n", d.Line-len(d.Context)+i, line)
+	}
+	fmt.Fprintf(&b, "- %6d  %s\n", d.Line, orEnd(d.Want))
+	fmt.Fprintf(&b, "+ %6d  %s\n", d.Line, orEnd(d.Got))
+	return b.String()
+}
+
+func orEnd(line string) string {
+	if line == "" {
+		return "(end of trace)"
+	}
+	return line
+}
+
+// Compare returns the names of the fields that differ between two entries.
+// Bytes and cycles are only compared when both entries have them.
+func Compare(got, want Entry, opts DiffOptions) []string {
+	var fields []string
+	if got.PC != want.PC {
+		fields = append(fields, "PC")
+	}
+	if len(got.Bytes) > 0 && len(want.Bytes) > 0 && string(got.Bytes) != string(want.Bytes) {
+		fields = append(fields, "bytes")
+	}
+	if !opts.IgnoreDisasm && got.Disasm != want.Disasm {
+		fields = append(fields, "disassembly")
+	}
+	for _, r := range []struct {
+		name      string
+		got, want byte
+	}{
+		{"A", got.A, want.A},
+		{"X", got.X, want.X},
+		{"Y", got.Y, want.Y},
+		{"P", got.P, want.P},
+		{"SP", got.SP, want.SP},
+	} {
+		if r.got != r.want {
+			fields = append(fields, r.name)
+		}
+	}
+	if !opts.IgnoreCycles && got.HasCycles && want.HasCycles && got.Cycles != want.Cycles {
+		fields = append(fields, "CYC")
+	}
+	return fields
+}
+
+// Diff compares two traces line by line and returns the first divergence,
+// or nil if they match. A trace that ends early diverges where it ends.
+// Blank lines are skipped.
+func Diff(got, want io.Reader, opts DiffOptions) (*Divergence, error) {
+	gs, ws := bufio.NewScanner(got), bufio.NewScanner(want)
+	var context []string
+	for n := 1; ; n++ {
+		g, gok := nextLine(gs)
+		w, wok := nextLine(ws)
+		if !gok && !wok {
+			break
+		}
+
+		var fields []string
+		switch {
+		case !gok || !wok:
+			fields = []string{"length"}
+		default:
+			ge, err := Parse(g)
+			if err != nil {
+				return nil, fmt.Errorf("trace: line %d: %w", n, err)
+			}
+			we, err := Parse(w)
+			if err != nil {
+				return nil, fmt.Errorf("trace: reference line %d: %w", n, err)
+			}
+			fields = Compare(ge, we, opts)
+		}
+		if len(fields) > 0 {
+			return &Divergence{Line: n, Got: g, Want: w, Fields: fields, Context: context}, nil
+		}
+
+		if opts.Context > 0 {
+			if len(context) == opts.Context {
+				context = context[1:]
+			}
+			context = append(context, w)
+		}
+	}
+	if err := gs.Err(); err != nil {
+		return nil, err
+	}
+	return nil, ws.Err()
+}
+
+// nextLine returns the next non-blank line.
+func nextLine(s *bufio.Scanner) (string, bool) {
+	for s.Scan() {
+		if line := strings.TrimRight(s.Text(), " \r"); line != "" {
+			return line, true
+		}
+	}
+	return "", false
+}
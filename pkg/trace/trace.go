@@ -0,0 +1,153 @@
+// Package trace logs execution one line per instruction in the format of
+// nestest.log, the reference log for the nestest CPU test ROM, and compares
+// such logs to find where two runs diverge.
+//
+// A line shows the registers before the instruction executes:
+//
+//	C5F7  86 00     STX $00 = 00                    A:00 X:00 Y:00 P:26 SP:FD CYC:12
+//
+// The disassembly follows nestest: undocumented opcodes are marked with
+// '*', memory operands show the value they read before the instruction
+// runs, and indexed and indirect operands show the effective address:
+//
+//	LDA $0300,X @ 0305 = 5A
+//	LDA ($80,X) @ 82 = 0300 = 5A
+//	LDA ($89),Y = 0300 @ 0305 = 5A
+//	JMP ($0200) = DB7E
+//
+// P is shown without the B flag, which exists only on the stack, and with
+// the unused bit set. nestest.log also has a PPU column, which is not
+// written; Diff ignores it.
+package trace
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/disasm"
+)
+
+// mnemonics maps the names in this module's opcode tables to the names
+// nestest uses, where they differ.
+var mnemonics = map[string]string{
+	"ISC": "ISB",
+}
+
+// Tracer steps a CPU and writes a line for each instruction.
+type Tracer struct {
+	CPU core.CPU
+	W   io.Writer
+
+	// Cycles is the cycle count shown for the next instruction. New sets
+	// it from the CPU's TotalCycles; nestest.log starts at 7.
+	Cycles uint64
+
+	bus core.Bus
+	dis *disasm.Disassembler
+}
+
+// New returns a tracer for cpu that writes to w. Operands are read from bus
+// for display, so it should be free of side effects: pass the memory the
+// CPU's bus is built on, not a bus with I/O registers.
+func New(cpu core.CPU, bus core.Bus, w io.Writer) (*Tracer, error) {
+	dis, err := disasm.New(bus, cpu.GetVariant())
+	if err != nil {
+		return nil, err
+	}
+	return &Tracer{CPU: cpu, W: w, Cycles: cpu.Snapshot().TotalCycles, bus: bus, dis: dis}, nil
+}
+
+// Step executes one instruction or interrupt sequence. Instructions are
+// logged, interrupt sequences and idle cycles only add to Cycles. It returns
+// the CPU's error, or the error from writing the line.
+func (t *Tracer) Step() (core.StepResult, error) {
+	line := t.Line()
+	result, err := t.CPU.StepInstruction()
+	t.Cycles += uint64(result.Cycles)
+	if result.Event == core.EventInstruction || result.Event == core.EventUnknownOpcode {
+		if _, werr := io.WriteString(t.W, line+"\n"); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return result, err
+}
+
+// Run steps until the CPU returns an error or n instructions have been
+// logged.
+func (t *Tracer) Run(n int) error {
+	for logged := 0; logged < n; {
+		result, err := t.Step()
+		if err != nil {
+			return err
+		}
+		if result.Event == core.EventInstruction {
+			logged++
+		}
+	}
+	return nil
+}
+
+// Line returns the line for the instruction at PC, without a newline.
+func (t *Tracer) Line() string {
+	r := t.CPU.Registers()
+	t.dis.Registers = &r
+	ins := t.dis.Decode(r.PC)
+
+	bytes := make([]string, len(ins.Bytes))
+	for i, b := range ins.Bytes {
+		bytes[i] = fmt.Sprintf("%02X", b)
+	}
+	mark := ' '
+	if ins.Illegal {
+		mark = '*'
+	}
+	p := r.Status&^core.FlagBreak | core.FlagUnused
+
+	return fmt.Sprintf("%04X  %-9s%c%-32sA:%02X X:%02X Y:%02X P:%02X SP:%02X CYC:%d",
+		r.PC, strings.Join(bytes, " "), mark, t.disassemble(ins, r),
+		r.A, r.X, r.Y, p, r.SP, t.Cycles)
+}
+
+// disassemble formats an instruction with nestest's operand annotations.
+func (t *Tracer) disassemble(ins disasm.Instruction, r core.Registers) string {
+	mnemonic := ins.Mnemonic
+	if m, ok := mnemonics[mnemonic]; ok {
+		mnemonic = m
+	}
+	s := mnemonic
+	if ins.Operand != "" {
+		s += " " + ins.Operand
+	}
+
+	var b1 byte
+	if len(ins.Bytes) > 1 {
+		b1 = ins.Bytes[1]
+	}
+	value := func(addr uint16) string { return fmt.Sprintf("%02X", t.bus.Read(addr)) }
+
+	switch ins.Mode {
+	case core.ZeroPage:
+		return s + " = " + value(ins.Target)
+	case core.Absolute:
+		if mnemonic == "JMP" || mnemonic == "JSR" {
+			return s
+		}
+		return s + " = " + value(ins.Target)
+	case core.ZeroPageX, core.ZeroPageY:
+		return fmt.Sprintf("%s @ %02X = %s", s, ins.Target, value(ins.Target))
+	case core.AbsoluteX, core.AbsoluteY:
+		return fmt.Sprintf("%s @ %04X = %s", s, ins.Target, value(ins.Target))
+	case core.Indirect, core.AbsoluteXIndirect:
+		return fmt.Sprintf("%s = %04X", s, ins.Target)
+	case core.IndirectX:
+		return fmt.Sprintf("%s @ %02X = %04X = %s", s, b1+r.X, ins.Target, value(ins.Target))
+	case core.IndirectY:
+		base := ins.Target - uint16(r.Y)
+		return fmt.Sprintf("%s = %04X @ %04X = %s", s, base, ins.Target, value(ins.Target))
+	case core.ZeroPageIndirect:
+		return fmt.Sprintf("%s = %04X = %s", s, ins.Target, value(ins.Target))
+	}
+	return s
+}
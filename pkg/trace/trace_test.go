@@ -0,0 +1,297 @@
+package trace
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/asm"
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// SimpleRAM is a simple RAM implementation for testing
+type SimpleRAM struct {
+	memory [0x10000]byte
+}
+
+func (r *SimpleRAM) Read(addr uint16) byte {
+	return r.memory[addr]
+}
+
+func (r *SimpleRAM) Write(addr uint16, data byte) {
+	r.memory[addr] = data
+}
+
+// nestestStart is the start of nestest.log with the PPU column removed.
+const nestestStart = `C000  4C F5 C5  JMP $C5F5                       A:00 X:00 Y:00 P:24 SP:FD CYC:7
+C5F5  A2 00     LDX #$00                        A:00 X:00 Y:00 P:24 SP:FD CYC:10
+C5F7  86 00     STX $00 = 00                    A:00 X:00 Y:00 P:26 SP:FD CYC:12
+C5F9  86 10     STX $10 = 00                    A:00 X:00 Y:00 P:26 SP:FD CYC:15
+C5FB  86 11     STX $11 = 00                    A:00 X:00 Y:00 P:26 SP:FD CYC:18
+C5FD  20 2D C7  JSR $C72D                       A:00 X:00 Y:00 P:26 SP:FD CYC:21
+C72D  EA        NOP                             A:00 X:00 Y:00 P:26 SP:FB CYC:27
+`
+
+// newTracer loads src, resets the CPU through the reset vector and returns a
+// tracer writing to b.
+func newTracer(t *testing.T, variant core.Variant, src string, b *strings.Builder) *Tracer {
+	t.Helper()
+	ram := &SimpleRAM{}
+	asm.MustAssemble(variant, src).Load(ram)
+	cpu, err := core.NewCPU(variant, ram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu.Reset()
+	tr, err := New(cpu, ram, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tr
+}
+
+func TestNestestFormat(t *testing.T) {
+	var b strings.Builder
+	tr := newTracer(t, core.VariantNMOS, `
+        .org $C000
+        jmp $C5F5
+        .org $C5F5
+        ldx #0
+        stx $00
+        stx $10
+        stx $11
+        jsr $C72D
+        .org $C72D
+        nop
+        .org $FFFC
+        .word $C000
+`, &b)
+	tr.Cycles = 7 // nestest counts the reset sequence as 7 cycles
+
+	if err := tr.Run(7); err != nil {
+		t.Fatal(err)
+	}
+	got, want := strings.Split(b.String(), "\n"), strings.Split(nestestStart, "\n")
+	if len(got) != len(want) {
+		t.Fatalf("expected %d lines, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d:\ngot  %q\nwant %q", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestOperands(t *testing.T) {
+	var b strings.Builder
+	tr := newTracer(t, core.VariantNMOS, `
+        .org $0200
+start:  ldx #5
+        ldy #2
+        lda $0300,x
+        lda $80,x
+        lda ($80,x)
+        lda ($89),y
+        lsr a
+        isc $10
+        jmp ($0220)
+        .org $0220
+        .word done
+done:   bne done
+        .org $0085
+        .word $0300
+        .org $0089
+        .word $0300
+        .org $0300
+        .byte 0,0,$11,0,0,$5A
+        .org $FFFC
+        .word start
+`, &b)
+	tr.Run(10)
+
+	var ops []string
+	trim := regexp.MustCompile(`^.{15}(.{32}).*$`)
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		ops = append(ops, strings.TrimSpace(trim.ReplaceAllString(line, "$1")))
+	}
+	want := []string{
+		"LDX #$05",
+		"LDY #$02",
+		"LDA $0300,X @ 0305 = 5A",
+		"LDA $80,X @ 85 = 00",
+		"LDA ($80,X) @ 85 = 0300 = 00",
+		"LDA ($89),Y = 0300 @ 0302 = 11",
+		"LSR A",
+		"*ISB $10 = 00",
+		"JMP ($0220) = 0222",
+		"BNE $0222",
+	}
+	if strings.Join(ops, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(ops, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestInterruptsAndErrors(t *testing.T) {
+	var b strings.Builder
+	tr := newTracer(t, core.VariantWDC65C02, `
+        .org $0200
+start:  cli
+        stp
+        .org $0300
+irq:    rti
+        .org $FFFC
+        .word start, irq
+`, &b)
+
+	tr.Step()
+	start := tr.Cycles
+	tr.CPU.TriggerIRQ()
+	if r, _ := tr.Step(); r.Event != core.EventIRQ {
+		t.Fatalf("expected the IRQ, got %v", r.Event)
+	}
+	if tr.Cycles != start+7 {
+		t.Errorf("expected the IRQ to add 7 cycles, got %d", tr.Cycles-start)
+	}
+	if err := tr.Run(10); !errors.Is(err, core.ErrStopped) {
+		t.Errorf("expected STP to stop the run, got %v", err)
+	}
+
+	// The interrupt sequence itself is not logged
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "RTI") || !strings.Contains(lines[2], "STP") {
+		t.Errorf("unexpected trace\n%s", b.String())
+	}
+}
+
+func TestParse(t *testing.T) {
+	e, err := Parse("C6BD  04 A9    *NOP $A9 = 00                    A:AA X:97 Y:4E P:EF SP:F5 PPU: 29, 61 CYC:3421")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.PC != 0xC6BD || string(e.Bytes) != "\x04\xA9" || e.Disasm != "*NOP $A9 = 00" ||
+		e.A != 0xAA || e.X != 0x97 || e.Y != 0x4E || e.P != 0xEF || e.SP != 0xF5 || !e.HasCycles || e.Cycles != 3421 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	for _, line := range []string{"", "C0 00", "C000  EA  NOP  A:00 X:00", "C000  EA  NOP  A:0G X:00 Y:00 P:24 SP:FD"} {
+		if _, err := Parse(line); err == nil {
+			t.Errorf("%q: expected an error", line)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	// PPU columns and spacing are ignored
+	want := strings.ReplaceAll(nestestStart, " CYC:", " PPU:  0, 21 CYC:")
+	if d, err := Diff(strings.NewReader(nestestStart), strings.NewReader(want), DiffOptions{}); d != nil || err != nil {
+		t.Errorf("expected a match, got %v, %v", d, err)
+	}
+
+	got := strings.Replace(nestestStart, "A:00 X:00 Y:00 P:26 SP:FD CYC:18", "A:00 X:00 Y:00 P:A4 SP:FD CYC:19", 1)
+	d, err := Diff(strings.NewReader(got), strings.NewReader(want), DiffOptions{Context: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d == nil || d.Line != 5 || strings.Join(d.Fields, ",") != "P,CYC" || len(d.Context) != 2 {
+		t.Fatalf("unexpected divergence %+v", d)
+	}
+	if s := d.String(); !strings.HasPrefix(s, "line 5: P, CYC differs\n       3  C5F7") || !strings.Contains(s, "+      5  C5FB") {
+		t.Errorf("unexpected report\n%s", s)
+	}
+
+	d, _ = Diff(strings.NewReader(got), strings.NewReader(want), DiffOptions{IgnoreCycles: true})
+	if d == nil || strings.Join(d.Fields, ",") != "P" {
+		t.Errorf("expected only P to differ, got %+v", d)
+	}
+
+	// A trace that stops early diverges at its end
+	short := strings.Join(strings.Split(nestestStart, "\n")[:3], "\n")
+	d, _ = Diff(strings.NewReader(short), strings.NewReader(want), DiffOptions{})
+	if d == nil || d.Line != 4 || d.Got != "" || !strings.Contains(d.String(), "(end of trace)") {
+		t.Errorf("unexpected divergence for a short trace %+v", d)
+	}
+
+	if _, err := Diff(strings.NewReader("garbage"), strings.NewReader(want), DiffOptions{}); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestBusLog(t *testing.T) {
+	ram := &SimpleRAM{}
+	asm.MustAssemble(core.VariantNMOS, `
+        .org $0200
+start:  lda #$12
+        sta $80
+        pha
+        .org $FFFC
+        .word start
+`).Load(ram)
+	cpu, err := core.NewCPU(core.VariantNMOS, ram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log BusLog
+	cpu.SetBusTrace(log.Record)
+	cpu.Reset()
+	for i := 0; i < 3; i++ {
+		cpu.StepInstruction()
+	}
+
+	want := []core.BusCycle{
+		{Cycle: 0, Addr: 0x0000, Data: 0x00, Kind: core.AccessDummy},
+		{Cycle: 1, Addr: 0x0100, Data: 0x00, Kind: core.AccessDummy},
+		{Cycle: 2, Addr: 0x01FF, Data: 0x00, Kind: core.AccessDummy},
+		{Cycle: 3, Addr: 0x01FE, Data: 0x00, Kind: core.AccessDummy},
+		{Cycle: 4, Addr: 0xFFFC, Data: 0x00, Kind: core.AccessVector},
+		{Cycle: 5, Addr: 0xFFFD, Data: 0x02, Kind: core.AccessVector},
+		{Cycle: 6, Addr: 0x0200, Data: 0xA9, Kind: core.AccessOpcode},
+		{Cycle: 7, Addr: 0x0201, Data: 0x12, Kind: core.AccessOperand},
+		{Cycle: 8, Addr: 0x0202, Data: 0x85, Kind: core.AccessOpcode},
+		{Cycle: 9, Addr: 0x0203, Data: 0x80, Kind: core.AccessOperand},
+		{Cycle: 10, Addr: 0x0080, Data: 0x12, Write: true, Kind: core.AccessData},
+		{Cycle: 11, Addr: 0x0204, Data: 0x48, Kind: core.AccessOpcode},
+		{Cycle: 12, Addr: 0x0205, Data: 0x00, Kind: core.AccessDummy},
+		{Cycle: 13, Addr: 0x01FD, Data: 0x12, Write: true, Kind: core.AccessStack},
+	}
+	if len(log.Cycles) != len(want) {
+		t.Fatalf("expected %d cycles, got %+v", len(want), log.Cycles)
+	}
+	for i := range want {
+		if log.Cycles[i] != want[i] {
+			t.Errorf("cycle %d: got %+v, want %+v", i, log.Cycles[i], want[i])
+		}
+	}
+
+	var b strings.Builder
+	if err := log.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	csv := strings.Split(b.String(), "\n")
+	if csv[0] != "cycle,address,data,rw,kind" || csv[7] != "6,0200,A9,R,opcode" || csv[11] != "10,0080,12,W,data" {
+		t.Errorf("unexpected CSV\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := log.WriteJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), `[{"cycle":0,"address":0,"data":0,"rw":"R","kind":"dummy"},`) {
+		t.Errorf("unexpected JSON\n%s", b.String())
+	}
+
+	// Removing the trace stops recording
+	cpu.SetBusTrace(nil)
+	cpu.Reset()
+	if len(log.Cycles) != len(want) {
+		t.Errorf("expected no more cycles after removing the trace, got %d", len(log.Cycles))
+	}
+
+	log = BusLog{Limit: 2}
+	for i := uint64(0); i < 5; i++ {
+		log.Record(core.BusCycle{Cycle: i})
+	}
+	if len(log.Cycles) != 2 || log.Cycles[0].Cycle != 3 {
+		t.Errorf("expected the last 2 cycles, got %+v", log.Cycles)
+	}
+}
@@ -0,0 +1,197 @@
+package wdc65c02
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+)
+
+// busCycles runs one instruction or interrupt sequence and returns its bus
+// cycles as "R 0200 EE", the direction, address and data.
+func busCycles(cpu *CPU) []string {
+	var cycles []string
+	cpu.SetBusTrace(func(c core.BusCycle) {
+		rw := "R"
+		if c.Write {
+			rw = "W"
+		}
+		cycles = append(cycles, fmt.Sprintf("%s %04X %02X", rw, c.Addr, c.Data))
+	})
+	cpu.StepInstruction()
+	cpu.SetBusTrace(nil)
+	return cycles
+}
+
+// TestEveryCycleIsABusAccess runs every opcode with operands that do and do
+// not cross pages, and with every flag clear and set, and checks that it
+// makes one bus access per cycle.
+func TestEveryCycleIsABusAccess(t *testing.T) {
+	for op := 0; op < 0x100; op++ {
+		for _, operand := range [][2]byte{{0x10, 0x20}, {0xF8, 0x20}} {
+			for _, status := range []byte{0x20, 0xFF} {
+				ram := &SimpleRAM{}
+				for i := range ram.memory {
+					ram.memory[i] = 0x20 // Pointers everywhere lead to $2020
+				}
+				copy(ram.memory[0x0200:], []byte{byte(op), operand[0], operand[1]})
+				cpu := NewCPU(ram)
+				cpu.PC = 0x0200
+				cpu.X, cpu.Y = 0x10, 0x10
+				cpu.Status = status
+
+				var accesses int
+				cpu.SetBusTrace(func(core.BusCycle) { accesses++ })
+				result, _ := cpu.StepInstruction()
+				if accesses != result.Cycles {
+					t.Errorf("opcode $%02X operand %02X%02X P=%02X: %d accesses in %d cycles",
+						op, operand[1], operand[0], status, accesses, result.Cycles)
+				}
+			}
+		}
+	}
+}
+
+// TestStepMatchesStepInstruction runs every opcode one Step at a time and
+// checks that each Step makes exactly one bus access and that the cycles and
+// final registers match running the whole instruction with StepInstruction.
+func TestStepMatchesStepInstruction(t *testing.T) {
+	for op := 0; op < 0x100; op++ {
+		for _, operand := range [][2]byte{{0x10, 0x20}, {0xF8, 0x20}} {
+			for _, status := range []byte{0x20, 0xFF} {
+				newCPU := func() *CPU {
+					ram := &SimpleRAM{}
+					for i := range ram.memory {
+						ram.memory[i] = 0x20
+					}
+					copy(ram.memory[0x0200:], []byte{byte(op), operand[0], operand[1]})
+					cpu := NewCPU(ram)
+					cpu.PC = 0x0200
+					cpu.X, cpu.Y = 0x10, 0x10
+					cpu.Status = status
+					return cpu
+				}
+				name := fmt.Sprintf("opcode $%02X operand %02X%02X P=%02X", op, operand[1], operand[0], status)
+
+				whole := newCPU()
+				want := busCycles(whole)
+
+				stepped := newCPU()
+				var got []string
+				stepped.SetBusTrace(func(c core.BusCycle) {
+					rw := "R"
+					if c.Write {
+						rw = "W"
+					}
+					got = append(got, fmt.Sprintf("%s %04X %02X", rw, c.Addr, c.Data))
+				})
+				for steps := 1; steps <= core.MaxSequenceCycles; steps++ {
+					stepped.Step()
+					if len(got) != steps {
+						t.Fatalf("%s: %d accesses after %d steps", name, len(got), steps)
+					}
+					if !stepped.InSequence() {
+						break
+					}
+				}
+
+				if strings.Join(got, ", ") != strings.Join(want, ", ") {
+					t.Errorf("%s:\n got  %v\n want %v", name, got, want)
+				}
+				if stepped.Registers() != whole.Registers() {
+					t.Errorf("%s: registers %+v, want %+v", name, stepped.Registers(), whole.Registers())
+				}
+			}
+		}
+	}
+}
+
+func TestBusSequences(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []byte
+		setup   func(cpu *CPU, ram *SimpleRAM)
+		want    []string
+	}{
+		{
+			name:    "INC reads twice instead of writing twice",
+			program: []byte{0xEE, 0x10, 0x20}, // INC $2010
+			setup:   func(cpu *CPU, ram *SimpleRAM) { ram.memory[0x2010] = 0x05 },
+			want:    []string{"R 0200 EE", "R 0201 10", "R 0202 20", "R 2010 05", "R 2010 05", "W 2010 06"},
+		},
+		{
+			name:    "LDA abs,X rereads the operand when crossing",
+			program: []byte{0xBD, 0xF0, 0x20}, // LDA $20F0,X
+			setup:   func(cpu *CPU, ram *SimpleRAM) { cpu.X = 0x20 },
+			want:    []string{"R 0200 BD", "R 0201 F0", "R 0202 20", "R 0202 20", "R 2110 00"},
+		},
+		{
+			name:    "STA abs,X",
+			program: []byte{0x9D, 0x10, 0x20}, // STA $2010,X
+			setup:   func(cpu *CPU, ram *SimpleRAM) { cpu.X, cpu.A = 0x01, 0x42 },
+			want:    []string{"R 0200 9D", "R 0201 10", "R 0202 20", "R 0202 20", "W 2011 42"},
+		},
+		{
+			name:    "LDA zp,X",
+			program: []byte{0xB5, 0x80}, // LDA $80,X
+			setup:   func(cpu *CPU, ram *SimpleRAM) { cpu.X = 0x90 },
+			want:    []string{"R 0200 B5", "R 0201 80", "R 0201 80", "R 0010 00"},
+		},
+		{
+			name:    "JMP (abs) takes a cycle to fix the page bug",
+			program: []byte{0x6C, 0xFF, 0x20}, // JMP ($20FF)
+			setup:   func(cpu *CPU, ram *SimpleRAM) { ram.memory[0x20FF], ram.memory[0x2100] = 0x34, 0x12 },
+			want:    []string{"R 0200 6C", "R 0201 FF", "R 0202 20", "R 0202 20", "R 20FF 34", "R 2100 12"},
+		},
+		{
+			name:    "TSB",
+			program: []byte{0x04, 0x10}, // TSB $10
+			setup:   func(cpu *CPU, ram *SimpleRAM) { ram.memory[0x0010], cpu.A = 0x01, 0x80 },
+			want:    []string{"R 0200 04", "R 0201 10", "R 0010 01", "R 0010 01", "W 0010 81"},
+		},
+		{
+			name:    "Decimal ADC",
+			program: []byte{0x69, 0x01}, // ADC #$01
+			setup:   func(cpu *CPU, ram *SimpleRAM) { cpu.SetFlag(core.FlagDecimal, true) },
+			want:    []string{"R 0200 69", "R 0201 01", "R 0201 01"},
+		},
+		{
+			name:    "BBR0 taken",
+			program: []byte{0x0F, 0x10, 0x02}, // BBR0 $10, *+5
+			want:    []string{"R 0200 0F", "R 0201 10", "R 0010 00", "R 0010 00", "R 0202 02", "R 0203 00"},
+		},
+		{
+			name:    "BBS0 not taken still reads the offset",
+			program: []byte{0x8F, 0x10, 0x02}, // BBS0 $10, *+5
+			want:    []string{"R 0200 8F", "R 0201 10", "R 0010 00", "R 0010 00", "R 0202 02"},
+		},
+		{
+			name:    "NOP $5C",
+			program: []byte{0x5C, 0x34, 0x12},
+			want:    []string{"R 0200 5C", "R 0201 34", "R 0202 12", "R FF34 00", "R FFFF 00", "R FFFF 00", "R FFFF 00", "R FFFF 00"},
+		},
+		{
+			name:    "WAI",
+			program: []byte{0xCB}, // WAI
+			want:    []string{"R 0200 CB", "R 0201 00", "R 0201 00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ram := &SimpleRAM{}
+			copy(ram.memory[0x0200:], tt.program)
+			cpu := NewCPU(ram)
+			cpu.PC = 0x0200
+			if tt.setup != nil {
+				tt.setup(cpu, ram)
+			}
+
+			got := busCycles(cpu)
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("got  %s\nwant %s", strings.Join(got, ", "), strings.Join(tt.want, ", "))
+			}
+		})
+	}
+}
@@ -45,53 +45,128 @@ func NewCPU(bus core.Bus) *CPU {
 	}
 }
 
-// Run executes instructions until the CPU is halted
-func (c *CPU) Run() {
-	for !c.Halted {
-		c.Step()
+var _ core.CPU = (*CPU)(nil)
+
+func init() {
+	core.Register(core.VariantWDC65C02, func(bus core.Bus) core.CPU {
+		return NewCPU(bus)
+	})
+}
+
+// Run executes until the CPU cannot continue and returns the reason, a
+// *core.StepError wrapping core.ErrStopped after STP, core.ErrBreakpoint or
+// core.ErrUnknownOpcode. A CPU waiting in WAI keeps running.
+func (c *CPU) Run() error {
+	for {
+		if _, err := c.StepInstruction(); err != nil {
+			return err
+		}
 	}
 }
 
-// Step executes a single CPU cycle
-func (c *CPU) Step() {
-	if c.Cycles == 0 {
-		if c.ResetPending {
-			c.HandleReset()
-			return
+// Step executes a single CPU cycle, which makes exactly one bus access. An
+// instruction or interrupt sequence takes as many calls as it has cycles,
+// and each register changes in the cycle that changes it.
+//
+// At an instruction boundary Step returns a *core.StepError if the CPU has
+// been stopped by STP, stops at a breakpoint or fetches an opcode it cannot
+// execute.
+func (c *CPU) Step() error {
+	if !c.InSequence() {
+		if event := c.dispatch(); !c.InSequence() {
+			return c.idle(event)
 		}
+	}
+	if event, done := c.StepSequence(&opcodes); done {
+		return c.StepError(event)
+	}
+	return nil
+}
+
+// idle runs the cycle of an event that starts no sequence: a waiting CPU
+// spends it doing nothing, and STP and a breakpoint stop before it.
+func (c *CPU) idle(event core.Event) error {
+	if event == core.EventWaiting {
+		c.TotalCycles++ // The clock keeps running while waiting
+	}
+	return c.StepError(event)
+}
+
+// StepInstruction executes exactly one instruction or interrupt sequence and
+// reports what happened. If Step has left one partway through, it runs the
+// rest of that one, and Cycles counts only the cycles run by this call. A
+// CPU waiting in WAI consumes one cycle per call until an interrupt wakes
+// it. The error is the same as Step's.
+func (c *CPU) StepInstruction() (core.StepResult, error) {
+	start := c.TotalCycles
+	result := core.StepResult{PC: c.SequencePC()}
 
-		if c.NMIPending {
-			c.HandleNMI()
-			return
+	if !c.InSequence() {
+		if result.Event = c.dispatch(); !c.InSequence() {
+			err := c.idle(result.Event)
+			result.Cycles = int(c.TotalCycles - start)
+			return result, err
 		}
+	}
+	result.Event = c.RunSequence(&opcodes)
+	if result.Event == core.EventInstruction || result.Event == core.EventUnknownOpcode {
+		result.Opcode = c.Opcode
+	}
+	result.Cycles = int(c.TotalCycles - start)
+	return result, c.StepError(result.Event)
+}
 
-		if c.IRQPending && !c.GetFlag(core.FlagInterruptDisable) {
-			c.HandleIRQ()
-			return
+// RunCycles executes n clock cycles, stopping early on an error, and
+// returns the number of cycles executed. Instructions that fit in what is
+// left of the budget are run whole; the budget may end partway through
+// one, and the next Step or StepInstruction finishes it.
+func (c *CPU) RunCycles(n int) (int, error) {
+	start := c.TotalCycles
+	for ran := 0; ran < n; ran = int(c.TotalCycles - start) {
+		var err error
+		if n-ran >= core.MaxSequenceCycles && !c.InSequence() {
+			_, err = c.StepInstruction()
+		} else {
+			err = c.Step()
+		}
+		if err != nil {
+			return int(c.TotalCycles - start), err
 		}
+	}
+	return int(c.TotalCycles - start), nil
+}
+
+// dispatch decides what starts at an instruction boundary. A reset,
+// interrupt or instruction is begun as a sequence for StepSequence or
+// RunSequence to run; other events start no sequence.
+func (c *CPU) dispatch() core.Event {
+	if c.ResetPending {
+		return c.BeginSequence(core.EventReset)
+	}
 
-		opcode := c.Bus.Read(c.PC)
-		c.PC++
+	if c.State == core.StateStopped {
+		return core.EventStopped // Only a reset restarts the clock
+	}
 
-		instruction, ok := instructionMap[opcode]
-		if !ok {
-			// On WDC65C02, all illegal opcodes are NOP
-			// Most are 1-byte, 1-cycle NOPs, but some vary
-			// For now, treat as 1-byte, 1-cycle NOP
-			c.Cycles = 1
-			return
+	if c.State == core.StateWaiting {
+		if !c.NMIPending && !c.IRQPending {
+			return core.EventWaiting // Sleep until an interrupt is asserted
 		}
+		// Wake up. With I set, an IRQ just resumes at the next instruction.
+		c.State = core.StateRunning
+	}
 
-		var addr uint16
-		var pageCrossed bool
+	if c.NMIPending {
+		return c.BeginSequence(core.EventNMI)
+	}
 
-		if instruction.AddrMode != nil {
-			addr, pageCrossed = instruction.AddrMode(c)
-		}
+	if c.IRQPending && !c.GetFlag(core.FlagInterruptDisable) {
+		return c.BeginSequence(core.EventIRQ)
+	}
 
-		instruction.Operation(c, addr, pageCrossed)
-		c.Cycles += instruction.Cycles
+	if c.BreakFunc != nil && c.CheckBreakpoint() {
+		return core.EventBreakpoint
 	}
 
-	c.Cycles--
+	return c.BeginSequence(core.EventInstruction)
 }
@@ -1,6 +1,10 @@
 package wdc65c02
 
 import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
 	"testing"
 
 	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
@@ -34,8 +38,8 @@ func TestWDC65C02Reset(t *testing.T) {
 	}
 
 	// Check that reset takes 7 cycles on WDC65C02 (not 6 like NMOS)
-	if cpu.Cycles != 7 {
-		t.Errorf("Expected 7 cycles after reset (per WDC65C02 datasheet), got %d", cpu.Cycles)
+	if cpu.TotalCycles != 7 {
+		t.Errorf("Expected reset to take 7 cycles (per WDC65C02 datasheet), got %d", cpu.TotalCycles)
 	}
 
 	// Check initial register state
@@ -139,11 +143,7 @@ func TestSTZ(t *testing.T) {
 			}
 
 			// Execute instruction
-			cpu.Step() // Consume any remaining cycles
-			for cpu.Cycles > 0 {
-				cpu.Step()
-			}
-			cpu.Step() // Execute the STZ instruction
+			cpu.StepInstruction() // Execute the STZ instruction
 
 			// Verify zero was stored
 			if ram.memory[tt.addr] != 0x00 {
@@ -164,22 +164,16 @@ func TestBRA(t *testing.T) {
 		ram.memory[0x0200] = 0x80 // BRA
 		ram.memory[0x0201] = 0x10 // +16 bytes
 
-		initialCycles := cpu.Cycles
-		cpu.Step()
-		for cpu.Cycles > 0 {
-			cpu.Step()
-		}
-		cpu.Step()
+		result, _ := cpu.StepInstruction()
 
 		expectedPC := uint16(0x0202 + 0x10)
 		if cpu.PC != expectedPC {
 			t.Errorf("Expected PC to be 0x%04X, got 0x%04X", expectedPC, cpu.PC)
 		}
 
-		// BRA should take 3 cycles base + 1 for branch taken = 4 total
-		cyclesTaken := initialCycles - cpu.Cycles + 3 // +3 for the instruction base cycles
-		if cyclesTaken < 3 {
-			t.Errorf("Expected at least 3 cycles for BRA, got %d", cyclesTaken)
+		// BRA should take 2 cycles base + 1 for branch taken = 3 total
+		if result.Cycles != 3 {
+			t.Errorf("Expected 3 cycles for BRA, got %d", result.Cycles)
 		}
 	})
 
@@ -189,11 +183,7 @@ func TestBRA(t *testing.T) {
 		ram.memory[0x0220] = 0x80 // BRA
 		ram.memory[0x0221] = 0xFE // -2 bytes
 
-		cpu.Step()
-		for cpu.Cycles > 0 {
-			cpu.Step()
-		}
-		cpu.Step()
+		cpu.StepInstruction()
 
 		expectedPC := uint16(0x0220)
 		if cpu.PC != expectedPC {
@@ -214,11 +204,7 @@ func TestPHXPLX(t *testing.T) {
 
 	ram.memory[0x0200] = 0xDA // PHX
 
-	cpu.Step()
-	for cpu.Cycles > 0 {
-		cpu.Step()
-	}
-	cpu.Step()
+	cpu.StepInstruction()
 
 	if ram.memory[0x01FD] != 0x42 {
 		t.Errorf("Expected 0x42 on stack, got 0x%02X", ram.memory[0x01FD])
@@ -233,11 +219,7 @@ func TestPHXPLX(t *testing.T) {
 
 	ram.memory[0x0201] = 0xFA // PLX
 
-	cpu.Step()
-	for cpu.Cycles > 0 {
-		cpu.Step()
-	}
-	cpu.Step()
+	cpu.StepInstruction()
 
 	if cpu.X != 0x42 {
 		t.Errorf("Expected X to be 0x42, got 0x%02X", cpu.X)
@@ -259,11 +241,7 @@ func TestPHYPLY(t *testing.T) {
 
 	ram.memory[0x0200] = 0x5A // PHY
 
-	cpu.Step()
-	for cpu.Cycles > 0 {
-		cpu.Step()
-	}
-	cpu.Step()
+	cpu.StepInstruction()
 
 	if ram.memory[0x01FD] != 0x84 {
 		t.Errorf("Expected 0x84 on stack, got 0x%02X", ram.memory[0x01FD])
@@ -275,11 +253,7 @@ func TestPHYPLY(t *testing.T) {
 
 	ram.memory[0x0201] = 0x7A // PLY
 
-	cpu.Step()
-	for cpu.Cycles > 0 {
-		cpu.Step()
-	}
-	cpu.Step()
+	cpu.StepInstruction()
 
 	if cpu.Y != 0x84 {
 		t.Errorf("Expected Y to be 0x84, got 0x%02X", cpu.Y)
@@ -298,11 +272,7 @@ func TestINCDECAccumulator(t *testing.T) {
 
 		ram.memory[0x0200] = 0x1A // INC A
 
-		cpu.Step()
-		for cpu.Cycles > 0 {
-			cpu.Step()
-		}
-		cpu.Step()
+		cpu.StepInstruction()
 
 		if cpu.A != 0x43 {
 			t.Errorf("Expected A to be 0x43, got 0x%02X", cpu.A)
@@ -316,11 +286,7 @@ func TestINCDECAccumulator(t *testing.T) {
 
 		ram.memory[0x0201] = 0x3A // DEC A
 
-		cpu.Step()
-		for cpu.Cycles > 0 {
-			cpu.Step()
-		}
-		cpu.Step()
+		cpu.StepInstruction()
 
 		if cpu.A != 0x42 {
 			t.Errorf("Expected A to be 0x42, got 0x%02X", cpu.A)
@@ -340,11 +306,7 @@ func TestTSB(t *testing.T) {
 	ram.memory[0x0200] = 0x04 // TSB $50
 	ram.memory[0x0201] = 0x50
 
-	cpu.Step()
-	for cpu.Cycles > 0 {
-		cpu.Step()
-	}
-	cpu.Step()
+	cpu.StepInstruction()
 
 	// Result should be 0xF0 | 0x0F = 0xFF
 	if ram.memory[0x50] != 0xFF {
@@ -370,11 +332,7 @@ func TestTRB(t *testing.T) {
 	ram.memory[0x0200] = 0x14 // TRB $50
 	ram.memory[0x0201] = 0x50
 
-	cpu.Step()
-	for cpu.Cycles > 0 {
-		cpu.Step()
-	}
-	cpu.Step()
+	cpu.StepInstruction()
 
 	// Result should be 0xFF & ~0x0F = 0xF0
 	if ram.memory[0x50] != 0xF0 {
@@ -390,11 +348,7 @@ func TestSTP(t *testing.T) {
 	cpu.PC = 0x0200
 	ram.memory[0x0200] = 0xDB // STP
 
-	cpu.Step()
-	for cpu.Cycles > 0 {
-		cpu.Step()
-	}
-	cpu.Step()
+	cpu.StepInstruction()
 
 	if !cpu.Halted {
 		t.Error("Expected CPU to be halted after STP")
@@ -422,11 +376,423 @@ func TestDecimalClearedOnInterrupt(t *testing.T) {
 	// Trigger IRQ
 	cpu.IRQPending = true
 
-	// Execute interrupt handling - Step() will check cycles and process IRQ
-	cpu.Step()
+	// Execute interrupt handling
+	cpu.StepInstruction()
 
 	// On WDC65C02, decimal mode should be cleared after interrupt
 	if cpu.GetFlag(core.FlagDecimal) {
 		t.Error("Expected decimal flag to be cleared on interrupt (WDC65C02 behavior)")
 	}
 }
+
+// TestReservedNOPs checks every reserved opcode against the byte lengths and
+// cycle counts in opcodes.json, which was taken from the W65C02S datasheet.
+func TestReservedNOPs(t *testing.T) {
+	data, err := os.ReadFile("opcodes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var table struct {
+		Opcodes []struct {
+			Opcode   string `json:"opcode"`
+			Mnemonic string `json:"mnemonic"`
+			Bytes    int    `json:"bytes"`
+			Cycles   int    `json:"cycles"`
+		} `json:"OPCODES"`
+	}
+	if err := json.Unmarshal(data, &table); err != nil {
+		t.Fatal(err)
+	}
+
+	checked := 0
+	for _, op := range table.Opcodes {
+		if op.Mnemonic != "NOP" || op.Opcode == "0xEA" {
+			continue
+		}
+		opcode, err := strconv.ParseUint(op.Opcode, 0, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ram := &SimpleRAM{}
+		cpu := NewCPU(ram)
+		ram.memory[0x0200] = byte(opcode)
+		ram.memory[0x0201] = 0xFF
+		ram.memory[0x0202] = 0x12
+		cpu.PC = 0x0200
+		cpu.X = 0x01
+		status := cpu.Status
+
+		cpu.Step()
+		cycles := 1
+		for cpu.Cycles > 0 {
+			cpu.Step()
+			cycles++
+		}
+
+		if got := int(cpu.PC - 0x0200); got != op.Bytes {
+			t.Errorf("opcode %s: expected %d bytes, got %d", op.Opcode, op.Bytes, got)
+		}
+		if cycles != op.Cycles {
+			t.Errorf("opcode %s: expected %d cycles, got %d", op.Opcode, op.Cycles, cycles)
+		}
+		if cpu.A != 0 || cpu.X != 0x01 || cpu.Y != 0 || cpu.Status != status {
+			t.Errorf("opcode %s: registers changed", op.Opcode)
+		}
+		checked++
+	}
+
+	if checked != 44 {
+		t.Errorf("expected 44 reserved opcodes, checked %d", checked)
+	}
+}
+
+// TestBITIndexed checks the 65C02 BIT zp,X and abs,X modes, which were
+// previously decoded as reserved NOPs.
+func TestBITIndexed(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []byte
+		cycles  int
+	}{
+		{"BIT zp,X", []byte{0x34, 0x4F}, 4},
+		{"BIT abs,X", []byte{0x3C, 0x4F, 0x30}, 4},
+		{"BIT abs,X page cross", []byte{0x3C, 0xFF, 0x30}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ram := &SimpleRAM{}
+			cpu := NewCPU(ram)
+			copy(ram.memory[0x0200:], tt.program)
+			ram.memory[0x0050] = 0xC0
+			ram.memory[0x3050] = 0xC0
+			ram.memory[0x3100] = 0xC0
+			cpu.PC = 0x0200
+			cpu.A = 0x01
+			cpu.X = 0x01
+
+			cpu.Step()
+			cycles := 1
+			for cpu.Cycles > 0 {
+				cpu.Step()
+				cycles++
+			}
+
+			if !cpu.GetFlag(core.FlagNegative) || !cpu.GetFlag(core.FlagOverflow) || !cpu.GetFlag(core.FlagZero) {
+				t.Errorf("expected N, V and Z set, got status 0x%02X", cpu.Status)
+			}
+			if cycles != tt.cycles {
+				t.Errorf("expected %d cycles, got %d", tt.cycles, cycles)
+			}
+			if want := uint16(0x0200 + len(tt.program)); cpu.PC != want {
+				t.Errorf("expected PC 0x%04X, got 0x%04X", want, cpu.PC)
+			}
+		})
+	}
+}
+
+// runUntilWaiting executes WAI at $0200 followed by INX and steps until the
+// CPU has entered the wait state.
+func runUntilWaiting(t *testing.T, cpu *CPU, ram *SimpleRAM) {
+	t.Helper()
+	ram.memory[0x0200] = 0xCB // WAI
+	ram.memory[0x0201] = 0xE8 // INX
+	ram.memory[0xFFFA] = 0x00 // NMI vector $0400
+	ram.memory[0xFFFB] = 0x04
+	ram.memory[0xFFFE] = 0x00 // IRQ vector $0300
+	ram.memory[0xFFFF] = 0x03
+	cpu.PC = 0x0200
+
+	cpu.Step()
+	for cpu.Cycles > 0 {
+		cpu.Step()
+	}
+	if cpu.State != core.StateWaiting {
+		t.Fatalf("expected state %v after WAI, got %v", core.StateWaiting, cpu.State)
+	}
+}
+
+func TestWAISuspendsFetch(t *testing.T) {
+	ram := &SimpleRAM{}
+	cpu := NewCPU(ram)
+	runUntilWaiting(t, cpu, ram)
+
+	for i := 0; i < 100; i++ {
+		cpu.Step()
+	}
+
+	if cpu.X != 0 || cpu.PC != 0x0201 {
+		t.Errorf("expected no execution while waiting, got X=%d PC=0x%04X", cpu.X, cpu.PC)
+	}
+	if !cpu.Idle() {
+		t.Error("expected Idle() while waiting with no interrupt pending")
+	}
+}
+
+func TestWAIWakeUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		nmi, irq  bool
+		interrupt bool // I flag
+		wantPC    uint16
+		wantPush  bool
+	}{
+		{"IRQ with I clear vectors", false, true, false, 0x0300, true},
+		{"IRQ with I set resumes", false, true, true, 0x0201, false},
+		{"NMI vectors", true, false, true, 0x0400, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ram := &SimpleRAM{}
+			cpu := NewCPU(ram)
+			runUntilWaiting(t, cpu, ram)
+			cpu.SetFlag(core.FlagInterruptDisable, tt.interrupt)
+			sp := cpu.SP
+
+			cpu.NMIPending = tt.nmi
+			cpu.IRQPending = tt.irq
+			if cpu.Idle() {
+				t.Fatal("expected Idle() to be false with an interrupt pending")
+			}
+			cpu.StepInstruction()
+
+			if cpu.State != core.StateRunning {
+				t.Errorf("expected state %v, got %v", core.StateRunning, cpu.State)
+			}
+			if tt.wantPush {
+				if cpu.PC != tt.wantPC || cpu.SP != sp-3 {
+					t.Errorf("expected vector to 0x%04X, got PC=0x%04X SP=0x%02X", tt.wantPC, cpu.PC, cpu.SP)
+				}
+				return
+			}
+
+			// Waking up runs the instruction after WAI
+			if cpu.X != 1 || cpu.PC != 0x0202 || cpu.SP != sp {
+				t.Errorf("expected INX to run without vectoring, got X=%d PC=0x%04X SP=0x%02X", cpu.X, cpu.PC, cpu.SP)
+			}
+		})
+	}
+}
+
+func TestWAIReset(t *testing.T) {
+	ram := &SimpleRAM{}
+	cpu := NewCPU(ram)
+	ram.memory[0xFFFC] = 0x00
+	ram.memory[0xFFFD] = 0x80
+	runUntilWaiting(t, cpu, ram)
+
+	cpu.ResetPending = true
+	cpu.StepInstruction()
+
+	if cpu.State != core.StateRunning || cpu.PC != 0x8000 {
+		t.Errorf("expected reset to wake the CPU at 0x8000, got state=%v PC=0x%04X", cpu.State, cpu.PC)
+	}
+}
+
+func TestSkipIdle(t *testing.T) {
+	ram := &SimpleRAM{}
+	cpu := NewCPU(ram)
+	runUntilWaiting(t, cpu, ram)
+
+	start := cpu.TotalCycles
+	if skipped := cpu.SkipIdle(1000); skipped != 1000 || cpu.TotalCycles != start+1000 {
+		t.Errorf("expected 1000 cycles skipped, got %d (TotalCycles +%d)", skipped, cpu.TotalCycles-start)
+	}
+
+	cpu.IRQPending = true
+	if skipped := cpu.SkipIdle(1000); skipped != 0 || cpu.TotalCycles != start+1000 {
+		t.Errorf("expected nothing skipped with an IRQ pending, got %d", skipped)
+	}
+}
+
+// TestOpcodeTable checks the public opcode table against opcodes.json.
+func TestOpcodeTable(t *testing.T) {
+	data, err := os.ReadFile("opcodes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var table struct {
+		Opcodes []struct {
+			Opcode   string `json:"opcode"`
+			Mnemonic string `json:"mnemonic"`
+			Bytes    int    `json:"bytes"`
+			Cycles   int    `json:"cycles"`
+		} `json:"OPCODES"`
+	}
+	if err := json.Unmarshal(data, &table); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range table.Opcodes {
+		opcode, err := strconv.ParseUint(want.Opcode, 0, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		op, ok := LookupOpcode(byte(opcode))
+		if !ok {
+			t.Errorf("opcode %s: not found", want.Opcode)
+			continue
+		}
+		if op.Code != byte(opcode) || op.Mnemonic != want.Mnemonic {
+			t.Errorf("opcode %s: got %s, want %s", want.Opcode, op.Mnemonic, want.Mnemonic)
+		}
+		// The reserved NOPs are the only opcodes the datasheet leaves undefined
+		if op.Illegal != (want.Mnemonic == "NOP" && opcode != 0xEA) {
+			t.Errorf("opcode %s: Illegal=%v", want.Opcode, op.Illegal)
+		}
+
+		// BRK's signature byte is skipped at run time, not decoded
+		if opcode != 0x00 && int(op.Length) != want.Bytes {
+			t.Errorf("opcode %s: expected %d bytes, got %d", want.Opcode, want.Bytes, op.Length)
+		}
+
+		// BRA is always taken; its base count excludes the taken cycle
+		cycles := int(op.Cycles)
+		if opcode == 0x80 {
+			cycles++
+		}
+		if cycles != want.Cycles {
+			t.Errorf("opcode %s: expected %d cycles, got %d", want.Opcode, want.Cycles, cycles)
+		}
+	}
+}
+
+// TestPageCrossPenalty checks the 65C02 page-crossing rules, including the
+// indexed shifts and rotates that only pay when crossing a page.
+func TestPageCrossPenalty(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []byte
+		cycles  int
+	}{
+		{"LDA abs,X same page", []byte{0xBD, 0x00, 0x30}, 4},
+		{"LDA abs,X page cross", []byte{0xBD, 0xFF, 0x30}, 5},
+		{"BIT abs,X page cross", []byte{0x3C, 0xFF, 0x30}, 5},
+		{"STA abs,X page cross", []byte{0x9D, 0xFF, 0x30}, 5},
+		{"ASL abs,X same page", []byte{0x1E, 0x00, 0x30}, 6},
+		{"ASL abs,X page cross", []byte{0x1E, 0xFF, 0x30}, 7},
+		{"INC abs,X page cross", []byte{0xFE, 0xFF, 0x30}, 7},
+		{"JMP (abs)", []byte{0x6C, 0x00, 0x30}, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ram := &SimpleRAM{}
+			cpu := NewCPU(ram)
+			copy(ram.memory[0x0200:], tt.program)
+			cpu.PC = 0x0200
+			cpu.X = 0x01
+
+			cpu.Step()
+			cycles := 1
+			for cpu.Cycles > 0 {
+				cpu.Step()
+				cycles++
+			}
+
+			if cycles != tt.cycles {
+				t.Errorf("expected %d cycles, got %d", tt.cycles, cycles)
+			}
+		})
+	}
+}
+
+func TestStepInstructionWAI(t *testing.T) {
+	ram := &SimpleRAM{}
+	cpu := NewCPU(ram)
+	ram.memory[0xFFFE] = 0x00
+	ram.memory[0xFFFF] = 0x03
+	copy(ram.memory[0x0200:], []byte{0xCB, 0xE8}) // WAI; INX
+	cpu.PC = 0x0200
+	cpu.SetFlag(core.FlagInterruptDisable, false)
+
+	want := core.StepResult{Event: core.EventInstruction, PC: 0x0200, Opcode: 0xCB, Cycles: 3}
+	if got, _ := cpu.StepInstruction(); got != want {
+		t.Errorf("WAI: got %+v, want %+v", got, want)
+	}
+
+	want = core.StepResult{Event: core.EventWaiting, PC: 0x0201, Cycles: 1}
+	for i := 0; i < 3; i++ {
+		if got, _ := cpu.StepInstruction(); got != want {
+			t.Errorf("waiting: got %+v, want %+v", got, want)
+		}
+	}
+
+	cpu.IRQPending = true
+	want = core.StepResult{Event: core.EventIRQ, PC: 0x0201, Cycles: 7}
+	if got, _ := cpu.StepInstruction(); got != want || cpu.PC != 0x0300 {
+		t.Errorf("wake: got %+v, want %+v", got, want)
+	}
+}
+
+func TestStepInstructionSTP(t *testing.T) {
+	ram := &SimpleRAM{}
+	cpu := NewCPU(ram)
+	ram.memory[0x0200] = 0xDB // STP
+	cpu.PC = 0x0200
+
+	if got, err := cpu.StepInstruction(); got.Event != core.EventInstruction || got.Opcode != 0xDB || got.Cycles != 3 || err != nil {
+		t.Errorf("STP: got %+v (%v)", got, err)
+	}
+	if got, err := cpu.StepInstruction(); got.Event != core.EventStopped || got.Cycles != 0 || !errors.Is(err, core.ErrStopped) {
+		t.Errorf("after STP: got %+v (%v)", got, err)
+	}
+	if n, err := cpu.RunCycles(10); n != 0 || !errors.Is(err, core.ErrStopped) {
+		t.Errorf("expected RunCycles to stop on a stopped CPU, ran %d cycles (%v)", n, err)
+	}
+}
+
+func TestNewCPUFromVariant(t *testing.T) {
+	ram := &SimpleRAM{}
+	cpu, err := core.NewCPU(core.VariantWDC65C02, ram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cpu.(*CPU); !ok || cpu.GetVariant() != core.VariantWDC65C02 {
+		t.Fatalf("expected a 65C02 *CPU, got %T", cpu)
+	}
+
+	if _, err := core.NewCPU(core.Variant(99), ram); err == nil {
+		t.Error("expected an error for an unregistered variant")
+	}
+
+	variants := core.Variants()
+	if len(variants) == 0 || variants[len(variants)-1] != core.VariantWDC65C02 {
+		t.Errorf("expected VariantWDC65C02 to be registered, got %v", variants)
+	}
+
+	if op, ok := cpu.LookupOpcode(0x9C); !ok || op.Mnemonic != "STZ" {
+		t.Errorf("expected STZ for $9C, got %q", op.Mnemonic)
+	}
+}
+
+func TestRunReturnsStopped(t *testing.T) {
+	ram := &SimpleRAM{}
+	cpu := NewCPU(ram)
+	copy(ram.memory[0x0200:], []byte{0xE8, 0xDB, 0xE8}) // INX; STP; INX
+	cpu.PC = 0x0200
+
+	err := cpu.Run()
+
+	var stepErr *core.StepError
+	if !errors.As(err, &stepErr) || !errors.Is(err, core.ErrStopped) {
+		t.Fatalf("expected ErrStopped, got %v", err)
+	}
+	if stepErr.PC != 0x0201 || stepErr.Opcode != 0xDB || stepErr.Cycles != 5 {
+		t.Errorf("unexpected error %v", err)
+	}
+	if cpu.X != 1 || cpu.State != core.StateStopped {
+		t.Errorf("expected to stop after one INX, got X=%d state=%v", cpu.X, cpu.State)
+	}
+
+	ram.memory[0xFFFD] = 0x02
+	cpu.TriggerReset()
+	if _, err := cpu.StepInstruction(); err != nil || cpu.State != core.StateRunning {
+		t.Errorf("expected reset to restart the CPU, got %v state=%v", err, cpu.State)
+	}
+}
@@ -6,26 +6,27 @@ import "github.com/andrewthecodertx/go-6502-emulator/pkg/core"
 
 // ADC adds with carry.
 func ADC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	carry := byte(0)
 	if c.GetFlag(core.FlagCarry) {
 		carry = 1
 	}
 
-	result := uint16(c.A) + uint16(data) + uint16(carry)
-	c.SetFlag(core.FlagCarry, result > 0xFF)
-	c.SetFlag(core.FlagOverflow, ((uint16(c.A)^result)&(uint16(data)^result))&0x80 != 0)
-	c.A = byte(result)
-	c.SetZN(c.A)
-
-	if pageCrossed {
-		c.Cycles++
+	if c.GetFlag(core.FlagDecimal) {
+		adcDecimal(c, data, carry)
+		c.DummyRead(addr) // Decimal mode takes one extra cycle on the 65C02
+	} else {
+		result := uint16(c.A) + uint16(data) + uint16(carry)
+		c.SetFlag(core.FlagCarry, result > 0xFF)
+		c.SetFlag(core.FlagOverflow, ((uint16(c.A)^result)&(uint16(data)^result))&0x80 != 0)
+		c.A = byte(result)
+		c.SetZN(c.A)
 	}
 }
 
 // SBC subtracts with carry.
 func SBC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	carry := byte(0)
 	if c.GetFlag(core.FlagCarry) {
 		carry = 1
@@ -34,33 +35,69 @@ func SBC(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 	result := uint16(c.A) - uint16(data) - (1 - uint16(carry))
 	c.SetFlag(core.FlagCarry, result < 0x100)
 	c.SetFlag(core.FlagOverflow, ((uint16(c.A)^result)&(^uint16(data)^result))&0x80 != 0)
-	c.A = byte(result)
+	if c.GetFlag(core.FlagDecimal) {
+		c.A = sbcDecimal(c.A, data, carry)
+		c.DummyRead(addr) // Decimal mode takes one extra cycle on the 65C02
+	} else {
+		c.A = byte(result)
+	}
+	c.SetZN(c.A)
+}
+
+// adcDecimal performs a BCD addition the way the 65C02 does.
+//
+// The accumulator and carry follow sequence 1 of Bruce Clark's "Decimal Mode"
+// tutorial. Unlike the NMOS part, N and Z are valid: they are set from the
+// adjusted accumulator. V is still computed from the intermediate result
+// before the high digit is adjusted (sequence 2).
+func adcDecimal(c *core.BaseCPU, data, carry byte) {
+	low := int(c.A&0x0F) + int(data&0x0F) + int(carry)
+	if low >= 0x0A {
+		low = ((low + 0x06) & 0x0F) + 0x10
+	}
+
+	sum := int(c.A&0xF0) + int(data&0xF0) + low
+	signed := int(int8(c.A&0xF0)) + int(int8(data&0xF0)) + low
+	c.SetFlag(core.FlagOverflow, signed < -128 || signed > 127)
+
+	if sum >= 0xA0 {
+		sum += 0x60
+	}
+	c.SetFlag(core.FlagCarry, sum >= 0x100)
+	c.A = byte(sum)
 	c.SetZN(c.A)
+}
 
-	if pageCrossed {
-		c.Cycles++
+// sbcDecimal returns the accumulator produced by a 65C02 BCD subtraction
+// (sequence 4 of Bruce Clark's "Decimal Mode" tutorial). Carry and overflow
+// come from the binary difference; SBC sets N and Z from the returned value.
+func sbcDecimal(a, data, carry byte) byte {
+	low := int(a&0x0F) - int(data&0x0F) + int(carry) - 1
+	result := int(a) - int(data) + int(carry) - 1
+	if result < 0 {
+		result -= 0x60
 	}
+	if low < 0 {
+		result -= 0x06
+	}
+	return byte(result)
 }
 
 // CMP compares the accumulator.
 func CMP(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	compare(c, c.A, data)
-
-	if pageCrossed {
-		c.Cycles++
-	}
 }
 
 // CPX compares the X register.
 func CPX(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	compare(c, c.X, data)
 }
 
 // CPY compares the Y register.
 func CPY(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	compare(c, c.Y, data)
 }
 
@@ -43,3 +43,17 @@ func CLV(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 func NOP(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 	// Do nothing
 }
+
+// NOPRead is a reserved opcode that reads its operand and discards it.
+func NOPRead(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	c.Read(addr)
+}
+
+// NOPSlow is opcode $5C, which reads from $FFxx, where xx is the low byte
+// of its operand, and then four times from $FFFF.
+func NOPSlow(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	c.DummyRead(0xFF00 | addr&0x00FF)
+	for i := 0; i < 4; i++ {
+		c.DummyRead(0xFFFF)
+	}
+}
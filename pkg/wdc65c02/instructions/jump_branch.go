@@ -10,55 +10,83 @@ func JMP(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 }
 
 // JSR jumps to a subroutine.
+// The high byte of the address is fetched after the return address, which
+// points at it, is pushed.
 func JSR(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	returnAddr := c.PC - 1
-	c.Push(byte(returnAddr >> 8))
-	c.Push(byte(returnAddr))
-	c.PC = addr
+	if c.Stage() == 0 {
+		low := uint16(c.ReadAs(core.AccessOperand, c.PC))
+		c.PC++
+		c.DummyStackRead()
+		returnAddr := c.PC
+		c.Push(byte(returnAddr >> 8))
+		c.Push(byte(returnAddr))
+		c.Continue(low)
+		return
+	}
+	high := uint16(c.ReadAs(core.AccessOperand, c.PC))
+	c.PC = (high << 8) | addr
 }
 
 // RTS returns from a subroutine.
 func RTS(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	low := uint16(c.Pull())
-	high := uint16(c.Pull())
-	c.PC = ((high << 8) | low) + 1
+	switch c.Stage() {
+	case 0:
+		c.DummyStackRead()
+		c.Continue(0)
+	case 1:
+		c.Continue(uint16(c.Pull()))
+	default:
+		high := uint16(c.Pull())
+		c.PC = (high << 8) | addr
+		c.DummyRead(c.PC) // Read while incrementing past the JSR
+		c.PC++
+	}
 }
 
 // RTI returns from an interrupt.
 func RTI(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	c.Status = c.Pull()
-	c.SetFlag(core.FlagUnused, true)  // Unused flag always set
-	c.SetFlag(core.FlagBreak, false) // B flag not actually stored
-	low := uint16(c.Pull())
-	high := uint16(c.Pull())
-	c.PC = (high << 8) | low
+	switch c.Stage() {
+	case 0:
+		c.DummyStackRead()
+		c.Continue(0)
+	case 1:
+		c.Status = c.Pull()
+		c.SetFlag(core.FlagUnused, true)  // Unused flag always set
+		c.SetFlag(core.FlagBreak, false) // B flag not actually stored
+		c.Continue(0)
+	case 2:
+		c.Continue(uint16(c.Pull()))
+	default:
+		high := uint16(c.Pull())
+		c.PC = (high << 8) | addr
+	}
 }
 
 // BRK executes a software interrupt.
 func BRK(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	c.PC++
-	c.Push(byte(c.PC >> 8))
-	c.Push(byte(c.PC))
-	c.Push(c.Status | core.FlagBreak | core.FlagUnused)
-	c.SetFlag(core.FlagInterruptDisable, true)
-
-	// WDC65C02: Clear decimal mode on interrupt
-	c.SetFlag(core.FlagDecimal, false)
-
-	low := uint16(c.Bus.Read(0xFFFE))
-	high := uint16(c.Bus.Read(0xFFFF))
-	c.PC = (high << 8) | low
+	switch c.Stage() {
+	case 0:
+		c.PC++
+		c.Push(byte(c.PC >> 8))
+		c.Push(byte(c.PC))
+		c.Push(c.Status | core.FlagBreak | core.FlagUnused)
+		c.SetFlag(core.FlagInterruptDisable, true)
+
+		// WDC65C02: Clear decimal mode on interrupt
+		c.SetFlag(core.FlagDecimal, false)
+		c.Continue(0)
+	case 1:
+		c.Continue(uint16(c.ReadAs(core.AccessVector, 0xFFFE)))
+	default:
+		high := uint16(c.ReadAs(core.AccessVector, 0xFFFF))
+		c.PC = (high << 8) | addr
+	}
 }
 
 // Branch helper function
 func branch(c *core.BaseCPU, condition bool, addr uint16) {
 	if condition {
-		oldPC := c.PC
-		c.PC = addr
-		c.Cycles++ // +1 cycle if branch taken
-		if (oldPC & 0xFF00) != (c.PC & 0xFF00) {
-			c.Cycles++ // +1 more cycle if page crossed
-		}
+		c.Branch(addr) // +1 cycle if taken, +1 more if page crossed
 	}
 }
 
@@ -105,10 +133,5 @@ func BVS(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 // BRA branches always (unconditional relative branch).
 // NEW instruction in WDC65C02 - always takes the branch.
 func BRA(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	oldPC := c.PC
-	c.PC = addr
-	c.Cycles++ // +1 cycle for taken branch
-	if (oldPC & 0xFF00) != (c.PC & 0xFF00) {
-		c.Cycles++ // +1 more cycle if page crossed
-	}
+	c.Branch(addr)
 }
@@ -6,40 +6,28 @@ import "github.com/andrewthecodertx/go-6502-emulator/pkg/core"
 
 // AND performs a bitwise AND.
 func AND(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	c.A &= data
 	c.SetZN(c.A)
-
-	if pageCrossed {
-		c.Cycles++
-	}
 }
 
 // ORA performs a bitwise OR.
 func ORA(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	c.A |= data
 	c.SetZN(c.A)
-
-	if pageCrossed {
-		c.Cycles++
-	}
 }
 
 // EOR performs a bitwise XOR.
 func EOR(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	c.A ^= data
 	c.SetZN(c.A)
-
-	if pageCrossed {
-		c.Cycles++
-	}
 }
 
 // BIT tests bits.
 func BIT(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	c.SetFlag(core.FlagNegative, data&0x80 != 0) // Negative from bit 7
 	c.SetFlag(core.FlagOverflow, data&0x40 != 0) // Overflow from bit 6
 	c.SetFlag(core.FlagZero, data&c.A == 0)      // Zero if AND is zero
@@ -50,9 +38,9 @@ func BIT(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 // Sets the Z flag based on the result of the bitwise AND of A and memory.
 // Then sets bits in memory that are set in A (memory = memory OR A).
 func TSB(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	c.SetFlag(core.FlagZero, (data&c.A) == 0)
-	c.Bus.Write(addr, data|c.A)
+	c.WriteModified(addr, data, data|c.A)
 }
 
 // TRB tests and resets bits in memory.
@@ -60,7 +48,7 @@ func TSB(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 // Sets the Z flag based on the result of the bitwise AND of A and memory.
 // Then clears bits in memory that are set in A (memory = memory AND NOT A).
 func TRB(c *core.BaseCPU, addr uint16, pageCrossed bool) {
-	data := c.Bus.Read(addr)
+	data := c.Read(addr)
 	c.SetFlag(core.FlagZero, (data&c.A) == 0)
-	c.Bus.Write(addr, data&^c.A)
+	c.WriteModified(addr, data, data&^c.A)
 }
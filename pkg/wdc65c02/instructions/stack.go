@@ -11,6 +11,11 @@ func PHA(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 
 // PLA pulls the accumulator from the stack.
 func PLA(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	if c.Stage() == 0 {
+		c.DummyStackRead()
+		c.Continue(0)
+		return
+	}
 	c.A = c.Pull()
 	c.SetZN(c.A)
 }
@@ -23,6 +28,11 @@ func PHP(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 
 // PLP pulls the status register from the stack.
 func PLP(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	if c.Stage() == 0 {
+		c.DummyStackRead()
+		c.Continue(0)
+		return
+	}
 	c.Status = c.Pull()
 	c.SetFlag(core.FlagUnused, true)  // Unused flag always set
 	c.SetFlag(core.FlagBreak, false) // B flag not actually stored
@@ -43,6 +53,11 @@ func PHY(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 // PLX pulls the X register from the stack.
 // NEW instruction in WDC65C02.
 func PLX(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	if c.Stage() == 0 {
+		c.DummyStackRead()
+		c.Continue(0)
+		return
+	}
 	c.X = c.Pull()
 	c.SetZN(c.X)
 }
@@ -50,6 +65,11 @@ func PLX(c *core.BaseCPU, addr uint16, pageCrossed bool) {
 // PLY pulls the Y register from the stack.
 // NEW instruction in WDC65C02.
 func PLY(c *core.BaseCPU, addr uint16, pageCrossed bool) {
+	if c.Stage() == 0 {
+		c.DummyStackRead()
+		c.Continue(0)
+		return
+	}
 	c.Y = c.Pull()
 	c.SetZN(c.Y)
 }